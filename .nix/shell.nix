@@ -10,6 +10,10 @@ in pkgs.mkShell {
 		gdk-pixbuf
 		gobjectIntrospection
 		libhandy
+		gst_all_1.gstreamer
+		gst_all_1.gst-plugins-base
+		gst_all_1.gst-plugins-good # gtksink
+		gst_all_1.gst-libav
 	];
 
 	nativeBuildInputs = with pkgs; [
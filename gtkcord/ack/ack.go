@@ -0,0 +1,125 @@
+// Package ack marks channels as read, acking many channels at once in batches
+// so that marking a whole guild or everything doesn't hit the rate limits.
+package ack
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/ningen/v2"
+	"github.com/pkg/errors"
+)
+
+// BatchSize is how many channels are acked in one request.
+const BatchSize = 100
+
+// BatchDelay is how long to wait between two batches.
+const BatchDelay = time.Second
+
+type readState struct {
+	ChannelID discord.ChannelID `json:"channel_id"`
+	MessageID discord.MessageID `json:"message_id"`
+}
+
+type bulkAckData struct {
+	ReadStates []readState `json:"read_states"`
+}
+
+// Unread returns true if the channel has messages that weren't read.
+func Unread(s *ningen.State, ch discord.Channel) bool {
+	if !ch.LastMessageID.IsValid() {
+		return false
+	}
+
+	rs := s.ReadState.FindLast(ch.ID)
+	return rs != nil && rs.LastMessageID < ch.LastMessageID
+}
+
+// Channel marks the channel as read up to its last message. The ack is sent in
+// the background.
+func Channel(s *ningen.State, ch discord.Channel) {
+	if Unread(s, ch) {
+		s.ReadState.MarkRead(ch.ID, ch.LastMessageID)
+	}
+}
+
+// Guild marks all channels in the guild as read. Threads aren't in the state,
+// so they have to be given.
+func Guild(s *ningen.State, guildID discord.GuildID, threads ...discord.Channel) error {
+	channels, err := s.Channels(guildID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get guild channels")
+	}
+
+	return Channels(s, append(channels, threads...))
+}
+
+// All marks every direct message and every channel in every guild as read.
+func All(s *ningen.State, threads ...discord.Channel) error {
+	channels, err := s.PrivateChannels()
+	if err != nil {
+		return errors.Wrap(err, "failed to get private channels")
+	}
+
+	guilds, err := s.Guilds()
+	if err != nil {
+		return errors.Wrap(err, "failed to get guilds")
+	}
+
+	for _, guild := range guilds {
+		guildChannels, err := s.Channels(guild.ID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get guild channels")
+		}
+
+		channels = append(channels, guildChannels...)
+	}
+
+	return Channels(s, append(channels, threads...))
+}
+
+// Channels marks the unread channels as read. It blocks until every batch is
+// acked, so it should be called in a goroutine.
+func Channels(s *ningen.State, channels []discord.Channel) error {
+	var states []readState
+
+	for _, ch := range channels {
+		if Unread(s, ch) {
+			states = append(states, readState{ch.ID, ch.LastMessageID})
+		}
+	}
+
+	for len(states) > 0 {
+		batch := states
+		if len(batch) > BatchSize {
+			batch = batch[:BatchSize]
+		}
+		states = states[len(batch):]
+
+		err := s.Client.Client.FastRequest(
+			"POST", api.Endpoint+"read-states/ack-bulk",
+			httputil.WithJSONBody(bulkAckData{batch}),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to ack channels")
+		}
+
+		// The acks are already sent, so this only updates the read state and
+		// everything that shows it.
+		for _, rs := range batch {
+			s.State.Handler.Call(&gateway.MessageAckEvent{
+				ChannelID: rs.ChannelID,
+				MessageID: rs.MessageID,
+			})
+		}
+
+		if len(states) > 0 {
+			time.Sleep(BatchDelay)
+		}
+	}
+
+	return nil
+}
@@ -44,7 +44,9 @@ func FilterChannels(s *ningen.State, chs []discord.Channel) []discord.Channel {
 		case discord.DirectMessage,
 			discord.GuildText,
 			discord.GuildCategory,
-			discord.GroupDM:
+			discord.GroupDM,
+			discord.GuildVoice,
+			GuildStageVoice:
 
 		default:
 			continue
@@ -57,6 +59,19 @@ func FilterChannels(s *ningen.State, chs []discord.Channel) []discord.Channel {
 }
 
 func transformChannels(s *ningen.State, chs []discord.Channel) []*Channel {
+	sorted := SortChannels(chs)
+	channels := make([]*Channel, len(sorted))
+
+	for i := range sorted {
+		channels[i] = createChannelRead(&sorted[i], s)
+	}
+
+	return channels
+}
+
+// SortChannels sorts the channels in the order that they're listed in, with
+// each category followed by its channels.
+func SortChannels(chs []discord.Channel) []discord.Channel {
 	tree := map[discord.ChannelID]*sortStructure{}
 
 	for _, ch := range chs {
@@ -95,7 +110,12 @@ func transformChannels(s *ningen.State, chs []discord.Channel) []*Channel {
 
 	for _, v := range tree {
 		if v.children != nil {
+			// Voice channels go after text channels, like Discord.
 			sort.SliceStable(v.children, func(i, j int) bool {
+				vi, vj := IsVoice(v.children[i].Type), IsVoice(v.children[j].Type)
+				if vi != vj {
+					return vj
+				}
 				return v.children[i].Position < v.children[j].Position
 			})
 		}
@@ -107,22 +127,30 @@ func transformChannels(s *ningen.State, chs []discord.Channel) []*Channel {
 		return list[i].parent.Position < list[j].parent.Position
 	})
 
+	// Channels without a category go first, text before voice.
+	rank := func(v *sortStructure) int {
+		switch {
+		case v.children != nil:
+			return 2
+		case IsVoice(v.parent.Type):
+			return 1
+		default:
+			return 0
+		}
+	}
+
 	sort.SliceStable(list, func(i, j int) bool {
-		return list[i].children == nil
+		return rank(list[i]) < rank(list[j])
 	})
 
-	channels := make([]*Channel, 0, len(chs))
-
-	for i := range list {
-		sch := list[i]
+	channels := make([]discord.Channel, 0, len(chs))
 
+	for _, sch := range list {
 		if sch.parent.ID.IsValid() {
-			channels = append(channels, createChannelRead(&sch.parent, s))
+			channels = append(channels, sch.parent)
 		}
 
-		for i := range sch.children {
-			channels = append(channels, createChannelRead(&sch.children[i], s))
-		}
+		channels = append(channels, sch.children...)
 	}
 
 	return channels
@@ -8,6 +8,8 @@ import (
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gotk4/pkg/pango"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
 	"github.com/diamondburned/gtkcord3/internal/log"
 	"github.com/diamondburned/ningen/v2"
 )
@@ -19,12 +21,22 @@ type Channel struct {
 	Style *gtk.StyleContext
 
 	Label *gtk.Label
-
-	ID       discord.ChannelID
-	Guild    discord.GuildID
+	// Participants is only in voice channels.
+	Participants *Participants
+	// Thread is only in threads.
+	Thread *threads.Thread
+
+	ID    discord.ChannelID
+	Guild discord.GuildID
+	// Parent is the category of the channel, or the channel of the thread.
+	Parent   discord.ChannelID
 	Name     string
 	Topic    string
 	Category bool
+	// Collapsed is only true in categories.
+	Collapsed bool
+
+	expander *gtk.Image
 
 	stateClass string
 	unread     bool
@@ -33,11 +45,26 @@ type Channel struct {
 func createChannelRead(ch *discord.Channel, s *ningen.State) (w *Channel) {
 	w = newChannel(ch)
 
+	if IsVoice(ch.Type) {
+		return
+	}
+
 	if ch.Type == discord.GuildCategory {
+		if s.MutedState.Channel(ch.ID) {
+			w.stateClass = "muted"
+			w.Style.AddClass("muted")
+		}
 		return
 	}
 
-	if s.MutedState.Channel(ch.ID) {
+	w.loadReadState(ch, s)
+	return
+}
+
+// loadReadState marks the channel as muted, unread or pinged. Channels in
+// muted categories are muted too.
+func (w *Channel) loadReadState(ch *discord.Channel, s *ningen.State) {
+	if notifications.ChannelMuted(s, ch.ID) {
 		w.stateClass = "muted"
 		w.Style.AddClass("muted")
 		return
@@ -62,8 +89,6 @@ func createChannelRead(ch *discord.Channel, s *ningen.State) (w *Channel) {
 			w.Style.AddClass(w.stateClass)
 		}
 	}
-
-	return
 }
 
 func newChannel(ch *discord.Channel) *Channel {
@@ -72,6 +97,8 @@ func newChannel(ch *discord.Channel) *Channel {
 		return newChannelRow(ch)
 	case discord.GuildCategory:
 		return newCategory(ch)
+	case discord.GuildVoice, GuildStageVoice:
+		return newVoiceChannelRow(ch)
 	}
 
 	log.Panicln("Unknown channel type", ch.Type)
@@ -85,17 +112,27 @@ func newCategory(ch *discord.Channel) (chw *Channel) {
 	l.Show()
 	l.SetUseMarkup(true)
 	l.SetXAlign(0.0)
-	l.SetMarginStart(8)
-	l.SetMarginTop(8)
 	l.SetEllipsize(pango.EllipsizeEnd)
 	l.SetSingleLineMode(true)
 	l.SetMaxWidthChars(40)
 
+	expander := gtk.NewImage()
+	expander.SetOpacity(0.5)
+	expander.Show()
+
+	b := gtk.NewBox(gtk.OrientationHorizontal, 2)
+	b.SetMarginStart(4)
+	b.SetMarginTop(8)
+	b.Add(expander)
+	b.Add(l)
+	b.Show()
+
+	// Clicking on the category collapses it.
 	r := gtk.NewListBoxRow()
 	r.Show()
 	r.SetSelectable(false)
-	r.SetSensitive(false)
-	r.Add(l)
+	r.SetTooltipText("Click to collapse or expand")
+	r.Add(b)
 
 	s := r.StyleContext()
 	s.AddClass("category")
@@ -111,8 +148,10 @@ func newCategory(ch *discord.Channel) (chw *Channel) {
 		Name:     ch.Name,
 		Topic:    ch.Topic,
 		Category: true,
+		expander: expander,
 	}
 
+	chw.setCollapsed(IsCollapsed(ch.GuildID, ch.ID))
 	return chw
 }
 
@@ -156,6 +195,7 @@ func newChannelRow(ch *discord.Channel) (chw *Channel) {
 		Label:    l,
 		ID:       ch.ID,
 		Guild:    ch.GuildID,
+		Parent:   ch.CategoryID,
 		Name:     ch.Name,
 		Topic:    ch.Topic,
 		Category: false,
@@ -2,9 +2,12 @@ package channel
 
 import (
 	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
 	"github.com/diamondburned/gtkcord3/gtkcord/cache"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/loadstatus"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
+	"github.com/diamondburned/gtkcord3/internal/log"
 	"github.com/diamondburned/ningen/v2"
 	"github.com/diamondburned/ningen/v2/states/read"
 
@@ -33,7 +36,8 @@ type Channels struct {
 	Channels []*Channel
 	Selected *Channel
 
-	state *ningen.State
+	state   *ningen.State
+	threads *threads.State
 
 	OnSelect func(ch *Channel)
 	GuildID  discord.GuildID
@@ -41,7 +45,9 @@ type Channels struct {
 	lastSelected map[discord.GuildID]discord.ChannelID
 }
 
-func NewChannels(state *ningen.State, onSelect func(ch *Channel)) (chs *Channels) {
+func NewChannels(
+	state *ningen.State, threadState *threads.State, onSelect func(ch *Channel)) (chs *Channels) {
+
 	main := gtk.NewBox(gtk.OrientationVertical, 0)
 	main.Show()
 
@@ -66,6 +72,7 @@ func NewChannels(state *ningen.State, onSelect func(ch *Channel)) (chs *Channels
 		Main:         main,
 		ChList:       cl,
 		state:        state,
+		threads:      threadState,
 		OnSelect:     onSelect,
 		lastSelected: make(map[discord.GuildID]discord.ChannelID),
 	}
@@ -75,15 +82,62 @@ func NewChannels(state *ningen.State, onSelect func(ch *Channel)) (chs *Channels
 			return
 		}
 
-		chs.Selected = chs.Channels[r.Index()]
+		ch := chs.Channels[r.Index()]
+		if ch.Category {
+			chs.toggleCategory(ch)
+			return
+		}
+
+		chs.Selected = ch
 		chs.lastSelected[chs.GuildID] = chs.Selected.ID
+		chs.updateCollapsed()
 		chs.OnSelect(chs.Selected)
 	})
 
+	cl.Connect("button-press-event", chs.onButtonPress)
+
 	state.ReadState.OnUpdate(func(rs *read.UpdateEvent) {
 		glib.IdleAdd(func() { chs.TraverseReadState(rs) })
 	})
 
+	state.AddHandler(func(ev *gateway.VoiceStateUpdateEvent) {
+		glib.IdleAdd(func() {
+			if ev.GuildID == chs.GuildID {
+				chs.UpdateVoiceStates()
+			}
+		})
+	})
+
+	// Participants that weren't cached are shown by their IDs until they're
+	// fetched.
+	state.AddHandler(func(ev *gateway.GuildMembersChunkEvent) {
+		if !inVoice(state, ev.GuildID, ev.Members) {
+			return
+		}
+
+		glib.IdleAdd(func() {
+			if ev.GuildID == chs.GuildID {
+				chs.UpdateVoiceStates()
+			}
+		})
+	})
+
+	state.AddHandler(func(ev *gateway.UserGuildSettingsUpdateEvent) {
+		glib.IdleAdd(func() {
+			if ev.GuildID == chs.GuildID {
+				chs.UpdateMuted()
+			}
+		})
+	})
+
+	threadState.OnUpdate(func(guildID discord.GuildID) {
+		glib.IdleAdd(func() {
+			if guildID == chs.GuildID {
+				chs.reloadThreads(guildID)
+			}
+		})
+	})
+
 	return
 }
 
@@ -106,6 +160,12 @@ func (chs *Channels) onError(err error) {
 	chs.Page.SetError("Error", err)
 }
 
+// Remember makes the channel the one that's opened the next time the guild is
+// loaded.
+func (chs *Channels) Remember(guildID discord.GuildID, chID discord.ChannelID) {
+	chs.lastSelected[guildID] = chID
+}
+
 func (chs *Channels) LoadGuild(guildID discord.GuildID) { // async
 	chs.Cleanup()
 	chs.SetLoading()
@@ -123,6 +183,12 @@ func (chs *Channels) LoadGuild(guildID discord.GuildID) { // async
 		}
 		channels = FilterChannels(chs.state, channels)
 
+		// Threads are optional, so the channels are still shown without them.
+		active, err := chs.threads.Active(guildID)
+		if err != nil {
+			log.Errorln("failed to get active threads:", err)
+		}
+
 		var bannerURL string
 
 		guild, err := chs.state.Guild(chs.GuildID)
@@ -139,11 +205,15 @@ func (chs *Channels) LoadGuild(guildID discord.GuildID) { // async
 
 			chs.SetDone()
 			chs.Channels = transformChannels(chs.state, channels)
+			chs.Channels = nestThreads(chs.state, chs.Channels, active)
 
 			for _, ch := range chs.Channels {
 				chs.ChList.Insert(ch, -1)
 			}
 
+			chs.UpdateVoiceStates()
+			chs.updateCollapsed()
+
 			if lastChID := chs.lastSelected[guildID]; lastChID.IsValid() {
 				lastCh := chs.FindByID(lastChID)
 				if lastCh != nil {
@@ -180,9 +250,41 @@ func (chs *Channels) FindByID(id discord.ChannelID) *Channel {
 	return nil
 }
 
+// inVoice returns true if any of the members are in a voice channel in the
+// guild.
+func inVoice(state *ningen.State, guildID discord.GuildID, members []discord.Member) bool {
+	for _, m := range members {
+		if vs, err := state.Cabinet.VoiceState(guildID, m.User.ID); err == nil && vs.ChannelID.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateVoiceStates shows who is connected to each voice channel.
+func (chs *Channels) UpdateVoiceStates() {
+	states, err := chs.state.Cabinet.VoiceStates(chs.GuildID)
+	if err != nil {
+		log.Errorln("failed to get voice states:", err)
+		return
+	}
+
+	connected := map[discord.ChannelID][]discord.VoiceState{}
+	for _, vs := range states {
+		connected[vs.ChannelID] = append(connected[vs.ChannelID], vs)
+	}
+
+	for _, ch := range chs.Channels {
+		if ch.Participants != nil {
+			ch.Participants.Update(chs.state, chs.GuildID, connected[ch.ID])
+			chs.updateVisibility(ch)
+		}
+	}
+}
+
 func (chs *Channels) First() *Channel {
 	for _, ch := range chs.Channels {
-		if ch.Category {
+		if ch.Category || ch.Participants != nil {
 			continue
 		}
 		return ch
@@ -197,6 +299,42 @@ func (chs *Channels) TraverseReadState(rs *read.UpdateEvent) {
 		}
 
 		ch.setUnread(rs.Unread, rs.MentionCount > 0)
+		chs.updateVisibility(ch)
 		break
 	}
 }
+
+// UpdateMuted marks the channels as muted or unread again after the mute
+// settings change.
+func (chs *Channels) UpdateMuted() {
+	for _, ch := range chs.Channels {
+		if ch.Participants != nil {
+			continue
+		}
+
+		if ch.stateClass != "" {
+			ch.Style.RemoveClass(ch.stateClass)
+			ch.stateClass = ""
+		}
+		ch.unread = false
+
+		if ch.Category {
+			if chs.state.MutedState.Channel(ch.ID) {
+				ch.setClass("muted")
+			}
+			continue
+		}
+
+		// Threads might not be in the state.
+		if ch.Thread != nil {
+			ch.loadReadState(&ch.Thread.Channel, chs.state)
+			continue
+		}
+
+		if dch, err := chs.state.Cabinet.Channel(ch.ID); err == nil {
+			ch.loadReadState(dch, chs.state)
+		}
+	}
+
+	chs.updateCollapsed()
+}
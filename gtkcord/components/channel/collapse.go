@@ -0,0 +1,154 @@
+package channel
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/config"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+// CollapseFile is the file in the config directory that the collapsed
+// categories of each guild are saved to.
+const CollapseFile = "categories.json"
+
+// collapsed is keyed by guild IDs. It's only used in the main thread.
+var collapsed map[discord.GuildID][]discord.ChannelID
+
+func loadCollapsed() map[discord.GuildID][]discord.ChannelID {
+	if collapsed == nil {
+		collapsed = make(map[discord.GuildID][]discord.ChannelID)
+		if err := config.UnmarshalFromFile(CollapseFile, &collapsed); err != nil {
+			log.Errorln("failed to load collapsed categories:", err)
+		}
+	}
+	return collapsed
+}
+
+func saveCollapsed() {
+	if err := config.MarshalToFile(CollapseFile, collapsed); err != nil {
+		log.Errorln("failed to save collapsed categories:", err)
+	}
+}
+
+// IsCollapsed returns true if the category is collapsed.
+func IsCollapsed(guildID discord.GuildID, categoryID discord.ChannelID) bool {
+	for _, id := range loadCollapsed()[guildID] {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCollapsed collapses or expands the categories and saves it.
+func SetCollapsed(guildID discord.GuildID, collapse bool, categoryIDs ...discord.ChannelID) {
+	c := loadCollapsed()
+
+	changing := make(map[discord.ChannelID]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		changing[id] = true
+	}
+
+	ids := c[guildID][:0]
+	for _, id := range c[guildID] {
+		if !changing[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	if collapse {
+		ids = append(ids, categoryIDs...)
+	}
+
+	if len(ids) == 0 {
+		delete(c, guildID)
+	} else {
+		c[guildID] = ids
+	}
+
+	saveCollapsed()
+}
+
+// setCollapsed changes the arrow of the category row.
+func (ch *Channel) setCollapsed(collapsed bool) {
+	ch.Collapsed = collapsed
+
+	if collapsed {
+		ch.expander.SetFromIconName("pan-end-symbolic", int(gtk.IconSizeMenu))
+	} else {
+		ch.expander.SetFromIconName("pan-down-symbolic", int(gtk.IconSizeMenu))
+	}
+}
+
+// toggleCategory collapses or expands the category that was clicked.
+func (chs *Channels) toggleCategory(category *Channel) {
+	category.setCollapsed(!category.Collapsed)
+	SetCollapsed(chs.GuildID, category.Collapsed, category.ID)
+	chs.updateCollapsed()
+}
+
+// SetAllCollapsed collapses or expands every category in the guild, which
+// doesn't have to be the one that's shown.
+func (chs *Channels) SetAllCollapsed(guildID discord.GuildID, collapse bool) {
+	channels, err := chs.state.Channels(guildID)
+	if err != nil {
+		log.Errorln("failed to get guild channels:", err)
+		return
+	}
+
+	var categoryIDs []discord.ChannelID
+	for _, ch := range channels {
+		if ch.Type == discord.GuildCategory {
+			categoryIDs = append(categoryIDs, ch.ID)
+		}
+	}
+
+	SetCollapsed(guildID, collapse, categoryIDs...)
+
+	if guildID != chs.GuildID {
+		return
+	}
+
+	for _, ch := range chs.Channels {
+		if ch.Category {
+			ch.setCollapsed(collapse)
+		}
+	}
+
+	chs.updateCollapsed()
+}
+
+// updateCollapsed hides the channels in collapsed categories. Unread channels,
+// voice channels with people in them and the selected channel are still shown.
+func (chs *Channels) updateCollapsed() {
+	for _, ch := range chs.Channels {
+		chs.updateVisibility(ch)
+	}
+}
+
+func (chs *Channels) updateVisibility(ch *Channel) {
+	if ch.Category {
+		return
+	}
+
+	category := chs.FindByID(ch.categoryID(chs))
+	connected := ch.Participants != nil && ch.Participants.Visible()
+	hidden := category != nil && category.Collapsed &&
+		ch != chs.Selected && !ch.unread && !connected
+
+	ch.Row.SetVisible(!hidden)
+}
+
+// categoryID returns the ID of the category that the channel is in. Threads
+// are in the category of their parent channel.
+func (ch *Channel) categoryID(chs *Channels) discord.ChannelID {
+	if ch.Thread == nil {
+		return ch.Parent
+	}
+
+	if parent := chs.FindByID(ch.Parent); parent != nil {
+		return parent.Parent
+	}
+
+	return 0
+}
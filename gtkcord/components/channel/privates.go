@@ -21,8 +21,12 @@ type PrivateChannels struct {
 	List   *gtk.ListBox
 	Scroll *gtk.ScrolledWindow
 
-	Search *gtk.Entry
-	search string
+	Search  *gtk.Entry
+	search  string
+	Friends *gtk.Button
+
+	// OnFriends is called when the Friends button is clicked.
+	OnFriends func()
 
 	Channels map[discord.ChannelID]*PrivateChannel
 
@@ -45,7 +49,15 @@ func NewPrivateChannels(s *ningen.State, onSelect func(pm *PrivateChannel)) (pcs
 	e := gtk.NewEntry()
 	e.SetPlaceholderText("Find conversation...")
 
+	friendsIcon := gtk.NewImageFromIconName("system-users-symbolic", int(gtk.IconSizeButton))
+
+	f := gtk.NewButtonWithLabel("Friends")
+	f.SetImage(friendsIcon)
+	f.SetAlwaysShowImage(true)
+	f.SetRelief(gtk.ReliefNone)
+
 	b := gtk.NewBox(gtk.OrientationVertical, 0)
+	b.Add(f)
 	b.Add(e)
 	b.Add(cs)
 	b.ShowAll()
@@ -54,11 +66,12 @@ func NewPrivateChannels(s *ningen.State, onSelect func(pm *PrivateChannel)) (pcs
 	page.SetChild(b)
 
 	pcs = &PrivateChannels{
-		Page:   page,
-		Main:   b,
-		List:   l,
-		Scroll: cs,
-		Search: e,
+		Page:    page,
+		Main:    b,
+		List:    l,
+		Scroll:  cs,
+		Search:  e,
+		Friends: f,
 
 		state:    s,
 		OnSelect: onSelect,
@@ -69,6 +82,12 @@ func NewPrivateChannels(s *ningen.State, onSelect func(pm *PrivateChannel)) (pcs
 		pcs.List.InvalidateFilter()
 	})
 
+	f.Connect("clicked", func() {
+		if pcs.OnFriends != nil {
+			pcs.OnFriends()
+		}
+	})
+
 	l.SetFilterFunc(pcs.filter)
 	l.SetSortFunc(pcs.sort)
 
@@ -101,6 +120,12 @@ func (pcs *PrivateChannels) Cleanup() {
 	pcs.Channels = nil
 }
 
+// Remember makes the channel the one that's opened the next time the direct
+// messages are loaded.
+func (pcs *PrivateChannels) Remember(chID discord.ChannelID) {
+	pcs.lastSelected = chID
+}
+
 func (pcs *PrivateChannels) Load() {
 	pcs.Cleanup()
 	pcs.SetLoading()
@@ -277,7 +302,8 @@ func (pcs *PrivateChannels) TraverseReadState(rs *read.UpdateEvent) {
 
 	pcs.List.InvalidateSort()
 
-	pc.setUnread(rs.Unread)
+	// Muted DMs are never shown as unread, like when they're first loaded.
+	pc.setUnread(rs.Unread && !pcs.state.MutedState.Channel(pc.ID))
 }
 
 func (pcs *PrivateChannels) FindByID(id discord.ChannelID) *PrivateChannel {
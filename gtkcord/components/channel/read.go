@@ -0,0 +1,80 @@
+package channel
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/ack"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+// markReadItem marks the channel as read, or every channel in it if it's a
+// category.
+func (chs *Channels) markReadItem(ch *Channel) *gtk.MenuItem {
+	channels := chs.discordChannels(ch)
+
+	item := gtk.NewMenuItemWithLabel("Mark As Read")
+	item.SetSensitive(chs.hasUnread(channels))
+	item.Connect("activate", func() {
+		if len(channels) == 1 {
+			ack.Channel(chs.state, channels[0])
+			return
+		}
+
+		go func() {
+			if err := ack.Channels(chs.state, channels); err != nil {
+				log.Errorln("failed to mark category as read:", err)
+			}
+		}()
+	})
+	item.Show()
+
+	return item
+}
+
+// discordChannels returns the channel, or the channels and threads in the
+// category.
+func (chs *Channels) discordChannels(ch *Channel) []discord.Channel {
+	if !ch.Category {
+		if c, ok := chs.discordChannel(ch); ok {
+			return []discord.Channel{c}
+		}
+		return nil
+	}
+
+	var channels []discord.Channel
+
+	for _, child := range chs.Channels {
+		if child.Category || child.categoryID(chs) != ch.ID {
+			continue
+		}
+
+		if c, ok := chs.discordChannel(child); ok {
+			channels = append(channels, c)
+		}
+	}
+
+	return channels
+}
+
+// discordChannel gets the channel from the state. Threads aren't in the state.
+func (chs *Channels) discordChannel(ch *Channel) (discord.Channel, bool) {
+	if ch.Thread != nil {
+		return ch.Thread.Channel, true
+	}
+
+	c, err := chs.state.Cabinet.Channel(ch.ID)
+	if err != nil {
+		return discord.Channel{}, false
+	}
+
+	return *c, true
+}
+
+func (chs *Channels) hasUnread(channels []discord.Channel) bool {
+	for _, ch := range channels {
+		if ack.Unread(chs.state, ch) {
+			return true
+		}
+	}
+	return false
+}
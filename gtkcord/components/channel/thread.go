@@ -0,0 +1,207 @@
+package channel
+
+import (
+	"html"
+	"sort"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/notifmenu"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+func createThreadRead(t threads.Thread, s *ningen.State) *Channel {
+	w := newThreadRow(t)
+	w.loadReadState(&t.Channel, s)
+	return w
+}
+
+func newThreadRow(t threads.Thread) *Channel {
+	icon := gtk.NewImageFromIconName("mail-reply-sender-symbolic", int(gtk.IconSizeMenu))
+	icon.SetVAlign(gtk.AlignCenter)
+	icon.SetMarginStart(24)
+	icon.SetMarginEnd(6)
+
+	l := gtk.NewLabel(html.EscapeString(t.Name))
+	l.SetVAlign(gtk.AlignCenter)
+	l.SetHAlign(gtk.AlignStart)
+	l.SetEllipsize(pango.EllipsizeEnd)
+	l.SetUseMarkup(true)
+
+	b := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	b.SetHAlign(gtk.AlignStart)
+	b.Add(icon)
+	b.Add(l)
+	b.ShowAll()
+
+	// Threads that the user hasn't joined are dimmer.
+	if !t.Joined() {
+		b.SetOpacity(0.6)
+	}
+
+	r := gtk.NewListBoxRow()
+	r.SetSizeRequest(-1, 16)
+	r.SetTooltipText(t.Name)
+	r.Add(b)
+	r.Show()
+
+	s := r.StyleContext()
+	s.AddClass("channel")
+	s.AddClass("thread")
+
+	return &Channel{
+		Widgetter: r,
+
+		Row:    r,
+		Style:  s,
+		Label:  l,
+		Thread: &t,
+		ID:     t.ID,
+		Guild:  t.GuildID,
+		Parent: t.ParentID(),
+		Name:   t.Name,
+	}
+}
+
+// nestThreads puts the rows of the active threads right after their parent
+// channels. Threads whose parent isn't listed are left out, and so are private
+// threads that the user isn't in.
+func nestThreads(s *ningen.State, channels []*Channel, active []threads.Thread) []*Channel {
+	children := make(map[discord.ChannelID][]threads.Thread, len(active))
+	for _, t := range active {
+		if t.Metadata.Archived || (t.Type == threads.GuildPrivateThread && !t.Joined()) {
+			continue
+		}
+		children[t.ParentID()] = append(children[t.ParentID()], t)
+	}
+
+	nested := make([]*Channel, 0, len(channels)+len(active))
+
+	for _, ch := range channels {
+		nested = append(nested, ch)
+
+		list := children[ch.ID]
+		// Newest first.
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].ID > list[j].ID
+		})
+
+		for _, t := range list {
+			nested = append(nested, createThreadRead(t, s))
+		}
+	}
+
+	return nested
+}
+
+// SetThreads replaces the thread rows with the given active threads.
+func (chs *Channels) SetThreads(active []threads.Thread) {
+	var selected discord.ChannelID
+	if chs.Selected != nil {
+		selected = chs.Selected.ID
+	}
+
+	channels := make([]*Channel, 0, len(chs.Channels))
+
+	for _, ch := range chs.Channels {
+		if ch.Thread != nil {
+			chs.ChList.Remove(ch)
+			continue
+		}
+		channels = append(channels, ch)
+	}
+
+	chs.Channels = nestThreads(chs.state, channels, active)
+	chs.Selected = nil
+
+	// Insert in order, so the indices are right.
+	for i, ch := range chs.Channels {
+		if ch.Thread != nil {
+			chs.ChList.Insert(ch, i)
+		}
+	}
+
+	if ch := chs.FindByID(selected); ch != nil {
+		chs.Selected = ch
+		chs.ChList.SelectRow(ch.Row)
+	}
+
+	chs.updateCollapsed()
+}
+
+// reloadThreads updates the thread rows after the threads of the guild change.
+func (chs *Channels) reloadThreads(guildID discord.GuildID) {
+	go func() {
+		active, err := chs.threads.Active(guildID)
+		if err != nil {
+			log.Errorln("failed to get active threads:", err)
+			return
+		}
+
+		glib.IdleAdd(func() {
+			if chs.GuildID == guildID && chs.Channels != nil {
+				chs.SetThreads(active)
+			}
+		})
+	}()
+}
+
+// onButtonPress shows the menu of the channel that was right-clicked. Threads
+// can be joined or left, and the other channels muted.
+func (chs *Channels) onButtonPress(ev *gdk.Event) bool {
+	btn := ev.AsButton()
+	if btn.Button() != gdk.BUTTON_SECONDARY {
+		return false
+	}
+
+	r := chs.ChList.RowAtY(int(btn.Y()))
+	if r == nil {
+		return false
+	}
+
+	ch := chs.Channels[r.Index()]
+	if ch.Participants != nil {
+		return false
+	}
+
+	menu := gtk.NewMenu()
+	menu.Add(chs.markReadItem(ch))
+
+	separator := gtk.NewSeparatorMenuItem()
+	separator.Show()
+	menu.Add(separator)
+
+	if ch.Thread != nil {
+		menu.Add(chs.threadMenuItem(*ch.Thread))
+	} else {
+		notifmenu.AddChannel(chs.state, menu, chs.GuildID, ch.ID)
+	}
+
+	menu.PopupAtPointer(gdk.CopyEventer(btn))
+	return true
+}
+
+// threadMenuItem joins or leaves the thread.
+func (chs *Channels) threadMenuItem(thread threads.Thread) *gtk.MenuItem {
+	label, action := "Join Thread", chs.threads.Join
+	if thread.Joined() {
+		label, action = "Leave Thread", chs.threads.Leave
+	}
+
+	item := gtk.NewMenuItemWithLabel(label)
+	item.Connect("activate", func() {
+		go func() {
+			if err := action(thread); err != nil {
+				log.Errorln("failed to join or leave thread:", err)
+			}
+		}()
+	})
+	item.Show()
+
+	return item
+}
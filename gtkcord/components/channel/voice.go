@@ -0,0 +1,212 @@
+package channel
+
+import (
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/roundimage"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// GuildStageVoice is a stage channel, which arikawa doesn't know about.
+const GuildStageVoice discord.ChannelType = 13
+
+const participantAvatar = 20
+
+// IsVoice returns true if the channel is a voice or stage channel.
+func IsVoice(t discord.ChannelType) bool {
+	return t == discord.GuildVoice || t == GuildStageVoice
+}
+
+func newVoiceChannelRow(ch *discord.Channel) *Channel {
+	iconName := "audio-volume-high-symbolic"
+	if ch.Type == GuildStageVoice {
+		iconName = "audio-input-microphone-symbolic"
+	}
+
+	icon := gtk.NewImageFromIconName(iconName, int(gtk.IconSizeButton))
+	icon.SetVAlign(gtk.AlignCenter)
+	icon.SetMarginStart(8)
+	icon.SetMarginEnd(8)
+
+	l := gtk.NewLabel(`<span weight="bold">` + html.EscapeString(ch.Name) + `</span>`)
+	l.SetVAlign(gtk.AlignCenter)
+	l.SetHAlign(gtk.AlignStart)
+	l.SetEllipsize(pango.EllipsizeEnd)
+	l.SetUseMarkup(true)
+
+	header := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	header.SetHAlign(gtk.AlignStart)
+	header.Add(icon)
+	header.Add(l)
+
+	participants := newParticipants()
+
+	b := gtk.NewBox(gtk.OrientationVertical, 0)
+	b.Add(header)
+	b.Add(participants)
+	b.ShowAll()
+
+	// There's no audio, so voice channels can't be opened.
+	r := gtk.NewListBoxRow()
+	r.SetSizeRequest(-1, 16)
+	r.SetActivatable(false)
+	r.SetSelectable(false)
+	r.Add(b)
+	r.Show()
+
+	s := r.StyleContext()
+	s.AddClass("channel")
+	s.AddClass("voice")
+
+	return &Channel{
+		Widgetter: r,
+
+		Row:          r,
+		Style:        s,
+		Label:        l,
+		Participants: participants,
+		ID:           ch.ID,
+		Guild:        ch.GuildID,
+		Parent:       ch.CategoryID,
+		Name:         ch.Name,
+		Topic:        ch.Topic,
+	}
+}
+
+// Participants lists the members connected to a voice channel.
+type Participants struct {
+	*gtk.Box
+}
+
+func newParticipants() *Participants {
+	b := gtk.NewBox(gtk.OrientationVertical, 0)
+	b.SetMarginStart(32)
+	b.SetNoShowAll(true)
+
+	return &Participants{b}
+}
+
+// Update replaces the participants with the ones in the voice states.
+func (p *Participants) Update(s *ningen.State, guildID discord.GuildID, states []discord.VoiceState) {
+	for _, child := range p.Children() {
+		p.Remove(child)
+	}
+
+	type participant struct {
+		name  string
+		state discord.VoiceState
+		user  discord.User
+	}
+
+	participants := make([]participant, 0, len(states))
+
+	for _, vs := range states {
+		part := participant{state: vs}
+
+		m := vs.Member
+		if m == nil {
+			m, _ = s.Cabinet.Member(guildID, vs.UserID)
+		}
+
+		switch {
+		case m != nil:
+			part.user = m.User
+			part.name = m.Nick
+			if part.name == "" {
+				part.name = m.User.Username
+			}
+		default:
+			// The member will be in the store the next time.
+			s.MemberState.RequestMember(guildID, vs.UserID)
+			part.user = discord.User{ID: vs.UserID}
+			part.name = vs.UserID.String()
+		}
+
+		participants = append(participants, part)
+	}
+
+	sort.SliceStable(participants, func(i, j int) bool {
+		return strings.ToLower(participants[i].name) < strings.ToLower(participants[j].name)
+	})
+
+	for _, participant := range participants {
+		p.Add(newParticipant(participant.name, participant.user, participant.state))
+	}
+
+	p.SetVisible(len(participants) > 0)
+}
+
+func newParticipant(name string, user discord.User, vs discord.VoiceState) gtk.Widgetter {
+	avatar := roundimage.NewImage(0)
+	avatar.SetSizeRequest(participantAvatar, participantAvatar)
+	avatar.SetInitials(name)
+	if url := user.AvatarURL(); url != "" {
+		cache.SetImageURLScaled(avatar, url+"?size=32", participantAvatar, participantAvatar)
+	}
+
+	label := gtk.NewLabel(name)
+	label.SetEllipsize(pango.EllipsizeEnd)
+	label.SetXAlign(0)
+	label.SetHExpand(true)
+	label.SetOpacity(0.8)
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 6)
+	gtkutils.Margin2(box, 2, 8)
+	box.Add(avatar)
+	box.Add(label)
+
+	if vs.SelfStream {
+		live := gtk.NewLabel("LIVE")
+		live.SetTooltipText("Streaming")
+		gtkutils.InjectCSS(live, "live", `
+			.live {
+				color: white;
+				background-color: #ED4245;
+				border-radius: 4px;
+				padding: 0 4px;
+				font-size: 0.75em;
+				font-weight: bold;
+			}
+		`)
+		box.Add(live)
+	}
+
+	switch {
+	case vs.Mute:
+		box.Add(voiceIndicator("microphone-disabled-symbolic", "Server Muted", true))
+	case vs.SelfMute:
+		box.Add(voiceIndicator("microphone-disabled-symbolic", "Muted", false))
+	}
+
+	switch {
+	case vs.Deaf:
+		box.Add(voiceIndicator("audio-volume-muted-symbolic", "Server Deafened", true))
+	case vs.SelfDeaf:
+		box.Add(voiceIndicator("audio-volume-muted-symbolic", "Deafened", false))
+	}
+
+	box.ShowAll()
+	return box
+}
+
+// voiceIndicator is an icon that shows a member is muted or deafened. Server
+// mutes and deafens are red.
+func voiceIndicator(iconName, tooltip string, server bool) gtk.Widgetter {
+	icon := gtk.NewImageFromIconName(iconName, int(gtk.IconSizeMenu))
+	icon.SetTooltipText(tooltip)
+	icon.SetOpacity(0.65)
+
+	if server {
+		icon.SetOpacity(1)
+		gtkutils.InjectCSS(icon, "server-muted", ".server-muted { color: #ED4245; }")
+	}
+
+	return icon
+}
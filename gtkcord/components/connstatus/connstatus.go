@@ -0,0 +1,211 @@
+package connstatus
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+)
+
+// State is the connection state displayed by the banner.
+type State uint8
+
+const (
+	Connected State = iota
+	Disconnected
+	Resuming
+	InvalidSession
+)
+
+const retryResponse = 1
+
+// Banner is an in-window bar that shows the gateway connection state. It is
+// hidden while connected.
+type Banner struct {
+	*gtk.Revealer
+	Bar *gtk.InfoBar
+
+	Status *gtk.Label
+	Error  *gtk.Label
+	Retry  *gtk.Button
+
+	// OnRetry is called when the user presses "Retry now".
+	OnRetry func()
+
+	state   State
+	lastErr error
+
+	until  time.Time
+	ticker glib.SourceHandle
+}
+
+var bannerCSS = gtkutils.CSSAdder(`
+	.connection-banner .status {
+		font-weight: bold;
+	}
+`)
+
+func NewBanner() *Banner {
+	status := gtk.NewLabel("")
+	status.SetXAlign(0)
+	status.SetEllipsize(pango.EllipsizeEnd)
+	status.StyleContext().AddClass("status")
+
+	errLabel := gtk.NewLabel("")
+	errLabel.SetXAlign(0)
+	errLabel.SetOpacity(0.75)
+	errLabel.SetEllipsize(pango.EllipsizeEnd)
+	errLabel.SetUseMarkup(true)
+
+	labels := gtk.NewBox(gtk.OrientationVertical, 0)
+	labels.SetHExpand(true)
+	labels.Add(status)
+	labels.Add(errLabel)
+
+	bar := gtk.NewInfoBar()
+	bar.SetMessageType(gtk.MessageWarning)
+	bar.ContentArea().Add(labels)
+	gtkutils.InjectCSS(bar, "connection-banner", "")
+	bannerCSS(bar.StyleContext())
+
+	retry := bar.AddButton("Retry now", retryResponse)
+
+	rev := gtk.NewRevealer()
+	rev.SetTransitionType(gtk.RevealerTransitionTypeSlideDown)
+	rev.SetTransitionDuration(150)
+	rev.SetRevealChild(false)
+	rev.Add(bar)
+	rev.ShowAll()
+
+	b := &Banner{
+		Revealer: rev,
+		Bar:      bar,
+		Status:   status,
+		Error:    errLabel,
+		Retry:    retry,
+	}
+
+	bar.ConnectResponse(func(resp int) {
+		if resp != retryResponse || b.OnRetry == nil {
+			return
+		}
+
+		// Don't let the user spam the button; the next state change will
+		// re-enable it.
+		b.Retry.SetSensitive(false)
+		b.stopTicker()
+		b.Status.SetText("Reconnecting…")
+		b.OnRetry()
+	})
+
+	return b
+}
+
+// State returns the currently displayed state.
+func (b *Banner) State() State {
+	return b.state
+}
+
+// SetConnected hides the banner and clears the last error.
+func (b *Banner) SetConnected() {
+	b.stopTicker()
+	b.state = Connected
+	b.lastErr = nil
+	b.SetRevealChild(false)
+}
+
+// SetDisconnected shows the banner with a countdown to the next reconnection
+// attempt. A zero or negative retryIn means an attempt is happening right now.
+func (b *Banner) SetDisconnected(err error, retryIn time.Duration) {
+	b.setState(Disconnected, err)
+
+	if retryIn <= 0 {
+		b.Status.SetText("Disconnected — reconnecting…")
+		return
+	}
+
+	b.until = time.Now().Add(retryIn)
+	b.updateCountdown()
+	b.ticker = glib.TimeoutSecondsAdd(1, func() bool {
+		if b.updateCountdown() {
+			return true
+		}
+		b.ticker = 0
+		return false
+	})
+}
+
+// SetResuming shows that the gateway is trying to resume the old session.
+func (b *Banner) SetResuming(err error) {
+	b.setState(Resuming, err)
+	b.Status.SetText("Resuming…")
+}
+
+// SetInvalidSession shows that Discord has invalidated the session and that a
+// new one was started. The banner hides itself after a few seconds, since the
+// gateway is connected again by then.
+func (b *Banner) SetInvalidSession() {
+	b.setState(InvalidSession, nil)
+	b.Status.SetText("Invalid session")
+	b.Error.SetMarkup(`<span size="smaller">Discord could not resume the old session, so a new one was started.</span>`)
+	b.Error.Show()
+	b.Retry.Hide()
+
+	b.ticker = glib.TimeoutSecondsAdd(5, func() {
+		b.ticker = 0
+		b.SetConnected()
+	})
+}
+
+func (b *Banner) setState(state State, err error) {
+	b.stopTicker()
+	b.state = state
+
+	if err != nil {
+		b.lastErr = err
+	}
+
+	if b.lastErr != nil {
+		b.Error.SetMarkup(`<span size="smaller">` + html.EscapeString(lastPart(b.lastErr)) + `</span>`)
+		b.Error.SetTooltipText(b.lastErr.Error())
+		b.Error.Show()
+	} else {
+		b.Error.Hide()
+	}
+
+	b.Retry.SetSensitive(true)
+	b.Retry.Show()
+	b.SetRevealChild(true)
+}
+
+// updateCountdown updates the status label and returns false once the
+// countdown is over.
+func (b *Banner) updateCountdown() bool {
+	left := time.Until(b.until).Round(time.Second)
+	if left <= 0 {
+		b.Status.SetText("Disconnected — reconnecting…")
+		return false
+	}
+
+	b.Status.SetText(fmt.Sprintf("Disconnected — reconnecting in %ds", int(left/time.Second)))
+	return true
+}
+
+func (b *Banner) stopTicker() {
+	if b.ticker > 0 {
+		glib.SourceRemove(b.ticker)
+		b.ticker = 0
+	}
+}
+
+// lastPart returns the innermost error message, since wrapped websocket errors
+// are too long for a single line.
+func lastPart(err error) string {
+	parts := strings.Split(err.Error(), ": ")
+	return parts[len(parts)-1]
+}
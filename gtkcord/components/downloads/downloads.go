@@ -0,0 +1,384 @@
+// Package downloads saves attachments in the background and keeps a history of
+// them. Downloads are written to a .part file first, so that cancelled or
+// failed downloads can be resumed with an HTTP Range request.
+package downloads
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/config"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+// HistoryFile is the file in the config directory that the history is saved
+// to.
+const HistoryFile = "downloads.json"
+
+// MaxHistory is the maximum number of downloads kept in the history.
+const MaxHistory = 100
+
+var (
+	// Directory is where files are saved without asking. The user's download
+	// directory is used if it's empty.
+	Directory string
+	// AlwaysAsk makes Save ask where to save every file.
+	AlwaysAsk bool
+)
+
+// client has no timeout, since downloads may take a while.
+var client = http.Client{}
+
+type State string
+
+const (
+	Downloading State = "downloading"
+	Done        State = "done"
+	Cancelled   State = "cancelled"
+	Failed      State = "failed"
+)
+
+// Download is a file that's being or was downloaded. Its fields must only be
+// accessed in the main thread.
+type Download struct {
+	URL   string    `json:"url"`
+	Path  string    `json:"path"`
+	Time  time.Time `json:"time"`
+	State State     `json:"state"`
+	Error string    `json:"error,omitempty"`
+
+	// Size is the total size, or 0 if it's not known yet.
+	Size int64 `json:"size"`
+
+	written int64 // atomic
+	size    int64 // atomic
+	cancel  context.CancelFunc
+	// onChange is called in the main thread when the state changes.
+	onChange func()
+}
+
+var (
+	history []*Download
+	loaded  bool
+)
+
+// History returns all downloads, oldest first.
+func History() []*Download {
+	if !loaded {
+		loaded = true
+
+		if err := config.UnmarshalFromFile(HistoryFile, &history); err != nil {
+			log.Errorln("failed to load download history:", err)
+		}
+
+		// Downloads that were running when gtkcord quit can be resumed.
+		for _, d := range history {
+			if d.State == Downloading {
+				d.State = Cancelled
+			}
+		}
+	}
+
+	return history
+}
+
+func saveHistory() {
+	if len(history) > MaxHistory {
+		history = history[len(history)-MaxHistory:]
+	}
+
+	if err := config.MarshalToFile(HistoryFile, history); err != nil {
+		log.Errorln("failed to save download history:", err)
+	}
+}
+
+// Save saves the file at the URL, asking where to if AlwaysAsk is true.
+// Otherwise, it's saved into Directory. The panel is shown once the download
+// starts.
+func Save(name, url string) {
+	if AlwaysAsk {
+		ask(name, func(path string) { Start(url, path) })
+		return
+	}
+
+	dir := Directory
+	if dir == "" {
+		dir = glib.GetUserSpecialDir(glib.UserDirectoryDownload)
+	}
+	if dir == "" {
+		ask(name, func(path string) { Start(url, path) })
+		return
+	}
+
+	Start(url, uniquePath(filepath.Join(dir, name)))
+}
+
+func ask(name string, onSave func(path string)) {
+	d := gtk.NewFileChooserNative(
+		"Save As", &window.Window.Window, gtk.FileChooserActionSave, "", "",
+	)
+	d.SetCurrentName(name)
+	d.SetDoOverwriteConfirmation(true)
+	if Directory != "" {
+		d.SetCurrentFolder(Directory)
+	}
+
+	if resp := d.Run(); gtk.ResponseType(resp) != gtk.ResponseAccept {
+		return
+	}
+
+	onSave(d.Filename())
+}
+
+// uniquePath appends a number to the file name if the path is taken. The path
+// is reserved by creating its .part file, so that downloads started at the
+// same time don't get the same one.
+func uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			f, err := os.OpenFile(path+".part", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err == nil {
+				f.Close()
+				return path
+			}
+			// Let the download report any other error.
+			if !os.IsExist(err) {
+				return path
+			}
+		}
+
+		path = base + " (" + strconv.Itoa(i) + ")" + ext
+	}
+}
+
+// Start starts downloading the URL into the path and shows it in the panel.
+func Start(url, path string) *Download {
+	d := &Download{
+		URL:  url,
+		Path: path,
+		Time: time.Now(),
+	}
+
+	history = append(History(), d)
+	d.start(false)
+
+	// A new panel lists the whole history, which already has d.
+	if panel != nil {
+		panel.add(d)
+	}
+	Present()
+
+	return d
+}
+
+// Progress returns the number of bytes written and the total size, which is 0
+// if it's not known.
+func (d *Download) Progress() (written, size int64) {
+	if d.State == Done {
+		return d.Size, d.Size
+	}
+	return atomic.LoadInt64(&d.written), atomic.LoadInt64(&d.size)
+}
+
+// Cancel stops the download. The partial file is kept to be resumed.
+func (d *Download) Cancel() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// Resume restarts a cancelled or failed download from where it stopped. It
+// starts over if a later download was saved to the same path, since the
+// partial file is then that one's.
+func (d *Download) Resume() {
+	if d.State == Cancelled || d.State == Failed {
+		d.start(d.ownsPart())
+		d.changed()
+	}
+}
+
+// ownsPart returns whether the partial file at the path is this download's.
+func (d *Download) ownsPart() bool {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Path == d.Path {
+			return history[i] == d || history[i].URL == d.URL
+		}
+	}
+	return true
+}
+
+// start starts the download. It continues from the partial file if resume is
+// true, or overwrites it otherwise.
+func (d *Download) start(resume bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d.State = Downloading
+	d.Error = ""
+	d.cancel = cancel
+
+	go func() {
+		err := d.download(ctx, resume)
+		glib.IdleAdd(func() { d.finish(ctx, err) })
+	}()
+}
+
+func (d *Download) finish(ctx context.Context, err error) {
+	d.cancel = nil
+	d.Size = atomic.LoadInt64(&d.size)
+
+	switch {
+	case err == nil:
+		d.State = Done
+	case ctx.Err() != nil:
+		d.State = Cancelled
+	default:
+		d.State = Failed
+		d.Error = err.Error()
+		log.Errorln("failed to download", d.URL+":", err)
+	}
+
+	saveHistory()
+	d.changed()
+}
+
+func (d *Download) changed() {
+	if d.onChange != nil {
+		d.onChange()
+	}
+}
+
+func (d *Download) download(ctx context.Context, resume bool) error {
+	part := d.Path + ".part"
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.Wrap(err, "failed to seek")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.URL, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to GET")
+	}
+	defer r.Body.Close()
+
+	switch {
+	case r.StatusCode == http.StatusPartialContent:
+		// Resuming.
+	case r.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is already complete.
+		atomic.StoreInt64(&d.written, offset)
+		atomic.StoreInt64(&d.size, offset)
+		return d.complete(f, part)
+	case r.StatusCode >= 200 && r.StatusCode <= 299:
+		// The server doesn't support ranges, so start over.
+		if err := f.Truncate(0); err != nil {
+			return errors.Wrap(err, "failed to truncate")
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return errors.Wrap(err, "failed to seek")
+		}
+		offset = 0
+	default:
+		return errors.Errorf("non-success status code: %d", r.StatusCode)
+	}
+
+	atomic.StoreInt64(&d.written, offset)
+	if r.ContentLength >= 0 {
+		atomic.StoreInt64(&d.size, offset+r.ContentLength)
+	}
+
+	if _, err := io.Copy(progressWriter{f, &d.written}, r.Body); err != nil {
+		return errors.Wrap(err, "failed to download")
+	}
+
+	if atomic.LoadInt64(&d.size) == 0 {
+		atomic.StoreInt64(&d.size, atomic.LoadInt64(&d.written))
+	}
+
+	return d.complete(f, part)
+}
+
+func (d *Download) complete(f *os.File, part string) error {
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close file")
+	}
+
+	if err := os.Rename(part, d.Path); err != nil {
+		return errors.Wrap(err, "failed to rename downloaded file")
+	}
+
+	return nil
+}
+
+type progressWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (w progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	atomic.AddInt64(w.n, int64(n))
+	return n, err
+}
+
+// Remove removes the download from the history. The file is kept.
+func Remove(d *Download) {
+	d.Cancel()
+
+	for i, h := range history {
+		if h == d {
+			history = append(history[:i], history[i+1:]...)
+			break
+		}
+	}
+
+	saveHistory()
+}
+
+// ClearFinished removes all downloads that aren't running from the history.
+func ClearFinished() {
+	running := history[:0]
+	for _, d := range history {
+		if d.State == Downloading {
+			running = append(running, d)
+		}
+	}
+	history = running
+
+	saveHistory()
+}
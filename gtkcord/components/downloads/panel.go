@@ -0,0 +1,266 @@
+package downloads
+
+import (
+	"html"
+	"path/filepath"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils/gdbus"
+	"github.com/diamondburned/gtkcord3/internal/humanize"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+// progressTick is how often progress bars are updated.
+const progressTick = 1000 / 30
+
+// panel is the downloads window. It's created on first use.
+var panel *Panel
+
+// Panel is a window that lists the download history.
+type Panel struct {
+	*gtk.Window
+	List  *gtk.ListBox
+	Empty *gtk.Label
+
+	rows map[*Download]*row
+}
+
+// Present shows the downloads window.
+func Present() {
+	if panel == nil {
+		panel = newPanel()
+	}
+
+	panel.Present()
+}
+
+func newPanel() *Panel {
+	p := &Panel{rows: map[*Download]*row{}}
+
+	clear := gtk.NewButtonWithLabel("Clear")
+	clear.SetTooltipText("Remove finished downloads from the list")
+	clear.Connect("clicked", func() {
+		ClearFinished()
+		p.reload()
+	})
+
+	header := gtk.NewHeaderBar()
+	header.SetTitle("Downloads")
+	header.SetShowCloseButton(true)
+	header.PackStart(clear)
+	header.ShowAll()
+
+	p.Empty = gtk.NewLabel("No downloads")
+	p.Empty.StyleContext().AddClass("dim-label")
+	p.Empty.Show()
+
+	p.List = gtk.NewListBox()
+	p.List.SetSelectionMode(gtk.SelectionNone)
+	p.List.SetPlaceholder(p.Empty)
+	p.List.Show()
+
+	scroll := gtk.NewScrolledWindow(nil, nil)
+	scroll.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scroll.Add(p.List)
+	scroll.Show()
+
+	p.Window = gtk.NewWindow(gtk.WindowToplevel)
+	p.Window.SetTransientFor(&window.Window.Window)
+	p.Window.SetDefaultSize(450, 400)
+	p.Window.SetTitlebar(header)
+	p.Window.Add(scroll)
+
+	// Keep the window around, since it holds the rows.
+	p.Window.Connect("delete-event", func() bool {
+		p.Hide()
+		return true
+	})
+
+	p.reload()
+	return p
+}
+
+// reload removes the rows of downloads that aren't in the history anymore and
+// adds the missing ones. The other rows are kept as they are.
+func (p *Panel) reload() {
+	history := History()
+
+	kept := make(map[*Download]bool, len(history))
+	for _, d := range history {
+		kept[d] = true
+	}
+
+	for d, r := range p.rows {
+		if !kept[d] {
+			p.remove(r)
+		}
+	}
+
+	for _, d := range history {
+		if _, ok := p.rows[d]; !ok {
+			p.add(d)
+		}
+	}
+}
+
+// add adds the download to the top of the list.
+func (p *Panel) add(d *Download) {
+	r := newRow(d, func() {
+		Remove(d)
+		p.remove(p.rows[d])
+	})
+
+	p.rows[d] = r
+	p.List.Prepend(r)
+}
+
+// remove removes the row and stops it from updating, since removing it from
+// the list doesn't destroy it.
+func (p *Panel) remove(r *row) {
+	r.stopTicker()
+	r.download.onChange = nil
+
+	p.List.Remove(r)
+	delete(p.rows, r.download)
+}
+
+type row struct {
+	*gtk.ListBoxRow
+	download *Download
+
+	bar    *gtk.ProgressBar
+	status *gtk.Label
+
+	cancel *gtk.Button
+	resume *gtk.Button
+	open   *gtk.Button
+	folder *gtk.Button
+	remove *gtk.Button
+
+	ticker glib.SourceHandle
+}
+
+func newRow(d *Download, onRemove func()) *row {
+	r := &row{download: d}
+
+	name := gtk.NewLabel(filepath.Base(d.Path))
+	name.SetXAlign(0)
+	name.SetEllipsize(pango.EllipsizeMiddle)
+	name.SetTooltipText(d.Path)
+
+	r.bar = gtk.NewProgressBar()
+	r.bar.SetNoShowAll(true)
+
+	r.status = gtk.NewLabel("")
+	r.status.SetXAlign(0)
+	r.status.SetEllipsize(pango.EllipsizeEnd)
+	r.status.StyleContext().AddClass("dim-label")
+
+	labels := gtk.NewBox(gtk.OrientationVertical, 2)
+	labels.SetVAlign(gtk.AlignCenter)
+	labels.PackStart(name, false, false, 0)
+	labels.PackStart(r.bar, false, false, 0)
+	labels.PackStart(r.status, false, false, 0)
+
+	r.cancel = newRowButton("process-stop-symbolic", "Cancel", d.Cancel)
+	r.resume = newRowButton("view-refresh-symbolic", "Resume", d.Resume)
+	r.open = newRowButton("document-open-symbolic", "Open", func() {
+		gtkutils.OpenURI(d.Path)
+	})
+	r.folder = newRowButton("folder-open-symbolic", "Show in Folder", func() {
+		showInFolder(d.Path)
+	})
+	r.remove = newRowButton("window-close-symbolic", "Remove from List", onRemove)
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	gtkutils.Margin(box, 6)
+	box.PackStart(labels, true, true, 0)
+	for _, b := range []*gtk.Button{r.cancel, r.resume, r.open, r.folder, r.remove} {
+		box.PackStart(b, false, false, 0)
+	}
+
+	r.ListBoxRow = gtk.NewListBoxRow()
+	r.ListBoxRow.Add(box)
+	r.ListBoxRow.Connect("destroy", r.stopTicker)
+	r.ListBoxRow.ShowAll()
+
+	d.onChange = r.update
+	r.update()
+
+	return r
+}
+
+func newRowButton(icon, tooltip string, clicked func()) *gtk.Button {
+	b := gtk.NewButtonFromIconName(icon, int(gtk.IconSizeButton))
+	b.SetRelief(gtk.ReliefNone)
+	b.SetVAlign(gtk.AlignCenter)
+	b.SetTooltipText(tooltip)
+	b.SetNoShowAll(true)
+	b.Connect("clicked", clicked)
+	return b
+}
+
+func (r *row) update() {
+	d := r.download
+
+	r.cancel.SetVisible(d.State == Downloading)
+	r.resume.SetVisible(d.State == Cancelled || d.State == Failed)
+	r.open.SetVisible(d.State == Done)
+	r.folder.SetVisible(d.State == Done)
+	r.remove.SetVisible(d.State != Downloading)
+	r.bar.SetVisible(d.State == Downloading)
+
+	switch d.State {
+	case Downloading:
+		if r.ticker == 0 {
+			r.ticker = glib.TimeoutAdd(progressTick, func() bool {
+				r.updateProgress()
+				return true
+			})
+		}
+		r.updateProgress()
+		return
+	case Done:
+		r.status.SetText(humanize.Size(uint64(d.Size)) + " — " + d.Time.Format("Jan 2, 15:04"))
+	case Cancelled:
+		r.status.SetText("Cancelled")
+	case Failed:
+		r.status.SetMarkup(`<span color="red">` + html.EscapeString("Failed: "+d.Error) + `</span>`)
+	}
+
+	r.stopTicker()
+}
+
+func (r *row) updateProgress() {
+	written, size := r.download.Progress()
+
+	if size > 0 {
+		r.bar.SetFraction(float64(written) / float64(size))
+		r.status.SetText(humanize.Size(uint64(written)) + " of " + humanize.Size(uint64(size)))
+	} else {
+		r.bar.Pulse()
+		r.status.SetText(humanize.Size(uint64(written)))
+	}
+}
+
+func (r *row) stopTicker() {
+	if r.ticker != 0 {
+		glib.SourceRemove(r.ticker)
+		r.ticker = 0
+	}
+}
+
+// showInFolder opens the file's folder in the file manager, or just the folder
+// if the file manager doesn't support selecting files.
+func showInFolder(path string) {
+	go func() {
+		if err := gdbus.ShowInFolder(path); err != nil {
+			log.Println("file manager can't show items, opening the folder instead:", err)
+			glib.IdleAdd(func() { gtkutils.OpenURI(filepath.Dir(path)) })
+		}
+	}()
+}
@@ -25,11 +25,19 @@ type Picker struct {
 	MainPage   MainPage   // page 1 "main"
 	SearchPage SearchPage // page 2 "search"
 	Error      *gtk.Label
+
+	// Tabs switches between emojis and stickers. It's nil if stickers can't be
+	// sent.
+	Tabs        *gtk.Stack
+	StickerPage *StickerPage
 }
 
 type Spawner struct {
 	// Optional.
 	Refocus interface{ GrabFocus() }
+	// Sticker is called when a sticker is picked. The stickers tab is only
+	// shown if it's set.
+	Sticker func(GuildSticker)
 
 	state  *ningen.State
 	click  func(string)
@@ -83,9 +91,31 @@ func (s *Spawner) newPicker(r gtk.Widgetter, currentGuild discord.GuildID) *Pick
 	picker.SearchPage = newSearchPage(picker)
 	picker.Error = gtk.NewLabel("")
 
-	picker.Main.Add(picker.Search)
-	picker.Main.Add(picker.PageView)
-	picker.Main.Add(picker.Error)
+	emojis := gtk.NewBox(gtk.OrientationVertical, 0)
+	emojis.Add(picker.Search)
+	emojis.Add(picker.PageView)
+	emojis.Add(picker.Error)
+
+	if s.Sticker != nil {
+		picker.StickerPage = newStickerPage(picker, s.state, currentGuild, s.Sticker)
+
+		picker.Tabs = gtk.NewStack()
+		picker.Tabs.SetTransitionType(gtk.StackTransitionTypeCrossfade)
+		picker.Tabs.SetTransitionDuration(75)
+		picker.Tabs.AddTitled(emojis, "emojis", "Emojis")
+		picker.Tabs.AddTitled(picker.StickerPage, "stickers", "Stickers")
+
+		switcher := gtk.NewStackSwitcher()
+		switcher.SetStack(picker.Tabs)
+		switcher.SetHAlign(gtk.AlignCenter)
+		gtkutils.Margin(switcher, 4)
+
+		picker.Main.Add(switcher)
+		picker.Main.Add(picker.Tabs)
+	} else {
+		picker.Main.Add(emojis)
+	}
+
 	picker.Popover.Add(picker.Main)
 
 	picker.Search.SetSearchMode(true)
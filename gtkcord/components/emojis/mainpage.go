@@ -44,33 +44,73 @@ func newMainPage(p *Picker, click func(string)) MainPage {
 }
 
 func (p *MainPage) init(guildEmojis []emoji.Guild) {
-	p.Sections = make([]*Section, 0, len(guildEmojis))
+	p.Sections = make([]*Section, 0, len(guildEmojis)+2)
 
-	// Adding 100 guilds right now, since it's not that expensive.
-	for i, group := range guildEmojis {
-		i := i
+	// Only show favorites and frequently used emojis that are still usable.
+	usable := map[discord.EmojiID]discord.Emoji{}
+	for _, group := range guildEmojis {
+		for _, e := range group.Emojis {
+			usable[e.ID] = e
+		}
+	}
+
+	pick := func(ids []discord.EmojiID) []discord.Emoji {
+		emojis := make([]discord.Emoji, 0, len(ids))
+		for _, id := range ids {
+			if e, ok := usable[id]; ok {
+				emojis = append(emojis, e)
+			}
+		}
+		return emojis
+	}
+
+	if favs := pick(Favorites()); len(favs) > 0 {
+		p.addSection(newSection(newIconHeaderButton("Favorites", "starred-symbolic"), favs))
+	}
 
-		s := newSection(group)
-		s.hide = p.picker.Popover.Popdown
-		s.clicked = p.click
-		s.ConnectRevealChild(func(bool) { p.unrevealOthers(i) })
+	if frequent := pick(Frequent(FrequentlyUsed)); len(frequent) > 0 {
+		p.addSection(newSection(
+			newIconHeaderButton("Frequently Used", "document-open-recent-symbolic"), frequent,
+		))
+	}
 
-		// Bind the revealer to the scrolled window so that expands can focus.
-		s.Revealer.SetFocusHAdjustment(p.hadj)
-		s.Revealer.SetFocusVAdjustment(p.vadj)
+	// Adding 100 guilds right now, since it's not that expensive.
+	for _, group := range guildEmojis {
+		p.addSection(newSection(newHeaderButton(group.Name, group.IconURL()), group.Emojis))
+	}
 
-		// Add the placeholder first.
-		p.Main.Add(s)
-		p.Sections = append(p.Sections, s)
+	// Open the favorites or frequently used emojis right away.
+	if len(p.Sections) > len(guildEmojis) {
+		p.Sections[0].Button.SetActive(true)
 	}
 
 	p.ShowAll()
 }
 
+func (p *MainPage) addSection(s *Section) {
+	i := len(p.Sections)
+
+	s.hide = p.picker.Popover.Popdown
+	s.clicked = p.click
+	s.ConnectRevealChild(func(revealed bool) {
+		if revealed {
+			p.unrevealOthers(i)
+		}
+	})
+
+	// Bind the revealer to the scrolled window so that expands can focus.
+	s.Revealer.SetFocusHAdjustment(p.hadj)
+	s.Revealer.SetFocusVAdjustment(p.vadj)
+
+	// Add the placeholder first.
+	p.Main.Add(s)
+	p.Sections = append(p.Sections, s)
+}
+
 func (p *MainPage) unrevealOthers(ix int) {
 	for i, section := range p.Sections {
 		if i != ix {
-			section.Revealer.SetRevealChild(false)
+			section.Button.SetActive(false)
 		}
 	}
 }
@@ -98,12 +138,12 @@ type sectionLoadState struct {
 	loaded int
 }
 
-func newSection(group emoji.Guild) *Section {
+func newSection(button *gtk.ToggleButton, emojis []discord.Emoji) *Section {
 	s := Section{
-		Emojis: group.Emojis,
+		Emojis: emojis,
 	}
 
-	s.Button = newHeaderButton(group.Name, group.IconURL())
+	s.Button = button
 	s.Body = newFlowBox()
 	s.RevealerBox = newRevealerBox(s.Button, s.Body)
 	s.RevealerBox.ConnectUnmap(func() {
@@ -153,7 +193,8 @@ func (s *Section) init() {
 	s.Body.ShowAll()
 
 	s.Body.Connect("child-activated", func(c *gtk.FlowBoxChild) {
-		s.clicked(s.Emojis[c.Index()].String())
+		emoji := s.Emojis[c.Index()]
+		s.clicked(emoji.String())
 		if !s.shiftHeld {
 			s.hide()
 		}
@@ -165,6 +206,13 @@ func (s *Section) init() {
 		evk := ev.AsButton()
 		const shift = gdk.ShiftMask
 
+		if evk.Button() == gdk.BUTTON_SECONDARY {
+			if child := f.ChildAtPos(int(evk.X()), int(evk.Y())); child != nil {
+				s.favoriteMenu(child)
+				return true
+			}
+		}
+
 		// Is shift being held?
 		s.shiftHeld = evk.State()&shift == shift
 
@@ -173,6 +221,33 @@ func (s *Section) init() {
 	})
 }
 
+// favoriteMenu shows a popover that pins or unpins the emoji. The favorites are
+// updated the next time the picker is opened.
+func (s *Section) favoriteMenu(child *gtk.FlowBoxChild) {
+	emoji := s.Emojis[child.Index()]
+	favorite := IsFavorite(emoji.ID)
+
+	label := "Add to Favorites"
+	if favorite {
+		label = "Remove from Favorites"
+	}
+
+	button := gtk.NewModelButton()
+	button.SetLabel(label)
+
+	popover := gtk.NewPopover(child)
+	popover.Add(button)
+	popover.Connect("closed", popover.Destroy)
+
+	button.Connect("clicked", func() {
+		SetFavorite(emoji.ID, !favorite)
+		popover.Popdown()
+	})
+
+	popover.ShowAll()
+	popover.Popup()
+}
+
 func (s *Section) load() {
 	s.init()
 
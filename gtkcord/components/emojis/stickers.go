@@ -0,0 +1,217 @@
+package emojis
+
+import (
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/core/glib"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/message/extras"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+	"github.com/pkg/errors"
+)
+
+// StickerSize is the sticker size in the picker.
+const StickerSize = 80
+
+// GuildSticker is a sticker uploaded to a guild. arikawa doesn't know about
+// guild stickers yet.
+type GuildSticker struct {
+	ID          discord.StickerID         `json:"id"`
+	GuildID     discord.GuildID           `json:"guild_id"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	FormatType  discord.StickerFormatType `json:"format_type"`
+	// Available is false if the guild lost the boosts needed for the sticker.
+	Available *bool `json:"available,omitempty"`
+}
+
+// Usable returns true if the sticker can be sent.
+func (s GuildSticker) Usable() bool {
+	return s.Available == nil || *s.Available
+}
+
+// Sticker returns the sticker as it appears in messages.
+func (s GuildSticker) Sticker() discord.Sticker {
+	return discord.Sticker{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		FormatType:  s.FormatType,
+	}
+}
+
+// GuildStickers fetches the stickers in the guild.
+func GuildStickers(s *ningen.State, guildID discord.GuildID) ([]GuildSticker, error) {
+	var stickers []GuildSticker
+	return stickers, s.Client.Client.RequestJSON(
+		&stickers, "GET", api.EndpointGuilds+guildID.String()+"/stickers",
+	)
+}
+
+// stickerGuilds returns the guilds with stickers that can be sent in the
+// current guild. Only Nitro lets stickers be used outside of their guild.
+func stickerGuilds(s *ningen.State, currentGuild discord.GuildID) []discord.Guild {
+	var guilds []discord.Guild
+
+	if currentGuild.IsValid() {
+		if g, err := s.Guild(currentGuild); err == nil {
+			guilds = append(guilds, *g)
+		}
+	}
+
+	me, err := s.Me()
+	if err != nil || me.Nitro != discord.NitroFull {
+		return guilds
+	}
+
+	all, _ := s.Guilds()
+	for _, g := range all {
+		if g.ID != currentGuild {
+			guilds = append(guilds, g)
+		}
+	}
+
+	return guilds
+}
+
+// StickerPage lists the stickers of each guild that can be used.
+type StickerPage struct {
+	*gtk.ScrolledWindow
+	Main     *gtk.Box
+	Sections []*StickerSection
+}
+
+func newStickerPage(
+	p *Picker, s *ningen.State, guildID discord.GuildID, click func(GuildSticker)) *StickerPage {
+
+	page := &StickerPage{}
+	page.Main = gtk.NewBox(gtk.OrientationVertical, 0)
+
+	page.ScrolledWindow = gtk.NewScrolledWindow(nil, nil)
+	page.ScrolledWindow.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	page.ScrolledWindow.SetPropagateNaturalHeight(true)
+	page.ScrolledWindow.SetMinContentHeight(400)
+	page.ScrolledWindow.SetMaxContentHeight(400)
+	page.ScrolledWindow.Add(page.Main)
+
+	guilds := stickerGuilds(s, guildID)
+	if len(guilds) == 0 {
+		label := gtk.NewLabel("Stickers can only be sent in servers.")
+		label.SetOpacity(0.65)
+		label.SetVExpand(true)
+		page.Main.Add(label)
+	}
+
+	for i, guild := range guilds {
+		i := i
+
+		section := newStickerSection(s, guild)
+		section.clicked = func(sticker GuildSticker) {
+			p.Popover.Popdown()
+			click(sticker)
+		}
+		section.ConnectRevealChild(func(revealed bool) {
+			if revealed {
+				page.unrevealOthers(i)
+			}
+		})
+
+		page.Main.Add(section)
+		page.Sections = append(page.Sections, section)
+	}
+
+	// Open the current guild's stickers right away.
+	if len(page.Sections) > 0 && guildID.IsValid() {
+		page.Sections[0].Button.SetActive(true)
+	}
+
+	return page
+}
+
+func (p *StickerPage) unrevealOthers(ix int) {
+	for i, section := range p.Sections {
+		if i != ix {
+			section.Button.SetActive(false)
+		}
+	}
+}
+
+// StickerSection is a guild's stickers, which are fetched when the section is
+// first opened.
+type StickerSection struct {
+	*RevealerBox
+	Button *gtk.ToggleButton
+	Body   *gtk.FlowBox
+
+	Stickers []GuildSticker
+
+	clicked func(GuildSticker)
+	loaded  bool
+}
+
+func newStickerSection(s *ningen.State, guild discord.Guild) *StickerSection {
+	section := &StickerSection{}
+	section.Button = newHeaderButton(guild.Name, guild.IconURL())
+
+	section.Body = newFlowBox()
+	section.Body.SetMaxChildrenPerLine(4)
+	section.Body.SetMinChildrenPerLine(4)
+	section.Body.Connect("child-activated", func(c *gtk.FlowBoxChild) {
+		section.clicked(section.Stickers[c.Index()])
+	})
+
+	section.RevealerBox = newRevealerBox(section.Button, section.Body)
+	section.ConnectRevealChild(func(revealed bool) {
+		if revealed && !section.loaded {
+			section.loaded = true
+			section.load(s, guild.ID)
+		}
+	})
+
+	return section
+}
+
+func (s *StickerSection) load(n *ningen.State, guildID discord.GuildID) {
+	go func() {
+		stickers, err := GuildStickers(n, guildID)
+		if err != nil {
+			log.Errorln("Failed to get stickers:", err)
+		}
+
+		glib.IdleAdd(func() {
+			if err != nil {
+				s.showMessage(errors.Wrap(err, "failed to get stickers").Error())
+				return
+			}
+
+			for _, sticker := range stickers {
+				if sticker.Usable() {
+					s.Stickers = append(s.Stickers, sticker)
+				}
+			}
+
+			if len(s.Stickers) == 0 {
+				s.showMessage("This server has no stickers.")
+				return
+			}
+
+			for _, sticker := range s.Stickers {
+				s.Body.Add(extras.NewSticker(sticker.ID, sticker.Name, sticker.FormatType, StickerSize))
+			}
+
+			s.Body.ShowAll()
+		})
+	}()
+}
+
+func (s *StickerSection) showMessage(text string) {
+	label := gtk.NewLabel(text)
+	label.SetOpacity(0.65)
+	label.SetMarginTop(8)
+	label.SetMarginBottom(8)
+	label.Show()
+
+	s.Revealer.Remove(s.Body)
+	s.Revealer.Add(label)
+}
@@ -0,0 +1,177 @@
+package emojis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gtkcord3/gtkcord/config"
+	"github.com/diamondburned/gtkcord3/internal/frecency"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+// UsageFile is the file in the config directory that emoji usage and favorites
+// are saved to.
+const UsageFile = "emojis.json"
+
+// FrequentlyUsed is the number of emojis in the Frequently Used section.
+const FrequentlyUsed = 20
+
+// saveDelay is how long to wait in seconds for more changes before saving.
+const saveDelay = 2
+
+// emojiUsage is keyed by emoji IDs. It's only used in the main thread.
+type emojiUsage struct {
+	frecency.Store
+	Favorites []discord.EmojiID `json:"favorites"`
+}
+
+var usage *emojiUsage
+
+func loadUsage() *emojiUsage {
+	if usage == nil {
+		usage = &emojiUsage{}
+		if err := config.UnmarshalFromFile(UsageFile, usage); err != nil {
+			log.Errorln("failed to load emoji usage:", err)
+		}
+	}
+	return usage
+}
+
+var (
+	saveHandle glib.SourceHandle
+
+	writeMutex sync.Mutex
+	writeGen   uint64 // how many times the usage was marshaled
+	written    uint64 // the last one that was written
+)
+
+// saveUsage saves the usage once it stops changing for a bit. The file is
+// written in the background.
+func saveUsage() {
+	if saveHandle != 0 {
+		glib.SourceRemove(saveHandle)
+	}
+
+	saveHandle = glib.TimeoutSecondsAdd(saveDelay, func() {
+		saveHandle = 0
+
+		b, err := json.MarshalIndent(usage, "", "\t")
+		if err != nil {
+			log.Errorln("failed to marshal emoji usage:", err)
+			return
+		}
+
+		writeGen++
+		gen := writeGen
+
+		go writeUsage(b, gen)
+	})
+}
+
+func writeUsage(b []byte, gen uint64) {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+
+	// Don't overwrite a newer save.
+	if gen < written {
+		return
+	}
+	written = gen
+
+	if err := os.WriteFile(filepath.Join(config.Path, UsageFile), b, 0644); err != nil {
+		log.Errorln("failed to save emoji usage:", err)
+	}
+}
+
+// RecordUse records that the custom emojis were used. It must be called in
+// the main thread.
+func RecordUse(ids ...discord.EmojiID) {
+	if len(ids) == 0 {
+		return
+	}
+
+	u := loadUsage()
+	now := time.Now()
+
+	for _, id := range ids {
+		if id.IsValid() {
+			u.Use(id.String(), now)
+		}
+	}
+
+	saveUsage()
+}
+
+var emojiRegex = regexp.MustCompile(`<a?:\w+:(\d+)>`)
+
+// RecordContent records the custom emojis in a message that was sent.
+func RecordContent(content string) {
+	var ids []discord.EmojiID
+
+	for _, match := range emojiRegex.FindAllStringSubmatch(content, -1) {
+		if sf, err := discord.ParseSnowflake(match[1]); err == nil {
+			ids = append(ids, discord.EmojiID(sf))
+		}
+	}
+
+	RecordUse(ids...)
+}
+
+// Score returns how frequently and recently the emoji was used.
+func Score(id discord.EmojiID) float64 {
+	return loadUsage().Score(id.String(), time.Now())
+}
+
+// Frequent returns the most used emojis, most used first.
+func Frequent(n int) []discord.EmojiID {
+	keys := loadUsage().Top(n, time.Now())
+	ids := make([]discord.EmojiID, 0, len(keys))
+
+	for _, key := range keys {
+		if sf, err := discord.ParseSnowflake(key); err == nil {
+			ids = append(ids, discord.EmojiID(sf))
+		}
+	}
+
+	return ids
+}
+
+// Favorites returns the pinned emojis in the order they were pinned.
+func Favorites() []discord.EmojiID {
+	return loadUsage().Favorites
+}
+
+// IsFavorite returns true if the emoji is pinned.
+func IsFavorite(id discord.EmojiID) bool {
+	for _, fav := range loadUsage().Favorites {
+		if fav == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFavorite pins or unpins the emoji.
+func SetFavorite(id discord.EmojiID, favorite bool) {
+	u := loadUsage()
+
+	favs := u.Favorites[:0]
+	for _, fav := range u.Favorites {
+		if fav != id {
+			favs = append(favs, fav)
+		}
+	}
+
+	if favorite {
+		favs = append(favs, id)
+	}
+
+	u.Favorites = favs
+	saveUsage()
+}
@@ -65,6 +65,27 @@ func newHeaderButton(name string, imgURL string) *gtk.ToggleButton {
 	return b
 }
 
+func newIconHeaderButton(name string, iconName string) *gtk.ToggleButton {
+	i := gtk.NewImageFromIconName(iconName, int(gtk.IconSizeLargeToolbar))
+	i.SetSizeRequest(Size, Size)
+	gtkutils.Margin(i, 4)
+
+	l := gtk.NewLabel(name)
+	l.SetMarginStart(4)
+	l.SetHAlign(gtk.AlignStart)
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 4)
+	box.Add(i)
+	box.Add(l)
+
+	b := gtk.NewToggleButton()
+	b.SetRelief(gtk.ReliefNone)
+	b.Add(box)
+	b.ShowAll()
+
+	return b
+}
+
 func newStaticViewport() *gtk.Viewport {
 	adj := gtk.NewAdjustment(0, 0, 0, 0, 0, 0)
 
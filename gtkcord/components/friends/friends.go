@@ -0,0 +1,477 @@
+// Package friends shows the user's friends, friend requests and blocked users,
+// which are kept up to date with the relationship events.
+package friends
+
+import (
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/user"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// Tab is a list in the friends page.
+type Tab string
+
+const (
+	Online  Tab = "online"
+	All     Tab = "all"
+	Pending Tab = "pending"
+	Blocked Tab = "blocked"
+)
+
+var tabs = []struct {
+	tab   Tab
+	title string
+	empty string
+}{
+	{Online, "Online", "No friends are online."},
+	{All, "All", "No friends yet."},
+	{Pending, "Pending", "No pending friend requests."},
+	{Blocked, "Blocked", "No blocked users."},
+}
+
+// Page shows the relationships in tabs, with an entry to add friends.
+type Page struct {
+	*gtk.Box
+	Stack *gtk.Stack
+	Lists map[Tab]*gtk.ListBox
+
+	AddEntry  *gtk.Entry
+	AddButton *gtk.Button
+	AddStatus *gtk.Label
+
+	// OnMessage is called with the user to message when a friend is activated
+	// or "Message" is clicked.
+	OnMessage func(discord.UserID)
+
+	state  *ningen.State
+	rows   map[Tab]map[discord.UserID]*row
+	queued bool
+	unbind []func()
+}
+
+// row is a relationship in one of the lists.
+type row struct {
+	*gtk.ListBoxRow
+	body         *user.Container
+	relationship discord.Relationship
+}
+
+// NewPage creates a new friends page.
+func NewPage(s *ningen.State) *Page {
+	p := &Page{
+		Lists: make(map[Tab]*gtk.ListBox, len(tabs)),
+		state: s,
+		rows:  make(map[Tab]map[discord.UserID]*row, len(tabs)),
+	}
+
+	p.Stack = gtk.NewStack()
+	p.Stack.SetTransitionType(gtk.StackTransitionTypeCrossfade)
+	p.Stack.SetVExpand(true)
+
+	for _, tab := range tabs {
+		empty := gtk.NewLabel(tab.empty)
+		empty.StyleContext().AddClass("dim-label")
+		gtkutils.Margin(empty, 15)
+		empty.Show()
+
+		list := gtk.NewListBox()
+		list.SetSelectionMode(gtk.SelectionNone)
+		list.SetPlaceholder(empty)
+		list.Connect("row-activated", p.onActivate)
+
+		clamp := gtk.NewBox(gtk.OrientationVertical, 0)
+		clamp.SetHAlign(gtk.AlignCenter)
+		clamp.SetSizeRequest(500, -1)
+		clamp.PackStart(list, false, false, 10)
+
+		scroll := gtk.NewScrolledWindow(nil, nil)
+		scroll.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+		scroll.Add(clamp)
+
+		p.Lists[tab.tab] = list
+		p.Stack.AddTitled(scroll, string(tab.tab), tab.title)
+	}
+
+	switcher := gtk.NewStackSwitcher()
+	switcher.SetStack(p.Stack)
+	switcher.SetHAlign(gtk.AlignCenter)
+
+	p.AddEntry = gtk.NewEntry()
+	p.AddEntry.SetPlaceholderText("Username#0000")
+	p.AddEntry.SetHExpand(true)
+	p.AddEntry.Connect("activate", p.addFriend)
+
+	p.AddButton = gtk.NewButtonWithLabel("Add Friend")
+	p.AddButton.StyleContext().AddClass("suggested-action")
+	p.AddButton.Connect("clicked", p.addFriend)
+
+	p.AddStatus = gtk.NewLabel("")
+	p.AddStatus.SetXAlign(0)
+	p.AddStatus.SetLineWrap(true)
+	p.AddStatus.StyleContext().AddClass("dim-label")
+	p.AddStatus.SetNoShowAll(true)
+
+	add := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	add.PackStart(p.AddEntry, true, true, 0)
+	add.PackStart(p.AddButton, false, false, 0)
+
+	top := gtk.NewBox(gtk.OrientationVertical, 5)
+	top.SetHAlign(gtk.AlignCenter)
+	top.SetSizeRequest(500, -1)
+	gtkutils.Margin(top, 10)
+	top.PackStart(switcher, false, false, 0)
+	top.PackStart(add, false, false, 0)
+	top.PackStart(p.AddStatus, false, false, 0)
+
+	p.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	p.Box.SetHExpand(true)
+	p.Box.SetVExpand(true)
+	p.Box.PackStart(top, false, false, 0)
+	p.Box.PackStart(gtk.NewSeparator(gtk.OrientationHorizontal), false, false, 0)
+	p.Box.PackStart(p.Stack, true, true, 0)
+	p.Box.ShowAll()
+
+	p.unbind = []func(){
+		s.AddHandler(func(*gateway.RelationshipAddEvent) { p.queueReload() }),
+		s.AddHandler(func(*gateway.RelationshipRemoveEvent) { p.queueReload() }),
+		s.AddHandler(func(ev *gateway.PresenceUpdateEvent) {
+			if s.RelationshipState.Relationship(ev.User.ID) == discord.FriendRelationship {
+				glib.IdleAdd(func() { p.updatePresence(ev.User.ID) })
+			}
+		}),
+	}
+
+	p.Reload()
+	return p
+}
+
+// Destroy removes the gateway handlers. The page shouldn't be used after.
+func (p *Page) Destroy() {
+	for _, unbind := range p.unbind {
+		unbind()
+	}
+	p.unbind = nil
+}
+
+// SetTab shows the tab.
+func (p *Page) SetTab(tab Tab) {
+	p.Stack.SetVisibleChildName(string(tab))
+}
+
+// queueReload reloads the lists once in the main thread, however many events
+// come before that.
+func (p *Page) queueReload() {
+	glib.IdleAdd(func() {
+		if !p.queued {
+			p.queued = true
+			glib.IdleAdd(func() {
+				p.queued = false
+				p.Reload()
+			})
+		}
+	})
+}
+
+// Reload remakes the lists from the relationships.
+func (p *Page) Reload() {
+	var relationships []discord.Relationship
+
+	p.state.RelationshipState.Each(func(r *discord.Relationship) bool {
+		relationships = append(relationships, *r)
+		return false
+	})
+
+	sort.Slice(relationships, func(i, j int) bool {
+		return strings.ToLower(relationships[i].User.Username) <
+			strings.ToLower(relationships[j].User.Username)
+	})
+
+	for tab, list := range p.Lists {
+		for _, child := range list.Children() {
+			list.Remove(child)
+		}
+		p.rows[tab] = map[discord.UserID]*row{}
+	}
+
+	for _, r := range relationships {
+		for _, tab := range tabsOf(r.Type) {
+			presence := p.presence(r.UserID)
+			if tab == Online && !online(presence) {
+				continue
+			}
+
+			item := p.newRow(r, presence)
+			p.rows[tab][r.UserID] = item
+			p.Lists[tab].Insert(item, -1)
+		}
+	}
+}
+
+// updatePresence updates the friend's rows, then adds them to or removes them
+// from the online list.
+func (p *Page) updatePresence(userID discord.UserID) {
+	presence := p.presence(userID)
+
+	for _, rows := range p.rows {
+		if r, ok := rows[userID]; ok {
+			r.setPresence(presence)
+		}
+	}
+
+	r, shown := p.rows[Online][userID]
+
+	switch {
+	case online(presence) && !shown:
+		// The relationship events reload the lists if it's not there yet.
+		all, ok := p.rows[All][userID]
+		if !ok {
+			return
+		}
+
+		r = p.newRow(all.relationship, presence)
+		p.rows[Online][userID] = r
+		p.Lists[Online].Insert(r, p.position(Online, r))
+
+	case !online(presence) && shown:
+		p.Lists[Online].Remove(r)
+		delete(p.rows[Online], userID)
+	}
+}
+
+// position returns where the row goes in the list, which is sorted by name.
+func (p *Page) position(tab Tab, r *row) int {
+	name := strings.ToLower(r.relationship.User.Username)
+
+	var pos int
+	for _, other := range p.rows[tab] {
+		if other != r && strings.ToLower(other.relationship.User.Username) < name {
+			pos++
+		}
+	}
+
+	return pos
+}
+
+func tabsOf(t discord.RelationshipType) []Tab {
+	switch t {
+	case discord.FriendRelationship:
+		return []Tab{Online, All}
+	case discord.IncomingFriendRequest, discord.SentFriendRequest:
+		return []Tab{Pending}
+	case discord.BlockedRelationship:
+		return []Tab{Blocked}
+	default:
+		return nil
+	}
+}
+
+func (p *Page) presence(userID discord.UserID) *gateway.Presence {
+	presence, _ := p.state.Presence(0, userID)
+	return presence
+}
+
+func online(p *gateway.Presence) bool {
+	if p == nil {
+		return false
+	}
+
+	switch p.Status {
+	case gateway.OnlineStatus, gateway.IdleStatus, gateway.DoNotDisturbStatus:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Page) onActivate(_ *gtk.ListBox, row *gtk.ListBoxRow) {
+	id, err := discord.ParseSnowflake(row.Name())
+	if err != nil {
+		return
+	}
+
+	userID := discord.UserID(id)
+	if p.state.RelationshipState.Relationship(userID) != discord.FriendRelationship {
+		return
+	}
+
+	if p.OnMessage != nil {
+		p.OnMessage(userID)
+	}
+}
+
+func (p *Page) newRow(r discord.Relationship, presence *gateway.Presence) *row {
+	body := user.New()
+	body.UpdateUser(r.User)
+	body.UpdateAvatar(r.User.AvatarURL())
+
+	switch r.Type {
+	case discord.IncomingFriendRequest:
+		body.LabelBox.Add(dimLabel("Incoming Friend Request"))
+	case discord.SentFriendRequest:
+		body.LabelBox.Add(dimLabel("Outgoing Friend Request"))
+	}
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	gtkutils.Margin2(box, 4, 8)
+	box.PackStart(body, true, true, 0)
+
+	for _, button := range p.buttons(r) {
+		box.PackStart(button, false, false, 0)
+	}
+
+	item := &row{
+		ListBoxRow:   gtk.NewListBoxRow(),
+		body:         body,
+		relationship: r,
+	}
+	item.setPresence(presence)
+	item.SetName(r.UserID.String())
+	item.SetActivatable(r.Type == discord.FriendRelationship)
+	item.Add(box)
+	item.ShowAll()
+
+	return item
+}
+
+func (r *row) setPresence(presence *gateway.Presence) {
+	if presence == nil {
+		r.body.UpdateStatus(gateway.OfflineStatus)
+		r.body.UpdateActivity(nil)
+		return
+	}
+
+	r.body.UpdateStatus(presence.Status)
+
+	if len(presence.Activities) > 0 {
+		r.body.UpdateActivity(&presence.Activities[0])
+	} else {
+		r.body.UpdateActivity(nil)
+	}
+}
+
+func dimLabel(text string) *gtk.Label {
+	l := gtk.NewLabel("")
+	l.SetMarkup(`<span size="smaller">` + html.EscapeString(text) + "</span>")
+	l.SetHAlign(gtk.AlignStart)
+	l.StyleContext().AddClass("dim-label")
+	return l
+}
+
+// buttons returns the actions for the relationship.
+func (p *Page) buttons(r discord.Relationship) []*gtk.Button {
+	id := r.UserID
+	name := r.User.Username
+
+	message := newRowButton("mail-send-symbolic", "Message", func() {
+		if p.OnMessage != nil {
+			p.OnMessage(id)
+		}
+	})
+	block := newRowButton("action-unavailable-symbolic", "Block", func() {
+		if window.Confirm(nil, "Block "+name, "They won't be able to message you.", "Block") {
+			p.do("block", func() error { return p.state.SetRelationship(id, discord.BlockedRelationship) })
+		}
+	})
+
+	switch r.Type {
+	case discord.FriendRelationship:
+		remove := newRowButton("list-remove-symbolic", "Remove Friend", func() {
+			if window.Confirm(nil, "Remove "+name, "They'll be removed from your friends.", "Remove Friend") {
+				p.do("remove friend", func() error { return p.state.DeleteRelationship(id) })
+			}
+		})
+		return []*gtk.Button{message, remove, block}
+
+	case discord.IncomingFriendRequest:
+		accept := newRowButton("object-select-symbolic", "Accept", func() {
+			p.do("accept friend request", func() error {
+				return p.state.SetRelationship(id, discord.FriendRelationship)
+			})
+		})
+		ignore := newRowButton("window-close-symbolic", "Ignore", func() {
+			p.do("ignore friend request", func() error { return p.state.DeleteRelationship(id) })
+		})
+		return []*gtk.Button{accept, ignore, block}
+
+	case discord.SentFriendRequest:
+		cancel := newRowButton("window-close-symbolic", "Cancel", func() {
+			p.do("cancel friend request", func() error { return p.state.DeleteRelationship(id) })
+		})
+		return []*gtk.Button{cancel}
+
+	case discord.BlockedRelationship:
+		unblock := gtk.NewButtonWithLabel("Unblock")
+		unblock.SetVAlign(gtk.AlignCenter)
+		unblock.Connect("clicked", func() {
+			p.do("unblock", func() error { return p.state.DeleteRelationship(id) })
+		})
+		return []*gtk.Button{unblock}
+
+	default:
+		return nil
+	}
+}
+
+func newRowButton(icon, tooltip string, clicked func()) *gtk.Button {
+	b := gtk.NewButtonFromIconName(icon, int(gtk.IconSizeButton))
+	b.SetRelief(gtk.ReliefNone)
+	b.SetVAlign(gtk.AlignCenter)
+	b.SetTooltipText(tooltip)
+	b.Connect("clicked", clicked)
+	return b
+}
+
+// do runs the request in the background. The lists are updated by the
+// relationship events that follow.
+func (p *Page) do(action string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			log.Errorln("failed to "+action+":", err)
+		}
+	}()
+}
+
+func (p *Page) addFriend() {
+	username, discriminator, ok := parseTag(p.AddEntry.Text())
+	if !ok {
+		p.setAddStatus("Enter a username and tag, like Wumpus#0000.")
+		return
+	}
+
+	p.AddEntry.SetSensitive(false)
+	p.AddButton.SetSensitive(false)
+
+	go func() {
+		err := sendRequest(p.state, username, discriminator)
+
+		glib.IdleAdd(func() {
+			p.AddEntry.SetSensitive(true)
+			p.AddButton.SetSensitive(true)
+
+			if err != nil {
+				log.Errorln("failed to send friend request:", err)
+				p.setAddStatus("Couldn't send a friend request to " + username + ".")
+				return
+			}
+
+			p.AddEntry.SetText("")
+			p.setAddStatus("Sent a friend request to " + username + ".")
+			p.SetTab(Pending)
+		})
+	}()
+}
+
+func (p *Page) setAddStatus(status string) {
+	p.AddStatus.SetText(status)
+	p.AddStatus.Show()
+}
@@ -0,0 +1,50 @@
+package friends
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// sendRequest sends a friend request to the user with the username and
+// discriminator.
+func sendRequest(s *ningen.State, username string, discriminator int) error {
+	var data struct {
+		Username      string `json:"username"`
+		Discriminator int    `json:"discriminator"`
+	}
+
+	data.Username = username
+	data.Discriminator = discriminator
+
+	return s.Client.Client.FastRequest(
+		"POST", api.EndpointMe+"/relationships",
+		httputil.WithJSONBody(data),
+	)
+}
+
+// parseTag splits a tag like "Wumpus#0000" into the username and the
+// discriminator.
+func parseTag(tag string) (string, int, bool) {
+	tag = strings.TrimSpace(tag)
+
+	i := strings.LastIndexByte(tag, '#')
+	if i < 1 {
+		return "", 0, false
+	}
+
+	discriminator := tag[i+1:]
+	if len(discriminator) != 4 {
+		return "", 0, false
+	}
+
+	d, err := strconv.Atoi(discriminator)
+	if err != nil || d < 0 {
+		return "", 0, false
+	}
+
+	return tag[:i], d, true
+}
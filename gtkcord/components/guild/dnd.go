@@ -0,0 +1,104 @@
+package guild
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+type dragKind string
+
+const (
+	dragGuild  dragKind = "guild"
+	dragFolder dragKind = "folder"
+)
+
+func dragTargets() []gtk.TargetEntry {
+	return []gtk.TargetEntry{
+		*gtk.NewTargetEntry("UTF8_STRING", uint(gtk.TargetSameApp), 0),
+	}
+}
+
+// bindDrag makes the guild, or the folder that has it, draggable onto other
+// guilds and folders.
+func (g *Guilds) bindDrag(w gtk.Widgetter, kind dragKind, guildID discord.GuildID) {
+	widget := gtk.BaseWidget(w)
+	widget.DragSourceSet(gdk.Button1Mask, dragTargets(), gdk.ActionMove)
+	widget.ConnectDragDataGet(func(_ gdk.DragContext, data *gtk.SelectionData, _, _ uint) {
+		text := string(kind) + ":" + guildID.String()
+		data.SetText(text, len(text))
+	})
+
+	g.bindDrop(w, dropTarget{guildID, kind == dragFolder})
+}
+
+// bindDrop moves what's dropped on the widget. The top and bottom of the widget
+// put it before or after, and the middle puts it into the folder.
+func (g *Guilds) bindDrop(w gtk.Widgetter, target dropTarget) {
+	widget := gtk.BaseWidget(w)
+	widget.DragDestSet(gtk.DestDefaultAll, dragTargets(), gdk.ActionMove)
+	widget.ConnectDragDataReceived(func(_ gdk.DragContext, _, y int, data *gtk.SelectionData, _, _ uint) {
+		kind, guildID, ok := parseDrag(data.Text())
+		if !ok {
+			return
+		}
+
+		folders := g.Folders()
+		if len(folders) == 0 {
+			return
+		}
+
+		to := target
+		pos := dropAt(y, widget.AllocatedHeight())
+
+		// Dropping on the direct messages moves to the top.
+		if !to.guildID.IsValid() {
+			to = dropTarget{folders[0].GuildIDs[0], true}
+			pos = dropBefore
+		}
+
+		var moved = moveFolder
+		if kind == dragGuild {
+			moved = moveGuild
+		}
+
+		if folders := moved(folders, guildID, to, pos); folders != nil {
+			// The rows are remade, so wait until the drop is done.
+			glib.IdleAdd(func() { g.rearrange(folders) })
+		}
+	})
+}
+
+func dropAt(y, height int) dropPosition {
+	switch {
+	case y < height/4:
+		return dropBefore
+	case y > height*3/4:
+		return dropAfter
+	default:
+		return dropInto
+	}
+}
+
+func parseDrag(text string) (dragKind, discord.GuildID, bool) {
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	kind := dragKind(parts[0])
+	if kind != dragGuild && kind != dragFolder {
+		return "", 0, false
+	}
+
+	return kind, discord.GuildID(id), true
+}
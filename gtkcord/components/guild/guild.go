@@ -5,10 +5,12 @@ import (
 
 	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/avatar"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/channel"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
 	"github.com/diamondburned/gtkcord3/internal/log"
 	"github.com/diamondburned/ningen/v2"
 )
@@ -20,6 +22,10 @@ const (
 	TotalWidth  = IconSize + IconPadding*3
 )
 
+// Menu, if not nil, is called to add items to the menu that's shown when a
+// guild is right-clicked.
+var Menu func(g *Guild, menu *gtk.Menu)
+
 type Guild struct {
 	*gtk.ListBoxRow
 	Parent *GuildFolder
@@ -34,6 +40,10 @@ type Guild struct {
 	ID   discord.GuildID
 	Name string
 
+	// folder is the guild's own folder if it's not in a shown one. Discord
+	// lets these have an ID, a name and a color, which are kept as they are.
+	folder gateway.GuildFolder
+
 	unreadChs map[discord.ChannelID]bool
 	muted     bool
 }
@@ -89,6 +99,8 @@ func newGuildRow(s *ningen.State, guildID discord.GuildID, parent *GuildFolder)
 
 	// Bind the name popup.
 	guild.Event = BindName(guild.ListBoxRow, guild.Unread, &guild.Name)
+	guild.Event.AddEvents(int(gdk.ButtonPressMask))
+	guild.Event.Connect("button-press-event", guild.onButtonPress)
 
 	// Check if the guild is unavailable:
 	// TODO: retry mechanism
@@ -100,10 +112,7 @@ func newGuildRow(s *ningen.State, guildID discord.GuildID, parent *GuildFolder)
 	// Update the guild icon in the background.
 	guild.UpdateImage()
 
-	if s.MutedState.Guild(guildID, false) {
-		guild.muted = true
-		return guild
-	}
+	guild.muted = notifications.GuildMuted(s, guildID)
 
 	if rs := guild.containsUnreadChannel(s); rs != nil {
 		log.Printf("for guild %s found unread %#v", g.Name, rs)
@@ -114,6 +123,20 @@ func newGuildRow(s *ningen.State, guildID discord.GuildID, parent *GuildFolder)
 	return guild
 }
 
+// onButtonPress shows the guild menu on right click.
+func (g *Guild) onButtonPress(ev *gdk.Event) bool {
+	btn := ev.AsButton()
+	if btn.Button() != gdk.BUTTON_SECONDARY || Menu == nil {
+		return false
+	}
+
+	menu := gtk.NewMenu()
+	Menu(g, menu)
+	menu.PopupAtPointer(gdk.CopyEventer(btn))
+
+	return true
+}
+
 func (g *Guild) SetUnavailable(unavailable bool) {
 	g.ListBoxRow.SetSensitive(!unavailable)
 }
@@ -127,7 +150,16 @@ func (g *Guild) UpdateImage() {
 	g.Image.SetURL(g.IconURL + "?size=64")
 }
 
-// nil == none
+// UpdateUnread shows whether the guild has unread channels again, such as after
+// its mute settings change.
+func (guild *Guild) UpdateUnread(s *ningen.State) {
+	rs := guild.containsUnreadChannel(s)
+	guild.setUnread(rs != nil, rs != nil && rs.MentionCount > 0)
+}
+
+// containsUnreadChannel returns the read state of an unread channel, preferring
+// ones with mentions. Muted channels and guilds only count mentions. nil ==
+// none.
 func (guild *Guild) containsUnreadChannel(s *ningen.State) *gateway.ReadState {
 	channels, err := s.Offline().Channels(guild.ID)
 	if err != nil {
@@ -143,13 +175,11 @@ func (guild *Guild) containsUnreadChannel(s *ningen.State) *gateway.ReadState {
 
 	for _, ch := range channels {
 		// in a guild, only text channels matter:
-		if ch.Type != discord.GuildText {
+		if !isTextChannel(ch.Type) {
 			continue
 		}
 
-		if s.MutedState.Channel(ch.ID) || s.MutedState.Category(ch.ID) {
-			continue
-		}
+		muted := guild.muted || notifications.ChannelMuted(s, ch.ID)
 
 		if rs := s.ReadState.FindLast(ch.ID); rs != nil {
 			unread := true &&
@@ -157,11 +187,12 @@ func (guild *Guild) containsUnreadChannel(s *ningen.State) *gateway.ReadState {
 				ch.LastMessageID.IsValid() &&
 				ch.LastMessageID > rs.LastMessageID
 
-			if !unread {
+			pinged := rs.MentionCount > 0
+
+			if !unread || (muted && !pinged) {
 				continue
 			}
 
-			pinged := rs.MentionCount > 0
 			guild.unreadChs[ch.ID] = pinged
 
 			if found == nil || pinged {
@@ -188,6 +219,13 @@ func (guild *Guild) setUnread(unread, pinged bool) {
 	}
 }
 
+// isTextChannel returns true if the guild channel has messages that can be
+// read. Both TraverseReadState and the initial scan use this, so the indicators
+// agree.
+func isTextChannel(t discord.ChannelType) bool {
+	return t == discord.GuildText || t == discord.GuildNews
+}
+
 func escape(str string) string {
 	return html.EscapeString(str)
 }
@@ -3,19 +3,27 @@ package guild
 import (
 	"fmt"
 
+	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/avatar"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
 	"github.com/diamondburned/ningen/v2"
 )
 
+// DefaultFolderColor is the color of folders that don't have one.
+const DefaultFolderColor discord.Color = 0x7289DA
+
 type GuildFolder struct {
 	// Row that belongs to the parent list.
 	*RevealerRow
 
-	Icon *GuildFolderIcon
-	Name string
+	Icon  *GuildFolderIcon
+	ID    gateway.GuildFolderID
+	Name  string
+	Color discord.Color // 0 if it's the default
 
 	// Child list.
 	List *gtk.ListBox
@@ -24,24 +32,29 @@ type GuildFolder struct {
 	Revealed bool
 
 	stateClass string
+
+	// onEdit is called with the new name and color from the menu.
+	onEdit func(gateway.GuildFolder)
 }
 
 func newGuildFolder(
 	s *ningen.State, folder gateway.GuildFolder, onSelect func(g *Guild)) *GuildFolder {
 
-	if folder.Color == 0 {
-		folder.Color = 0x7289DA
-	}
-
 	guildList := gtk.NewListBox()
 	guildList.SetActivateOnSingleClick(true)
 
 	f := &GuildFolder{
 		List:   guildList,
+		ID:     folder.ID,
 		Name:   folder.Name,
+		Color:  folder.Color,
 		Guilds: make([]*Guild, 0, len(folder.GuildIDs)),
 	}
 
+	if folder.Color == 0 {
+		folder.Color = DefaultFolderColor
+	}
+
 	// Bind the child list independent of the parent list.
 	guildList.Connect("row-activated", func(l *gtk.ListBox, r *gtk.ListBoxRow) {
 		i := r.Index()
@@ -59,6 +72,7 @@ func newGuildFolder(
 	f.RevealerRow = newRevealerRow(f.Icon, guildList, func(reveal bool) {
 		// Expand/collapse the icon
 		f.Icon.setReveal(reveal)
+		f.Revealed = reveal
 	})
 
 	for _, id := range folder.GuildIDs {
@@ -81,6 +95,9 @@ func newGuildFolder(
 	// Show name on hover.
 	BindNameDirect(f.RevealerRow.Button, f.RevealerRow.Strip, &f.Name)
 
+	f.RevealerRow.Button.AddEvents(int(gdk.ButtonPressMask))
+	f.RevealerRow.Button.Connect("button-press-event", f.onButtonPress)
+
 	// Color time.
 	color := fmt.Sprintf("#%06X", folder.Color.Uint32())
 
@@ -101,6 +118,103 @@ func newGuildFolder(
 	return f
 }
 
+// folder returns the folder in the format of the user settings.
+func (f *GuildFolder) folder() gateway.GuildFolder {
+	ids := make([]discord.GuildID, len(f.Guilds))
+	for i, g := range f.Guilds {
+		ids[i] = g.ID
+	}
+
+	return gateway.GuildFolder{
+		ID:       f.ID,
+		Name:     f.Name,
+		Color:    f.Color,
+		GuildIDs: ids,
+	}
+}
+
+func (f *GuildFolder) setRevealed(reveal bool) {
+	f.Revealer.SetRevealChild(reveal)
+	f.Icon.setReveal(reveal)
+	f.Strip.SetSuppress(reveal)
+	f.Revealed = reveal
+}
+
+// onButtonPress shows the menu that renames and recolors the folder.
+func (f *GuildFolder) onButtonPress(ev *gdk.Event) bool {
+	btn := ev.AsButton()
+	if btn.Button() != gdk.BUTTON_SECONDARY || f.onEdit == nil {
+		return false
+	}
+
+	rename := gtk.NewMenuItemWithLabel("Rename Folder")
+	rename.Connect("activate", f.rename)
+	rename.Show()
+
+	recolor := gtk.NewMenuItemWithLabel("Change Folder Color")
+	recolor.Connect("activate", f.recolor)
+	recolor.Show()
+
+	menu := gtk.NewMenu()
+	menu.Add(rename)
+	menu.Add(recolor)
+	menu.PopupAtPointer(gdk.CopyEventer(btn))
+
+	return true
+}
+
+func (f *GuildFolder) rename() {
+	name, ok := window.Prompt(nil, "Rename Folder", "Name the folder:", "Rename", f.Name)
+	if !ok || name == f.Name {
+		return
+	}
+
+	folder := f.folder()
+	folder.Name = name
+	f.onEdit(folder)
+}
+
+func (f *GuildFolder) recolor() {
+	color := f.Color
+	if color == 0 {
+		color = DefaultFolderColor
+	}
+
+	r, g, b := color.RGB()
+	rgba := gdk.NewRGBA(float64(r)/255, float64(g)/255, float64(b)/255, 1)
+
+	d := gtk.NewColorChooserDialog("Folder Color", &window.Window.Window)
+	d.SetUseAlpha(false)
+	d.SetRGBA(&rgba)
+
+	resp := d.Run()
+	chosen := d.RGBA()
+	d.Destroy()
+
+	if gtk.ResponseType(resp) != gtk.ResponseOK {
+		return
+	}
+
+	folder := f.folder()
+	folder.Color = discord.Color(
+		uint32(chosen.Red()*255)<<16 | uint32(chosen.Green()*255)<<8 | uint32(chosen.Blue()*255),
+	)
+	f.onEdit(folder)
+}
+
+// remove removes the guild from the folder.
+func (f *GuildFolder) remove(g *Guild) {
+	for i, guild := range f.Guilds {
+		if guild == g {
+			f.List.Remove(g)
+			f.Guilds = append(f.Guilds[:i], f.Guilds[i+1:]...)
+			break
+		}
+	}
+
+	f.setUnread(false, false)
+}
+
 func (f *GuildFolder) unselectAll(except int) {
 	for i, r := range f.Guilds {
 		if i == except {
@@ -8,6 +8,7 @@ import (
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
 	"github.com/diamondburned/ningen/v2"
 	"github.com/diamondburned/ningen/v2/states/read"
 )
@@ -36,22 +37,8 @@ func NewGuilds(s *ningen.State) *Guilds {
 }
 
 func newGuildsFromFolders(s *ningen.State, folders []gateway.GuildFolder) *Guilds {
-	rows := make([]gtk.Widgetter, 0, len(folders))
 	g := &Guilds{}
-
-	for i := 0; i < len(folders); i++ {
-		f := folders[i]
-
-		if len(f.GuildIDs) == 1 {
-			r := newGuildRow(s, f.GuildIDs[0], nil)
-			rows = append(rows, r)
-		} else {
-			e := newGuildFolder(s, f, g.onFolderSelect)
-			rows = append(rows, e)
-		}
-	}
-
-	g.Guilds = rows
+	g.Guilds = g.newRows(s, folders)
 	initGuilds(g, s)
 	return g
 }
@@ -60,8 +47,6 @@ func newGuildsLegacy(s *ningen.State, positions []discord.GuildID) *Guilds {
 	// TODO: retry mechanism.
 	guilds, _ := s.Guilds()
 
-	rows := make([]gtk.Widgetter, 0, len(guilds))
-
 	sort.Slice(guilds, func(a, b int) bool {
 		var found = false
 		for _, guild := range positions {
@@ -76,16 +61,42 @@ func newGuildsLegacy(s *ningen.State, positions []discord.GuildID) *Guilds {
 		return false
 	})
 
-	for _, g := range guilds {
-		r := newGuildRow(s, g.ID, nil)
-		rows = append(rows, r)
+	// Each guild is in its own folder, which is how Discord saves it too.
+	folders := make([]gateway.GuildFolder, len(guilds))
+	for i, guild := range guilds {
+		folders[i].GuildIDs = []discord.GuildID{guild.ID}
 	}
 
-	g := &Guilds{
-		Guilds: rows,
+	return newGuildsFromFolders(s, folders)
+}
+
+// newRows makes the rows of the guilds and folders, which can be dragged.
+func (g *Guilds) newRows(s *ningen.State, folders []gateway.GuildFolder) []gtk.Widgetter {
+	rows := make([]gtk.Widgetter, 0, len(folders))
+
+	for i := 0; i < len(folders); i++ {
+		f := folders[i]
+
+		switch len(f.GuildIDs) {
+		case 0:
+			continue
+		case 1:
+			r := newGuildRow(s, f.GuildIDs[0], nil)
+			r.folder = f
+			g.bindDrag(r.Event, dragGuild, r.ID)
+			rows = append(rows, r)
+		default:
+			e := newGuildFolder(s, f, g.onFolderSelect)
+			e.onEdit = g.editFolder
+			g.bindDrag(e.RevealerRow.Button, dragFolder, f.GuildIDs[0])
+			for _, r := range e.Guilds {
+				g.bindDrag(r.Event, dragGuild, r.ID)
+			}
+			rows = append(rows, e)
+		}
 	}
-	initGuilds(g, s)
-	return g
+
+	return rows
 }
 
 func initGuilds(g *Guilds, s *ningen.State) {
@@ -105,6 +116,7 @@ func initGuilds(g *Guilds, s *ningen.State) {
 	// Add the button to the second of the list:
 	g.DMButton = NewPMButton(s)
 	g.ListBox.Insert(g.DMButton, -1)
+	g.bindDrop(g.DMButton, dropTarget{})
 
 	// Add the rest:
 	for _, guild := range g.Guilds {
@@ -116,6 +128,26 @@ func initGuilds(g *Guilds, s *ningen.State) {
 	s.ReadState.OnUpdate(func(rs *read.UpdateEvent) {
 		glib.IdleAdd(func() { g.TraverseReadState(rs) })
 	})
+	s.AddHandler(func(ev *gateway.UserSettingsUpdateEvent) {
+		// Another client might have moved the guilds.
+		if len(ev.GuildFolders) > 0 {
+			glib.IdleAdd(func() { g.syncFolders(ev.GuildFolders) })
+		}
+	})
+	s.AddHandler(func(ev *gateway.GuildDeleteEvent) {
+		// Unavailable guilds are still joined.
+		if !ev.Unavailable {
+			glib.IdleAdd(func() { g.Remove(ev.ID) })
+		}
+	})
+	s.AddHandler(func(ev *gateway.UserGuildSettingsUpdateEvent) {
+		glib.IdleAdd(func() {
+			if guild, _ := g.FindByID(ev.GuildID); guild != nil {
+				guild.muted = notifications.GuildMuted(s, guild.ID)
+				guild.UpdateUnread(s)
+			}
+		})
+	})
 }
 
 func (g *Guilds) rowActivated(l *gtk.ListBox, r *gtk.ListBoxRow) {
@@ -219,19 +251,51 @@ func (guilds *Guilds) Find(fn func(*Guild) bool) (*Guild, *GuildFolder) {
 	return nil, nil
 }
 
+// Remove removes the guild that was left. If it was selected, the direct
+// messages are shown instead.
+func (guilds *Guilds) Remove(guildID discord.GuildID) {
+	guild, folder := guilds.FindByID(guildID)
+	if guild == nil {
+		return
+	}
+
+	if folder != nil {
+		folder.remove(guild)
+	}
+
+	// Remove the guild's own row, or the folder if it's now empty.
+	for i, row := range guilds.Guilds {
+		if row == guild || (row == folder && len(folder.Guilds) == 0) {
+			guilds.ListBox.Remove(row)
+			guilds.Guilds = append(guilds.Guilds[:i], guilds.Guilds[i+1:]...)
+			break
+		}
+	}
+
+	if guilds.Current == guild {
+		guilds.Current = nil
+		guilds.Select(guilds.DMButton.ListBoxRow)
+
+		if guilds.DMButton.OnClick != nil {
+			guilds.DMButton.OnClick()
+		}
+	}
+}
+
 func (guilds *Guilds) TraverseReadState(rs *read.UpdateEvent) {
 	ch, err := guilds.state.Offline().Channel(rs.ChannelID)
 	if err != nil {
 		return
 	}
 	if !ch.GuildID.IsValid() {
-		// DM:
-		guilds.DMButton.setUnread(rs.Unread)
+		// DM: rescan, since other DMs might still be unread and muted ones
+		// don't count.
+		guilds.DMButton.resetRead(guilds.state)
 		return
 	}
 	// Ignore if this isn't a DM channel and not a guild text channel either.
 	// It's probably something we don't support.
-	if ch.Type != discord.GuildText {
+	if !isTextChannel(ch.Type) {
 		return
 	}
 
@@ -243,15 +307,11 @@ func (guilds *Guilds) TraverseReadState(rs *read.UpdateEvent) {
 	pinged := rs.MentionCount > 0
 	unread := rs.Unread
 
-	if guilds.state.MutedState.Channel(ch.ID) {
+	// Muted channels and guilds only show mentions.
+	if (guild.muted || notifications.ChannelMuted(guilds.state, ch.ID)) && !pinged {
 		unread = false
 	}
 
-	// TODO: confirm that nothing breaks with this running prematurely.
-	if guild.muted {
-		return
-	}
-
 	if !unread {
 		delete(guild.unreadChs, ch.ID)
 	} else {
@@ -0,0 +1,330 @@
+package guild
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// Folders returns the order of the guilds and folders in the list, in the
+// format of the user settings. Guilds that aren't in a folder are in their own.
+func (guilds *Guilds) Folders() []gateway.GuildFolder {
+	folders := make([]gateway.GuildFolder, 0, len(guilds.Guilds))
+
+	for _, row := range guilds.Guilds {
+		switch row := row.(type) {
+		case *Guild:
+			folder := row.folder
+			folder.GuildIDs = []discord.GuildID{row.ID}
+			folders = append(folders, folder)
+		case *GuildFolder:
+			folders = append(folders, row.folder())
+		}
+	}
+
+	return folders
+}
+
+// GuildIDs returns the guilds in the order that they're listed in.
+func (guilds *Guilds) GuildIDs() []discord.GuildID {
+	var ids []discord.GuildID
+	for _, folder := range guilds.Folders() {
+		ids = append(ids, folder.GuildIDs...)
+	}
+	return ids
+}
+
+// setFolders rebuilds the list with the new order. The selected guild and the
+// opened folders stay so.
+func (guilds *Guilds) setFolders(folders []gateway.GuildFolder) {
+	revealed := map[gateway.GuildFolderID]bool{}
+
+	for _, row := range guilds.Guilds {
+		if f, ok := row.(*GuildFolder); ok && f.Revealed {
+			revealed[f.ID] = true
+		}
+		guilds.ListBox.Remove(row)
+	}
+
+	guilds.Guilds = guilds.newRows(guilds.state, folders)
+
+	for _, row := range guilds.Guilds {
+		guilds.ListBox.Insert(row, -1)
+	}
+
+	guilds.ListBox.ShowAll()
+
+	for _, row := range guilds.Guilds {
+		if f, ok := row.(*GuildFolder); ok && revealed[f.ID] {
+			f.setRevealed(true)
+		}
+	}
+
+	if guilds.Current == nil {
+		return
+	}
+
+	guild, folder := guilds.FindByID(guilds.Current.ID)
+	guilds.Current = guild
+
+	// The direct messages might be shown instead.
+	if guild == nil || guilds.DMButton.IsSelected() {
+		return
+	}
+
+	if folder != nil {
+		folder.List.SelectRow(guild.ListBoxRow)
+	} else {
+		guilds.ListBox.SelectRow(guild.ListBoxRow)
+	}
+
+	guild.Unread.SetActive(true)
+}
+
+// syncFolders shows the order that was changed by another client.
+func (guilds *Guilds) syncFolders(folders []gateway.GuildFolder) {
+	if !sameFolders(guilds.Folders(), folders) {
+		guilds.setFolders(folders)
+	}
+}
+
+// rearrange shows the new order right away, then saves it to the user
+// settings. The old order comes back if that fails.
+func (guilds *Guilds) rearrange(folders []gateway.GuildFolder) {
+	old := guilds.Folders()
+	guilds.setFolders(folders)
+
+	go func() {
+		if err := saveFolders(guilds.state, folders); err != nil {
+			log.Errorln("failed to save guild folders:", err)
+			glib.IdleAdd(func() { guilds.setFolders(old) })
+		}
+	}()
+}
+
+// editFolder saves the folder's new name and color.
+func (guilds *Guilds) editFolder(edited gateway.GuildFolder) {
+	folders := guilds.Folders()
+
+	i, _ := findGuild(folders, edited.GuildIDs[0])
+	if i == -1 {
+		return
+	}
+
+	folders[i] = edited
+	guilds.rearrange(folders)
+}
+
+// saveFolders changes the user settings, then updates the state without
+// waiting for the gateway.
+func saveFolders(s *ningen.State, folders []gateway.GuildFolder) error {
+	var data struct {
+		GuildFolders   []gateway.GuildFolder `json:"guild_folders"`
+		GuildPositions []discord.GuildID     `json:"guild_positions"`
+	}
+
+	data.GuildFolders = folders
+	for _, folder := range folders {
+		data.GuildPositions = append(data.GuildPositions, folder.GuildIDs...)
+	}
+
+	var settings gateway.UserSettings
+
+	err := s.Client.Client.RequestJSON(
+		&settings, "PATCH", api.EndpointMe+"/settings",
+		httputil.WithJSONBody(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.State.Handler.Call(&gateway.UserSettingsUpdateEvent{UserSettings: settings})
+	return nil
+}
+
+// sameFolders returns true if both have the same guilds in the same order, and
+// the folders have the same IDs, names and colors.
+func sameFolders(a, b []gateway.GuildFolder) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if len(a[i].GuildIDs) != len(b[i].GuildIDs) {
+			return false
+		}
+
+		for j := range a[i].GuildIDs {
+			if a[i].GuildIDs[j] != b[i].GuildIDs[j] {
+				return false
+			}
+		}
+
+		if a[i].ID != b[i].ID || a[i].Name != b[i].Name || a[i].Color != b[i].Color {
+			return false
+		}
+	}
+
+	return true
+}
+
+type dropPosition uint8
+
+const (
+	dropBefore dropPosition = iota
+	dropInto
+	dropAfter
+)
+
+// dropTarget is what's dropped on: a guild, or the folder of the guild. The
+// zero value is the direct messages button, which moves to the top.
+type dropTarget struct {
+	guildID discord.GuildID
+	folder  bool
+}
+
+// moveGuild moves the guild next to the target, or into its folder. Dropping
+// a guild into another one makes a new folder with both. It returns nil if
+// nothing moves.
+func moveGuild(
+	folders []gateway.GuildFolder,
+	guildID discord.GuildID, target dropTarget, pos dropPosition) []gateway.GuildFolder {
+
+	if guildID == target.guildID {
+		return nil
+	}
+
+	// A guild that's on its own keeps its folder when it's moved.
+	own := gateway.GuildFolder{GuildIDs: []discord.GuildID{guildID}}
+	if i, _ := findGuild(folders, guildID); i != -1 && len(folders[i].GuildIDs) == 1 {
+		own = folders[i]
+		own.GuildIDs = []discord.GuildID{guildID}
+	}
+
+	folders = removeGuild(copyFolders(folders), guildID)
+
+	i, j := findGuild(folders, target.guildID)
+	if i == -1 {
+		return nil
+	}
+
+	inFolder := len(folders[i].GuildIDs) > 1
+
+	switch {
+	case inFolder && !target.folder:
+		// Dropped on a guild in a folder, so it goes next to it.
+		if pos == dropAfter {
+			j++
+		}
+		folders[i].GuildIDs = insertGuild(folders[i].GuildIDs, j, guildID)
+
+	case pos == dropInto && inFolder:
+		folders[i].GuildIDs = append(folders[i].GuildIDs, guildID)
+
+	case pos == dropInto:
+		if folders[i].ID == 0 {
+			folders[i].ID = newFolderID()
+		}
+		folders[i].GuildIDs = append(folders[i].GuildIDs, guildID)
+
+	default:
+		if pos == dropAfter {
+			i++
+		}
+		folders = insertFolder(folders, i, own)
+	}
+
+	return folders
+}
+
+// moveFolder moves the folder of the guild before or after the target's.
+// Folders can't be put into others. It returns nil if nothing moves.
+func moveFolder(
+	folders []gateway.GuildFolder,
+	guildID discord.GuildID, target dropTarget, pos dropPosition) []gateway.GuildFolder {
+
+	from, _ := findGuild(folders, guildID)
+	to, _ := findGuild(folders, target.guildID)
+	if from == -1 || to == -1 || from == to {
+		return nil
+	}
+
+	folders = copyFolders(folders)
+
+	folder := folders[from]
+	folders = append(folders[:from], folders[from+1:]...)
+
+	to, _ = findGuild(folders, target.guildID)
+	if pos != dropBefore {
+		to++
+	}
+
+	return insertFolder(folders, to, folder)
+}
+
+func copyFolders(folders []gateway.GuildFolder) []gateway.GuildFolder {
+	copied := make([]gateway.GuildFolder, len(folders))
+	for i, folder := range folders {
+		copied[i] = folder
+		copied[i].GuildIDs = append([]discord.GuildID(nil), folder.GuildIDs...)
+	}
+	return copied
+}
+
+// removeGuild removes the guild, and its folder if it's now empty.
+func removeGuild(folders []gateway.GuildFolder, guildID discord.GuildID) []gateway.GuildFolder {
+	i, j := findGuild(folders, guildID)
+	if i == -1 {
+		return folders
+	}
+
+	ids := folders[i].GuildIDs
+	folders[i].GuildIDs = append(ids[:j], ids[j+1:]...)
+
+	if len(folders[i].GuildIDs) == 0 {
+		folders = append(folders[:i], folders[i+1:]...)
+	}
+
+	return folders
+}
+
+// findGuild returns the index of the folder with the guild and the index of
+// the guild in it, or -1 if it's not found.
+func findGuild(folders []gateway.GuildFolder, guildID discord.GuildID) (int, int) {
+	for i, folder := range folders {
+		for j, id := range folder.GuildIDs {
+			if id == guildID {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+func insertGuild(ids []discord.GuildID, i int, id discord.GuildID) []discord.GuildID {
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+func insertFolder(folders []gateway.GuildFolder, i int, f gateway.GuildFolder) []gateway.GuildFolder {
+	folders = append(folders, gateway.GuildFolder{})
+	copy(folders[i+1:], folders[i:])
+	folders[i] = f
+	return folders
+}
+
+var folderRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// newFolderID makes a random ID for a new folder, like the official client.
+func newFolderID() gateway.GuildFolderID {
+	return gateway.GuildFolderID(folderRand.Int31())
+}
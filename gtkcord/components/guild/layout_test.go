@@ -0,0 +1,100 @@
+package guild
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+)
+
+func layout(groups ...[]discord.GuildID) []gateway.GuildFolder {
+	folders := make([]gateway.GuildFolder, len(groups))
+	for i, ids := range groups {
+		folders[i].GuildIDs = ids
+		if len(ids) > 1 {
+			folders[i].ID = gateway.GuildFolderID(i + 1)
+		}
+	}
+	return folders
+}
+
+func guildIDs(folders []gateway.GuildFolder) [][]discord.GuildID {
+	groups := make([][]discord.GuildID, len(folders))
+	for i, folder := range folders {
+		groups[i] = folder.GuildIDs
+	}
+	return groups
+}
+
+type ids = []discord.GuildID
+
+func TestMoveGuild(t *testing.T) {
+	// 1, [2 3], 4
+	folders := layout(ids{1}, ids{2, 3}, ids{4})
+
+	tests := []struct {
+		name   string
+		guild  discord.GuildID
+		target dropTarget
+		pos    dropPosition
+		want   [][]discord.GuildID
+	}{
+		{"before guild", 4, dropTarget{1, false}, dropBefore, [][]discord.GuildID{{4}, {1}, {2, 3}}},
+		{"after guild", 1, dropTarget{4, false}, dropAfter, [][]discord.GuildID{{2, 3}, {4}, {1}}},
+		{"into guild", 4, dropTarget{1, false}, dropInto, [][]discord.GuildID{{1, 4}, {2, 3}}},
+		{"into folder", 1, dropTarget{2, true}, dropInto, [][]discord.GuildID{{2, 3, 1}, {4}}},
+		{"next to folder", 4, dropTarget{2, true}, dropBefore, [][]discord.GuildID{{1}, {4}, {2, 3}}},
+		{"in folder", 4, dropTarget{3, false}, dropBefore, [][]discord.GuildID{{1}, {2, 4, 3}}},
+		{"out of folder", 3, dropTarget{1, false}, dropBefore, [][]discord.GuildID{{3}, {1}, {2}, {4}}},
+	}
+
+	for _, test := range tests {
+		moved := moveGuild(folders, test.guild, test.target, test.pos)
+		if got := guildIDs(moved); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if got := guildIDs(folders); !reflect.DeepEqual(got, [][]discord.GuildID{{1}, {2, 3}, {4}}) {
+		t.Errorf("original layout changed: %v", got)
+	}
+
+	if moved := moveGuild(folders, 4, dropTarget{1, false}, dropInto); moved[0].ID == 0 {
+		t.Error("new folder has no ID")
+	}
+
+	named := layout(ids{1}, ids{2, 3}, ids{4})
+	named[2].ID = 9
+	named[2].Name = "Mine"
+
+	if moved := moveGuild(named, 4, dropTarget{1, false}, dropBefore); moved[0].ID != 9 || moved[0].Name != "Mine" {
+		t.Errorf("guild lost its own folder: %+v", moved[0])
+	}
+
+	if moved := moveGuild(named, 1, dropTarget{4, false}, dropInto); moved[1].ID != 9 || moved[1].Name != "Mine" {
+		t.Errorf("folder of the target guild changed: %+v", moved[1])
+	}
+
+	if moved := moveGuild(folders, 1, dropTarget{1, false}, dropInto); moved != nil {
+		t.Errorf("guild moved onto itself: %v", guildIDs(moved))
+	}
+}
+
+func TestMoveFolder(t *testing.T) {
+	folders := layout(ids{1}, ids{2, 3}, ids{4})
+
+	moved := moveFolder(folders, 2, dropTarget{4, false}, dropAfter)
+	want := [][]discord.GuildID{{1}, {4}, {2, 3}}
+	if got := guildIDs(moved); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if moved[2].ID != folders[1].ID {
+		t.Errorf("folder lost its ID")
+	}
+
+	if moved := moveFolder(folders, 2, dropTarget{3, false}, dropBefore); moved != nil {
+		t.Errorf("folder moved onto itself: %v", guildIDs(moved))
+	}
+}
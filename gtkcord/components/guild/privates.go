@@ -74,8 +74,9 @@ func (dm *DMButton) resetRead(s *ningen.State) {
 			return
 		}
 
-		channel.ScanUnreadDMs(s, chs, func(ch *discord.Channel) {
-			glib.IdleAdd(func() { dm.setUnread(true) })
-		})
+		var unread bool
+		channel.ScanUnreadDMs(s, chs, func(ch *discord.Channel) { unread = true })
+
+		glib.IdleAdd(func() { dm.setUnread(unread) })
 	}()
 }
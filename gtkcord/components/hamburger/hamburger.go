@@ -3,6 +3,7 @@ package hamburger
 import (
 	"github.com/diamondburned/arikawa/v2/gateway"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/about"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/downloads"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/popup"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
@@ -15,6 +16,7 @@ type Opts struct {
 	State    *ningen.State
 	LogOut   func()
 	Settings func()
+	MarkRead func()
 }
 
 type Popover struct {
@@ -70,12 +72,24 @@ func wrapHamburger(body *popup.UserPopupBody, destroy func(), opts Opts) {
 	statusBtn.SetObjectProperty("menu-name", "status")
 	menu.Add(statusBtn)
 
+	readBtn := newButton("Mark All as Read", func() {
+		destroy()
+		opts.MarkRead()
+	})
+	menu.Add(readBtn)
+
 	propBtn := newButton("Properties", func() {
 		destroy()
 		opts.Settings()
 	})
 	menu.Add(propBtn)
 
+	downloadsBtn := newButton("Downloads", func() {
+		destroy()
+		downloads.Present()
+	})
+	menu.Add(downloadsBtn)
+
 	logoutBtn := newButton("Log Out", func() {
 		destroy()
 		opts.LogOut()
@@ -0,0 +1,350 @@
+// Package inbox shows the recent messages that mention the user in a popover
+// from the header.
+package inbox
+
+import (
+	"html"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/md"
+	"github.com/diamondburned/gtkcord3/gtkcord/mentions"
+	"github.com/diamondburned/gtkcord3/internal/humanize"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+const (
+	inboxWidth  = 420
+	inboxHeight = 480
+)
+
+// allGuilds is the filter ID that shows the mentions from every guild.
+const allGuilds = "0"
+
+// Button is the header button that opens the inbox.
+type Button struct {
+	*gtk.MenuButton
+	Inbox *Inbox
+}
+
+// NewButton creates a new inbox button. open is called with the message that's
+// picked, after the popover is closed.
+func NewButton(s *ningen.State, state *mentions.State, open func(discord.Message)) *Button {
+	icon := gtk.NewImageFromIconName("mail-unread-symbolic", int(gtk.IconSizeSmallToolbar))
+
+	b := &Button{MenuButton: gtk.NewMenuButton()}
+	b.SetTooltipText("Mentions")
+	b.SetHAlign(gtk.AlignCenter)
+	b.Add(icon)
+	b.ShowAll()
+
+	popover := gtk.NewPopover(b.MenuButton)
+
+	b.Inbox = NewInbox(s, state, func(msg discord.Message) {
+		popover.Popdown()
+		open(msg)
+	})
+
+	popover.Add(b.Inbox)
+	b.SetPopover(popover)
+	b.SetUsePopover(true)
+
+	// Only fetch the mentions once they're looked at.
+	b.Connect("toggled", func() {
+		if b.Active() {
+			b.Inbox.Reload()
+		}
+	})
+
+	return b
+}
+
+// Inbox lists the recent mentions, which can be filtered by guild.
+type Inbox struct {
+	*gtk.Box
+	Guilds *gtk.ComboBoxText
+	List   *gtk.ListBox
+	Empty  *gtk.Label
+
+	state    *ningen.State
+	mentions *mentions.State
+	open     func(discord.Message)
+
+	messages []discord.Message
+	guildID  discord.GuildID // filter, 0 for all
+	loaded   bool
+	updating bool // ignore the filter changing while it's remade
+}
+
+// NewInbox creates a new inbox. The mentions aren't fetched until Reload is
+// called.
+func NewInbox(s *ningen.State, state *mentions.State, open func(discord.Message)) *Inbox {
+	i := &Inbox{
+		state:    s,
+		mentions: state,
+		open:     open,
+	}
+
+	title := gtk.NewLabel("Mentions")
+	title.SetXAlign(0)
+	title.SetAttributes(gtkutils.PangoAttrs(
+		pango.NewAttrWeight(pango.WeightSemibold),
+	))
+
+	i.Guilds = gtk.NewComboBoxText()
+	i.Guilds.SetTooltipText("Show mentions from")
+	i.Guilds.Connect("changed", i.onFilter)
+
+	top := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	gtkutils.Margin(top, 8)
+	top.PackStart(title, true, true, 0)
+	top.PackEnd(i.Guilds, false, false, 0)
+
+	i.Empty = gtk.NewLabel("No recent mentions")
+	i.Empty.StyleContext().AddClass("dim-label")
+	i.Empty.SetLineWrap(true)
+	gtkutils.Margin(i.Empty, 15)
+	i.Empty.Show()
+
+	i.List = gtk.NewListBox()
+	i.List.SetSelectionMode(gtk.SelectionNone)
+	i.List.SetActivateOnSingleClick(true)
+	i.List.SetPlaceholder(i.Empty)
+	i.List.Connect("row-activated", func(row *gtk.ListBoxRow) {
+		shown := i.shown()
+		if n := row.Index(); n >= 0 && n < len(shown) {
+			i.open(shown[n])
+		}
+	})
+
+	scroll := gtk.NewScrolledWindow(nil, nil)
+	scroll.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scroll.SetSizeRequest(inboxWidth, inboxHeight)
+	scroll.Add(i.List)
+
+	i.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	i.Box.PackStart(top, false, false, 0)
+	i.Box.PackStart(gtk.NewSeparator(gtk.OrientationHorizontal), false, false, 0)
+	i.Box.PackStart(scroll, true, true, 0)
+	i.Box.ShowAll()
+
+	// Keep the list up to date once it's been shown.
+	state.OnUpdate(func() {
+		glib.IdleAdd(func() {
+			if i.loaded {
+				i.Reload()
+			}
+		})
+	})
+
+	return i
+}
+
+// Reload fetches the mentions if they haven't been, then shows them.
+func (i *Inbox) Reload() {
+	if !i.loaded {
+		i.Empty.SetText("Loading...")
+	}
+
+	go func() {
+		msgs, err := i.mentions.Messages()
+		if err != nil {
+			log.Errorln("failed to get mentions:", err)
+			glib.IdleAdd(func() { i.Empty.SetText("Failed to get mentions.") })
+			return
+		}
+
+		glib.IdleAdd(func() {
+			i.loaded = true
+			i.Empty.SetText("No recent mentions")
+			i.setMessages(msgs)
+		})
+	}()
+}
+
+func (i *Inbox) setMessages(msgs []discord.Message) {
+	i.messages = msgs
+	i.updateGuilds()
+	i.render()
+}
+
+// updateGuilds remakes the filter with the guilds that have mentions.
+func (i *Inbox) updateGuilds() {
+	i.updating = true
+	defer func() { i.updating = false }()
+
+	i.Guilds.RemoveAll()
+	i.Guilds.Append(allGuilds, "All Servers")
+
+	var found bool
+	added := map[discord.GuildID]bool{}
+
+	for _, msg := range i.messages {
+		guildID := i.guildOf(msg)
+		if !guildID.IsValid() || added[guildID] {
+			continue
+		}
+		added[guildID] = true
+
+		name := guildID.String()
+		if g, err := i.state.Cabinet.Guild(guildID); err == nil {
+			name = humanize.TrimString(g.Name, 32)
+		}
+
+		i.Guilds.Append(guildID.String(), name)
+		found = found || guildID == i.guildID
+	}
+
+	// Show everything if the guild has no more mentions.
+	if !found {
+		i.guildID = 0
+	}
+
+	i.Guilds.SetActiveID(i.guildID.String())
+}
+
+func (i *Inbox) onFilter() {
+	if i.updating {
+		return
+	}
+
+	id, err := discord.ParseSnowflake(i.Guilds.ActiveID())
+	if err != nil {
+		id = 0
+	}
+
+	i.guildID = discord.GuildID(id)
+	i.render()
+}
+
+// shown returns the messages that pass the filter.
+func (i *Inbox) shown() []discord.Message {
+	if !i.guildID.IsValid() {
+		return i.messages
+	}
+
+	var shown []discord.Message
+	for _, msg := range i.messages {
+		if i.guildOf(msg) == i.guildID {
+			shown = append(shown, msg)
+		}
+	}
+	return shown
+}
+
+func (i *Inbox) render() {
+	for _, child := range i.List.Children() {
+		i.List.Remove(child)
+	}
+
+	for _, msg := range i.shown() {
+		i.List.Insert(i.newRow(msg), -1)
+	}
+}
+
+// guildOf returns the message's guild. Messages from the mentions endpoint
+// might not have it, so it's taken from the channel.
+func (i *Inbox) guildOf(msg discord.Message) discord.GuildID {
+	if msg.GuildID.IsValid() {
+		return msg.GuildID
+	}
+	if ch, err := i.state.Cabinet.Channel(msg.ChannelID); err == nil {
+		return ch.GuildID
+	}
+	return 0
+}
+
+func (i *Inbox) newRow(msg discord.Message) *gtk.ListBoxRow {
+	author := gtk.NewLabel("")
+	author.SetMarkup("<b>" + html.EscapeString(msg.Author.Username) + "</b>")
+	author.SetEllipsize(pango.EllipsizeEnd)
+	author.SetXAlign(0)
+
+	if guildID := i.guildOf(msg); guildID.IsValid() {
+		if m, err := i.state.Cabinet.Member(guildID, msg.Author.ID); err == nil && m.Nick != "" {
+			author.SetMarkup("<b>" + html.EscapeString(m.Nick) + "</b>")
+		}
+	}
+
+	where := gtk.NewLabel(i.location(msg))
+	where.SetEllipsize(pango.EllipsizeEnd)
+	where.SetXAlign(0)
+	where.StyleContext().AddClass("dim-label")
+
+	timestamp := gtk.NewLabel(humanize.TimeAgo(msg.Timestamp.Time().Local()))
+	timestamp.SetXAlign(0)
+	timestamp.StyleContext().AddClass("dim-label")
+
+	content := gtk.NewLabel("")
+	content.SetMarkup(string(md.ParseToSimpleMarkupWithMessage(
+		[]byte(humanize.TrimString(msg.Content, 256)), i.state.Cabinet, &msg,
+	)))
+	content.SetXAlign(0)
+	content.SetLineWrap(true)
+	content.SetLineWrapMode(pango.WrapWordChar)
+	content.SetLines(3)
+	content.SetEllipsize(pango.EllipsizeEnd)
+
+	dismiss := gtk.NewButtonFromIconName("window-close-symbolic", int(gtk.IconSizeButton))
+	dismiss.SetRelief(gtk.ReliefNone)
+	dismiss.SetVAlign(gtk.AlignStart)
+	dismiss.SetTooltipText("Dismiss")
+	dismiss.Connect("clicked", func() {
+		dismiss.SetSensitive(false)
+		i.dismiss(msg.ID, func() { dismiss.SetSensitive(true) })
+	})
+
+	top := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	top.PackStart(author, false, false, 0)
+	top.PackStart(where, true, true, 0)
+
+	labels := gtk.NewBox(gtk.OrientationVertical, 2)
+	labels.PackStart(top, false, false, 0)
+	labels.PackStart(content, false, false, 0)
+	labels.PackStart(timestamp, false, false, 0)
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	gtkutils.Margin(box, 6)
+	box.PackStart(labels, true, true, 0)
+	box.PackEnd(dismiss, false, false, 0)
+
+	row := gtk.NewListBoxRow()
+	row.SetTooltipText("Jump to message")
+	row.Add(box)
+	row.ShowAll()
+
+	return row
+}
+
+// location returns the channel and guild names of the message.
+func (i *Inbox) location(msg discord.Message) string {
+	ch, err := i.state.Cabinet.Channel(msg.ChannelID)
+	if err != nil || ch.Name == "" {
+		return ""
+	}
+
+	location := "#" + ch.Name
+
+	if ch.GuildID.IsValid() && i.guildID != ch.GuildID {
+		if g, err := i.state.Cabinet.Guild(ch.GuildID); err == nil {
+			location += " · " + g.Name
+		}
+	}
+
+	return location
+}
+
+// dismiss removes the mention. The row is removed once the state updates. failed
+// is called in the main thread if it can't be dismissed.
+func (i *Inbox) dismiss(msgID discord.MessageID, failed func()) {
+	go func() {
+		if err := i.mentions.Dismiss(msgID); err != nil {
+			log.Errorln("failed to dismiss mention:", err)
+			glib.IdleAdd(failed)
+		}
+	}()
+}
@@ -0,0 +1,176 @@
+// Package inspector provides a developer window that logs gateway events and
+// REST requests.
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/internal/eventname"
+	"github.com/diamondburned/gtkcord3/internal/humanize"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// Inspector is the developer window. It only records while enabled.
+type Inspector struct {
+	*gtk.Window
+	Stack *gtk.Stack
+
+	Gateway *LogView
+	REST    *LogView
+	Objects *LogView // objects inspected from the UI
+
+	state   *ningen.State
+	enabled uint32 // atomic
+	rmEvent func()
+
+	lastEvent time.Time // only accessed in the main thread
+
+	started sync.Map // httpdriver.Request -> time.Time
+}
+
+// New creates a new inspector for the given state. The REST hooks are
+// installed immediately, but they do nothing until the inspector is enabled.
+func New(s *ningen.State) *Inspector {
+	i := &Inspector{
+		Gateway: newLogView("gateway"),
+		REST:    newLogView("rest"),
+		Objects: newLogView("objects"),
+		state:   s,
+	}
+
+	i.Stack = gtk.NewStack()
+	i.Stack.SetTransitionType(gtk.StackTransitionTypeCrossfade)
+	i.Stack.AddTitled(i.Gateway, "gateway", "Gateway")
+	i.Stack.AddTitled(i.REST, "rest", "REST")
+	i.Stack.AddTitled(i.Objects, "objects", "Objects")
+	i.Stack.Show()
+
+	switcher := gtk.NewStackSwitcher()
+	switcher.SetStack(i.Stack)
+	switcher.Show()
+
+	header := gtk.NewHeaderBar()
+	header.SetShowCloseButton(true)
+	header.SetCustomTitle(switcher)
+	header.Show()
+
+	i.Window = gtk.NewWindow(gtk.WindowToplevel)
+	i.Window.SetTitle("Inspector")
+	i.Window.SetTransientFor(&window.Window.Window)
+	i.Window.SetDefaultSize(700, 600)
+	i.Window.SetTitlebar(header)
+	i.Window.Add(i.Stack)
+
+	// Only hide the window, since it's reused.
+	i.Window.Connect("delete-event", func() bool {
+		i.Window.Hide()
+		return true
+	})
+
+	s.Client.Client.OnRequest = append(s.Client.Client.OnRequest, i.onRequest)
+	s.Client.Client.OnResponse = append(s.Client.Client.OnResponse, i.onResponse)
+
+	return i
+}
+
+// Enabled returns true if the inspector is recording.
+func (i *Inspector) Enabled() bool {
+	return atomic.LoadUint32(&i.enabled) == 1
+}
+
+// SetEnabled starts or stops recording. It must be called in the main thread.
+func (i *Inspector) SetEnabled(enabled bool) {
+	if enabled == i.Enabled() {
+		return
+	}
+
+	if enabled {
+		atomic.StoreUint32(&i.enabled, 1)
+		i.rmEvent = i.state.AddHandler(i.onEvent)
+		return
+	}
+
+	atomic.StoreUint32(&i.enabled, 0)
+	i.rmEvent()
+	i.rmEvent = nil
+	i.Window.Hide()
+}
+
+// Present shows the inspector window.
+func (i *Inspector) Present() {
+	i.Window.ShowAll()
+	i.Window.Present()
+}
+
+// Inspect shows the JSON of the given value in the Objects page.
+func (i *Inspector) Inspect(name string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(err.Error())
+	}
+
+	i.Objects.Add(Entry{
+		Time: time.Now(),
+		Name: name,
+		Info: humanize.Size(uint64(len(b))),
+		Body: b,
+	})
+
+	if row := i.Objects.List.RowAtIndex(len(i.Objects.entries) - 1); row != nil {
+		i.Objects.List.SelectRow(row)
+	}
+	i.Present()
+	i.Stack.SetVisibleChildName("objects")
+}
+
+func (i *Inspector) onEvent(ev interface{}) {
+	now := time.Now()
+
+	// The payload is re-encoded, so its size may differ slightly from what
+	// Discord actually sent.
+	b, err := json.Marshal(ev)
+	if err != nil {
+		b, _ = json.Marshal(err.Error())
+	}
+
+	name := eventName(ev)
+
+	glib.IdleAdd(func() {
+		// Show the time since the last event to make bursts easier to spot.
+		var delta time.Duration
+		if !i.lastEvent.IsZero() {
+			delta = now.Sub(i.lastEvent)
+		}
+		i.lastEvent = now
+
+		i.Gateway.Add(Entry{
+			Time: now,
+			Name: name,
+			Info: fmt.Sprintf("%s  +%s", humanize.Size(uint64(len(b))), delta.Round(time.Millisecond)),
+			Body: b,
+		})
+	})
+}
+
+// eventName returns the Discord name of the event, such as MESSAGE_CREATE. For
+// events that aren't from Discord, the Go type name is returned instead.
+func eventName(ev interface{}) string {
+	if name, ok := eventname.Of(ev); ok {
+		return name
+	}
+
+	t := reflect.TypeOf(ev)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.String()
+}
@@ -0,0 +1,273 @@
+package inspector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+// MaxEntries is the maximum number of entries kept in each log. Older entries
+// are dropped first.
+const MaxEntries = 1000
+
+// Entry is a single line in a log.
+type Entry struct {
+	Time time.Time       `json:"time"`
+	Name string          `json:"name"`
+	Info string          `json:"info,omitempty"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// LogView is a filterable list of entries with a JSON viewer below it.
+type LogView struct {
+	*gtk.Box
+
+	Filter *gtk.SearchEntry
+	Pause  *gtk.ToggleButton
+	Export *gtk.Button
+	Clear  *gtk.Button
+
+	List   *gtk.ListBox
+	Detail *gtk.TextView
+
+	entries []Entry // same order as the rows in List
+	dropped int     // entries dropped while paused
+}
+
+var logCSS = gtkutils.CSSAdder(`
+	.inspector-log row {
+		padding: 2px 6px;
+	}
+	.inspector-log .time,
+	.inspector-log .info {
+		opacity: 0.65;
+	}
+	.inspector-detail {
+		font-family: monospace;
+	}
+`)
+
+func newLogView(name string) *LogView {
+	filter := gtk.NewSearchEntry()
+	filter.SetPlaceholderText("Filter by name")
+	filter.SetHExpand(true)
+
+	pause := gtk.NewToggleButtonWithLabel("Pause")
+	export := gtk.NewButtonWithLabel("Export")
+	clear := gtk.NewButtonWithLabel("Clear")
+
+	toolbar := gtk.NewBox(gtk.OrientationHorizontal, 5)
+	gtkutils.Margin(toolbar, 5)
+	toolbar.PackStart(filter, true, true, 0)
+	toolbar.PackStart(pause, false, false, 0)
+	toolbar.PackStart(export, false, false, 0)
+	toolbar.PackStart(clear, false, false, 0)
+
+	list := gtk.NewListBox()
+	list.SetSelectionMode(gtk.SelectionBrowse)
+	gtkutils.InjectCSS(list, "inspector-log", "")
+	logCSS(list.StyleContext())
+
+	listScroll := gtk.NewScrolledWindow(nil, nil)
+	listScroll.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	listScroll.SetVExpand(true)
+	listScroll.Add(list)
+
+	detail := gtk.NewTextView()
+	detail.SetEditable(false)
+	detail.SetWrapMode(gtk.WrapWordChar)
+	gtkutils.Margin(detail, 5)
+	gtkutils.InjectCSS(detail, "inspector-detail", "")
+	logCSS(detail.StyleContext())
+
+	detailScroll := gtk.NewScrolledWindow(nil, nil)
+	detailScroll.SetPolicy(gtk.PolicyAutomatic, gtk.PolicyAutomatic)
+	detailScroll.Add(detail)
+
+	paned := gtk.NewPaned(gtk.OrientationVertical)
+	paned.Pack1(listScroll, true, false)
+	paned.Pack2(detailScroll, true, false)
+	paned.SetPosition(300)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 0)
+	box.PackStart(toolbar, false, false, 0)
+	box.PackStart(paned, true, true, 0)
+	box.ShowAll()
+
+	v := &LogView{
+		Box:    box,
+		Filter: filter,
+		Pause:  pause,
+		Export: export,
+		Clear:  clear,
+		List:   list,
+		Detail: detail,
+	}
+
+	list.SetFilterFunc(func(row *gtk.ListBoxRow) bool {
+		return v.matches(row.Index())
+	})
+	filter.Connect("search-changed", list.InvalidateFilter)
+
+	list.Connect("row-selected", func(l *gtk.ListBox, row *gtk.ListBoxRow) {
+		if row != nil {
+			v.showDetail(row.Index())
+		}
+	})
+
+	pause.Connect("toggled", func() {
+		if !pause.Active() {
+			v.dropped = 0
+			pause.SetLabel("Pause")
+		}
+	})
+
+	export.Connect("clicked", func() {
+		v.export(name + ".jsonl")
+	})
+
+	clear.Connect("clicked", func() {
+		for _, child := range list.Children() {
+			list.Remove(child)
+		}
+		v.entries = v.entries[:0]
+		detail.Buffer().SetText("")
+	})
+
+	return v
+}
+
+// Add appends an entry to the log. It must be called in the main thread.
+func (v *LogView) Add(entry Entry) {
+	if v.Pause.Active() {
+		v.dropped++
+		v.Pause.SetLabel(fmt.Sprintf("Paused (%d)", v.dropped))
+		return
+	}
+
+	if len(v.entries) >= MaxEntries {
+		if row := v.List.RowAtIndex(0); row != nil {
+			v.List.Remove(row)
+		}
+		v.entries = append(v.entries[:0], v.entries[1:]...)
+	}
+
+	v.entries = append(v.entries, entry)
+	v.List.Add(newEntryRow(entry))
+}
+
+func newEntryRow(entry Entry) *gtk.ListBoxRow {
+	t := gtk.NewLabel(entry.Time.Format("15:04:05.000"))
+	t.StyleContext().AddClass("time")
+
+	name := gtk.NewLabel(entry.Name)
+	name.SetXAlign(0)
+	name.SetHExpand(true)
+	name.SetEllipsize(pango.EllipsizeEnd)
+
+	info := gtk.NewLabel(entry.Info)
+	info.SetXAlign(1)
+	info.StyleContext().AddClass("info")
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 10)
+	box.PackStart(t, false, false, 0)
+	box.PackStart(name, true, true, 0)
+	box.PackStart(info, false, false, 0)
+
+	row := gtk.NewListBoxRow()
+	row.Add(box)
+	row.ShowAll()
+
+	return row
+}
+
+func (v *LogView) matches(ix int) bool {
+	if ix < 0 || ix >= len(v.entries) {
+		return true
+	}
+
+	filter := strings.ToLower(v.Filter.Text())
+	if filter == "" {
+		return true
+	}
+
+	return strings.Contains(strings.ToLower(v.entries[ix].Name), filter)
+}
+
+func (v *LogView) showDetail(ix int) {
+	if ix < 0 || ix >= len(v.entries) {
+		return
+	}
+
+	v.Detail.Buffer().SetText(prettyJSON(v.entries[ix].Body))
+}
+
+func prettyJSON(body json.RawMessage) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return string(body)
+	}
+
+	return buf.String()
+}
+
+// export writes all entries as JSON lines into a file chosen by the user.
+func (v *LogView) export(filename string) {
+	d := gtk.NewFileChooserNative(
+		"Export", &window.Window.Window, gtk.FileChooserActionSave, "", "",
+	)
+	d.SetCurrentName(filename)
+	d.SetDoOverwriteConfirmation(true)
+
+	if resp := d.Run(); gtk.ResponseType(resp) != gtk.ResponseAccept {
+		return
+	}
+
+	// Copy the entries, since the slice may change while we're writing.
+	entries := append([]Entry(nil), v.entries...)
+	path := d.Filename()
+
+	go func() {
+		if err := writeEntries(path, entries); err != nil {
+			log.Errorln("failed to export inspector log:", err)
+		}
+	}()
+}
+
+func writeEntries(path string, entries []Entry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+	enc := json.NewEncoder(buf)
+
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return errors.Wrap(err, "failed to encode entry")
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write file")
+	}
+
+	return nil
+}
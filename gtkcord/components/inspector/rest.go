@@ -0,0 +1,101 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/utils/httputil/httpdriver"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+)
+
+// request is the JSON body shown for each REST request.
+type request struct {
+	Method   string `json:"method,omitempty"`
+	Path     string `json:"path"`
+	Status   int    `json:"status,omitempty"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+
+	RateLimit *rateLimit `json:"rate_limit,omitempty"`
+}
+
+type rateLimit struct {
+	Bucket     string `json:"bucket"`
+	Limit      string `json:"limit,omitempty"`
+	Remaining  string `json:"remaining,omitempty"`
+	ResetAfter string `json:"reset_after,omitempty"`
+	Global     bool   `json:"global,omitempty"`
+}
+
+func (i *Inspector) onRequest(r httpdriver.Request) error {
+	if i.Enabled() {
+		i.started.Store(r, time.Now())
+	}
+	return nil
+}
+
+func (i *Inspector) onResponse(r httpdriver.Request, resp httpdriver.Response) error {
+	v, ok := i.started.Load(r)
+	if !ok {
+		return nil
+	}
+	i.started.Delete(r)
+
+	start := v.(time.Time)
+	took := time.Since(start).Round(time.Millisecond)
+
+	req := request{
+		Path:     r.GetPath(),
+		Duration: took.String(),
+	}
+
+	// The default driver wraps the stdlib request, which has the method.
+	if r, ok := r.(*httpdriver.DefaultRequest); ok {
+		req.Method = r.Method
+	}
+
+	info := "failed"
+
+	if resp != nil {
+		req.Status = resp.GetStatus()
+		req.RateLimit = parseRateLimit(resp.GetHeader())
+
+		info = fmt.Sprintf("%d", req.Status)
+		if req.RateLimit != nil {
+			info += "  " + req.RateLimit.Bucket
+		}
+	} else {
+		req.Error = "no response"
+	}
+
+	info += "  " + req.Duration
+
+	b, _ := json.Marshal(req)
+
+	entry := Entry{
+		Time: start,
+		Name: req.Method + " " + req.Path,
+		Info: info,
+		Body: b,
+	}
+
+	glib.IdleAdd(func() { i.REST.Add(entry) })
+	return nil
+}
+
+func parseRateLimit(h http.Header) *rateLimit {
+	bucket := h.Get("X-RateLimit-Bucket")
+	if bucket == "" {
+		return nil
+	}
+
+	return &rateLimit{
+		Bucket:     bucket,
+		Limit:      h.Get("X-RateLimit-Limit"),
+		Remaining:  h.Get("X-RateLimit-Remaining"),
+		ResetAfter: h.Get("X-RateLimit-Reset-After"),
+		Global:     h.Get("X-RateLimit-Global") != "",
+	}
+}
@@ -1,7 +1,11 @@
 package completer
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/emojis"
 	"github.com/diamondburned/gtkcord3/gtkcord/md"
 
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
@@ -24,43 +28,79 @@ func (c *State) completeEmojis(word string) {
 		return
 	}
 
-	filtered := guildEmojis[:0]
-	filteredLen := 0
+	type match struct {
+		emoji   discord.Emoji
+		guild   string
+		quality int
+		score   float64
+	}
 
-	for _, guild := range guildEmojis {
-		filteredEmojis := make([]discord.Emoji, 0, MaxCompletionEntries)
+	var matches []match
 
+	for _, guild := range guildEmojis {
 		for _, e := range guild.Emojis {
-			if contains(e.Name, word) {
-				filteredEmojis = append(filteredEmojis, e)
-				filteredLen++
-
-				if filteredLen > MaxCompletionEntries {
-					break
-				}
+			if q := matchQuality(e.Name, word); q > 0 {
+				matches = append(matches, match{e, guild.Name, q, emojis.Score(e.ID)})
 			}
 		}
+	}
 
-		guild.Emojis = filteredEmojis
-		filtered = append(filtered, guild)
-
-		if filteredLen > MaxCompletionEntries {
-			break
+	// Better matches go first, then the ones used more.
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].quality != matches[j].quality {
+			return matches[i].quality > matches[j].quality
 		}
+		return matches[i].score > matches[j].score
+	})
+
+	if len(matches) > MaxCompletionEntries {
+		matches = matches[:MaxCompletionEntries]
 	}
 
-	if len(filtered) == 0 {
-		return
+	for _, m := range matches {
+		b := gtk.NewBox(gtk.OrientationHorizontal, 0)
+
+		b.Add(completerImage(md.EmojiURL(m.emoji.ID.String(), m.emoji.Animated)))
+		b.Add(completerLeftLabel(m.emoji.Name))
+		b.Add(completerRightLabel(m.guild))
+		c.addCompletionEntry(b, m.emoji.String())
 	}
+}
 
-	for _, guild := range filtered {
-		for _, e := range guild.Emojis {
-			b := gtk.NewBox(gtk.OrientationHorizontal, 0)
+// matchQuality returns how well the name matches the lowercase word: 4 for the
+// whole name, 3 for the start of it, 2 for the start of a word in it and 1
+// for anywhere. It returns 0 if it doesn't match.
+func matchQuality(name, word string) int {
+	lower := strings.ToLower(name)
 
-			b.Add(completerImage(md.EmojiURL(e.ID.String(), e.Animated)))
-			b.Add(completerLeftLabel(e.Name))
-			b.Add(completerRightLabel(guild.Name))
-			c.addCompletionEntry(b, e.String())
+	switch i := strings.Index(lower, word); {
+	case i < 0:
+		return 0
+	case lower == word:
+		return 4
+	case i == 0:
+		return 3
+	case matchesWordStart(name, lower, word):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// matchesWordStart returns true if a word in the name, which is split by
+// underscores, dashes and camel case, starts with the word. Emoji names are
+// ASCII.
+func matchesWordStart(name, lower, word string) bool {
+	for i := 1; i < len(name); i++ {
+		prev, cur := name[i-1], name[i]
+
+		start := prev == '_' || prev == '-' ||
+			('a' <= prev && prev <= 'z' && 'A' <= cur && cur <= 'Z')
+
+		if start && strings.HasPrefix(lower[i:], word) {
+			return true
 		}
 	}
+
+	return false
 }
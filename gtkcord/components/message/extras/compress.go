@@ -0,0 +1,176 @@
+package extras
+
+import (
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gdkpixbuf/v2"
+	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+	"github.com/diamondburned/gtkcord3/internal/exif"
+	"github.com/pkg/errors"
+)
+
+// ImageOptions describes how an image is processed before it's uploaded.
+type ImageOptions struct {
+	// Compress re-encodes the image into Format, which also drops all of its
+	// metadata.
+	Compress bool
+	// Format is either "jpeg" or "webp".
+	Format string
+	// Quality is from 1 to 100. Values outside of that are clamped.
+	Quality int
+	// MaxDimension is the maximum width and height of a compressed image. 0
+	// keeps the size.
+	MaxDimension int
+	// StripLocation removes the location from JPEG files that aren't
+	// compressed.
+	StripLocation bool
+}
+
+var (
+	// DefaultImageOptions is used for newly staged images.
+	DefaultImageOptions = ImageOptions{
+		Format:        "jpeg",
+		Quality:       85,
+		MaxDimension:  2560,
+		StripLocation: true,
+	}
+	// AutoCompress compresses staged images when the files are over the
+	// upload limit.
+	AutoCompress = true
+)
+
+// ClampQuality clamps the quality to between 1 and 100.
+func ClampQuality(quality int) int {
+	switch {
+	case quality < 1:
+		return 1
+	case quality > 100:
+		return 100
+	default:
+		return quality
+	}
+}
+
+// IsImage returns true if the file looks like an image that can be processed.
+func IsImage(path string) bool {
+	MIME := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	return strings.HasPrefix(MIME, "image/") && MIME != "image/gif" && MIME != "image/svg+xml"
+}
+
+// CanEncode returns true if gdk-pixbuf can save images in the format. WebP
+// needs an extra loader to be installed.
+func CanEncode(format string) bool {
+	for _, f := range gdkpixbuf.PixbufGetFormats() {
+		if f.Name() == format {
+			return f.IsWritable()
+		}
+	}
+	return false
+}
+
+// ProcessImage processes the image at the path and returns the path to the
+// result, which is path itself if nothing needed to be done. It may take a
+// while, so it shouldn't be called in the main thread.
+func ProcessImage(path string, opts ImageOptions) (string, error) {
+	if opts.Compress {
+		return compressImage(path, opts)
+	}
+
+	if opts.StripLocation && isJPEG(path) {
+		return stripLocation(path)
+	}
+
+	return path, nil
+}
+
+func isJPEG(path string) bool {
+	return mime.TypeByExtension(strings.ToLower(filepath.Ext(path))) == "image/jpeg"
+}
+
+func compressImage(path string, opts ImageOptions) (string, error) {
+	p, err := gdkpixbuf.NewPixbufFromFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to load image")
+	}
+
+	// The orientation is lost with the rest of the metadata.
+	if rotated := p.ApplyEmbeddedOrientation(); rotated != nil {
+		p = rotated
+	}
+
+	w, h := p.Width(), p.Height()
+	if opts.MaxDimension > 0 {
+		w, h = cache.MaxSize(w, h, opts.MaxDimension, opts.MaxDimension)
+	}
+
+	switch {
+	case opts.Format == "jpeg" && p.HasAlpha():
+		// JPEG has no transparency, so put the image on white instead of
+		// letting transparent pixels turn black.
+		p = p.CompositeColorSimple(w, h, gdkpixbuf.InterpBilinear, 255, 8, 0xFFFFFF, 0xFFFFFF)
+	case w != p.Width() || h != p.Height():
+		p = p.ScaleSimple(w, h, gdkpixbuf.InterpBilinear)
+	}
+
+	if p == nil {
+		return "", errors.New("failed to scale image")
+	}
+
+	f, err := os.CreateTemp(cache.TmpPath(), "upload-*."+extension(opts.Format))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create file")
+	}
+	f.Close()
+
+	quality := strconv.Itoa(ClampQuality(opts.Quality))
+	if err := p.Savev(f.Name(), opts.Format, []string{"quality"}, []string{quality}); err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, "failed to encode image")
+	}
+
+	return f.Name(), nil
+}
+
+func stripLocation(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read image")
+	}
+
+	stripped, err := exif.StripLocation(b)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to strip location")
+	}
+	if !stripped {
+		return path, nil
+	}
+
+	f, err := os.CreateTemp(cache.TmpPath(), "upload-*.jpg")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, "failed to write image")
+	}
+
+	return f.Name(), nil
+}
+
+func extension(format string) string {
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
+
+// ReplaceExt replaces the extension of the file name with the format's.
+func ReplaceExt(name, format string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "." + extension(format)
+}
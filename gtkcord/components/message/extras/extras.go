@@ -22,6 +22,11 @@ func NewAttachment(msg *discord.Message) []gtk.Widgetter {
 	widgets := make([]gtk.Widgetter, 0, len(msg.Attachments))
 
 	for _, att := range msg.Attachments {
+		if kind := mediaKind(att.Filename); kind != "" {
+			widgets = append(widgets, newAttachmentPlayer(att, kind == "video"))
+			continue
+		}
+
 		if att.Width == 0 || att.Height == 0 || !validExt(att.Proxy, attachmentFormats) {
 			widgets = append(widgets, NewAnyAttachment(att.Filename, att.URL, att.Size))
 			continue
@@ -42,6 +47,25 @@ func NewAttachment(msg *discord.Message) []gtk.Widgetter {
 	return widgets
 }
 
+func newAttachmentPlayer(att discord.Attachment, video bool) gtk.Widgetter {
+	fallback := func() gtk.Widgetter {
+		return NewAnyAttachment(att.Filename, att.URL, att.Size)
+	}
+
+	var poster string
+	w, h := maxSize(
+		int(att.Width), int(att.Height),
+		variables.EmbedMaxWidth, variables.EmbedImgHeight,
+	)
+
+	// The media proxy gives the first frame of videos as a JPEG.
+	if video && att.Width > 0 && att.Height > 0 {
+		poster = sizeToURL(att.Proxy, w, h) + "&format=jpeg"
+	}
+
+	return NewMediaPlayer(att.Filename, att.URL, poster, w, h, video, fallback)
+}
+
 func NewEmbed(s *ningen.State, msg *discord.Message) []gtk.Widgetter {
 	if len(msg.Embeds) == 0 {
 		return nil
@@ -68,8 +92,13 @@ func newEmbed(s *ningen.State, msg *discord.Message, embed discord.Embed) gtk.Wi
 	case discord.ImageEmbed:
 		return newImageEmbed(embed)
 	case discord.VideoEmbed:
-		// I'm tired and lazy.
-		if embed.Thumbnail != nil && embed.Image == nil {
+		if embed.Video != nil && mediaKind(embed.Video.URL) == "video" {
+			return newVideoEmbed(s, msg, embed)
+		}
+
+		// Videos that can't be played inline get a poster that opens them.
+		// Without a video, the thumbnail is all there is to show.
+		if embed.Video == nil && embed.Thumbnail != nil && embed.Image == nil {
 			img := embed.Thumbnail
 			embed.Image = &discord.EmbedImage{
 				URL:    img.URL,
@@ -87,6 +116,32 @@ func newEmbed(s *ningen.State, msg *discord.Message, embed discord.Embed) gtk.Wi
 	return nil
 }
 
+// newVideoEmbed plays embedded video files inline, with the thumbnail as the
+// poster. Videos that can't be played fall back to a normal embed with a poster
+// that opens them.
+func newVideoEmbed(s *ningen.State, msg *discord.Message, embed discord.Embed) gtk.Widgetter {
+	var poster string
+	w, h := maxSize(
+		int(embed.Video.Width), int(embed.Video.Height),
+		variables.EmbedMaxWidth, variables.EmbedImgHeight,
+	)
+
+	if embed.Thumbnail != nil {
+		poster = sizeToURL(embed.Thumbnail.Proxy, w, h)
+	}
+
+	fallback := func() gtk.Widgetter {
+		return newNormalEmbed(s, msg, embed)
+	}
+
+	name := embed.Title
+	if name == "" {
+		name = path.Base(embed.Video.URL)
+	}
+
+	return NewMediaPlayer(name, embed.Video.URL, poster, w, h, true, fallback)
+}
+
 func newImageEmbed(embed discord.Embed) gtk.Widgetter {
 	if embed.Thumbnail == nil {
 		return nil
@@ -115,7 +170,7 @@ func newExtraImage(proxy, url string, w, h int) *gtk.EventBox {
 		if !gtkutils.EventIsLeftClick(ev) {
 			return
 		}
-		SpawnPreviewDialog(proxy, url)
+		SpawnViewer(proxy, url)
 	})
 	embedSetMargin(evb)
 
@@ -5,6 +5,7 @@ import (
 	"html"
 
 	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gotk4/pkg/pango"
 	"github.com/diamondburned/gtkcord3/gtkcord/cache"
@@ -16,43 +17,30 @@ import (
 	"github.com/diamondburned/ningen/v2"
 )
 
+const (
+	// embedFieldColumns is the maximum number of inline fields in a row.
+	embedFieldColumns = 3
+	// embedThumbSize is the maximum size of thumbnails next to the content.
+	embedThumbSize = 80
+	// embedIconSize is the size of the author and footer icons.
+	embedIconSize = 20
+)
+
+// newNormalEmbed renders a rich embed the way Discord lays it out: the
+// provider, author, title, description and fields on the left, the thumbnail
+// floating to the right of them, then the image or video, then the footer.
 func newNormalEmbed(
 	s *ningen.State, msg *discord.Message, embed discord.Embed) gtk.Widgetter {
 
 	content := gtk.NewBox(gtk.OrientationVertical, 0)
-	content.SetHAlign(gtk.AlignFill)
-
-	widthHint := 0 // used for calculating requested embed width
-
-	if embed.Author != nil {
-		box := gtk.NewBox(gtk.OrientationHorizontal, 0)
-		embedSetMargin(box)
-
-		if embed.Author.ProxyIcon != "" {
-			img := roundimage.NewImage(0)
-			img.SetMarginEnd(variables.EmbedMargin)
-			cache.SetImageStreamed(img, embed.Author.ProxyIcon, 24, 24)
-			box.Add(img)
-		}
-
-		if embed.Author.Name != "" {
-			author := gtk.NewLabel(embed.Author.Name)
-			author.SetUseMarkup(true)
-			author.SetLineWrap(true)
-			author.SetLineWrapMode(pango.WrapWordChar)
-			author.SetXAlign(0.0)
-
-			if embed.Author.URL != "" {
-				author.SetMarkup(fmt.Sprintf(
-					`<a href="%s">%s</a>`,
-					html.EscapeString(embed.Author.URL), html.EscapeString(embed.Author.Name),
-				))
-			}
+	content.SetHExpand(true)
 
-			box.Add(author)
-		}
+	if embed.Provider != nil && embed.Provider.Name != "" {
+		content.Add(newEmbedProvider(embed.Provider))
+	}
 
-		content.Add(box)
+	if embed.Author != nil && (embed.Author.Name != "" || embed.Author.ProxyIcon != "") {
+		content.Add(newEmbedAuthor(embed.Author))
 	}
 
 	if embed.Title != "" {
@@ -90,143 +78,284 @@ func newNormalEmbed(
 	}
 
 	if len(embed.Fields) > 0 {
-		fields := gtk.NewGrid()
-		fields.SetRowSpacing(uint(7))
-		fields.SetColumnSpacing(uint(14))
-		embedSetMargin(fields)
-
-		content.Add(fields)
-
-		col, row := 0, 0
-
-		for _, field := range embed.Fields {
-			text := gtk.NewLabel("")
-			text.SetEllipsize(pango.EllipsizeEnd)
-			text.SetXAlign(0.0)
-			text.SetMarkup(fmt.Sprintf(
-				`<span weight="heavy">%s</span>`+"\n"+`<span weight="light">%s</span>`,
-				field.Name, field.Value,
-			))
-			text.SetTooltipText(field.Name + "\n" + field.Value)
-
-			// I have no idea what this does. It')s just improvised.
-			if field.Inline && col < 3 {
-				fields.Attach(text, col, row, 1, 1)
-				col++
-			} else {
-				if col > 0 {
-					row++
-				}
-
-				col = 0
-				fields.Attach(text, col, row, 1, 1)
-
-				if !field.Inline {
-					row++
-				} else {
-					col++
-				}
-			}
-		}
+		content.Add(newEmbedFields(s, msg, embed.Fields))
 	}
 
-	if embed.Footer != nil || embed.Timestamp.IsValid() {
-		footer := gtk.NewBox(gtk.OrientationHorizontal, 0)
-		embedSetMargin(footer)
-
-		if embed.Footer != nil {
-			if embed.Footer.ProxyIcon != "" {
-				img := roundimage.NewImage(0)
-				img.SetMarginEnd(variables.EmbedMargin)
-				cache.SetImageStreamed(img, embed.Footer.ProxyIcon, 24, 24)
-				footer.Add(img)
-			}
-
-			if embed.Footer.Text != "" {
-				text := gtk.NewLabel(embed.Footer.Text)
-				text.SetVAlign(gtk.AlignStart)
-				text.SetOpacity(0.65)
-				text.SetLineWrap(true)
-				text.SetLineWrapMode(pango.WrapWordChar)
-				text.SetXAlign(0.0)
-
-				footer.Add(text)
-			}
-		}
-
-		if embed.Timestamp.IsValid() {
-			time := humanize.TimeAgo(embed.Timestamp.Time())
-
-			text := gtk.NewLabel(time)
-			text.SetAttributes(gtkutils.PangoAttrs(
-				pango.NewAttrScale(0.85),
-				pango.NewAttrForegroundAlpha(0xDDDD),
-			))
-			if embed.Footer != nil {
-				text.SetText(" - " + time)
-			}
-
-			footer.Add(text)
-		}
-
-		content.Add(footer)
-	}
-
-	if embed.Thumbnail != nil {
-		wrapper := gtk.NewBox(gtk.OrientationHorizontal, 0)
-		wrapper.Add(content)
-
-		// Do a shitty hack:
-		content = wrapper
-		content.SetHAlign(gtk.AlignStart)
+	// The thumbnail floats to the right of the content. Videos use it as their
+	// poster instead.
+	body := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	body.PackStart(content, true, true, 0)
 
+	if embed.Thumbnail != nil && embed.Video == nil {
 		w, h := int(embed.Thumbnail.Width), int(embed.Thumbnail.Height)
-		w, h = maxSize(w, h, 80, 80)
+		w, h = maxSize(w, h, embedThumbSize, embedThumbSize)
 
-		wrapper.Add(newExtraImage(
+		thumb := newExtraImage(
 			sizeToURL(embed.Thumbnail.Proxy, w, h),
-			embed.Thumbnail.URL, 0, 0,
-		))
+			embed.Thumbnail.URL, w, h,
+		)
+		thumb.SetVAlign(gtk.AlignStart)
+		body.PackEnd(thumb, false, false, 0)
 	}
 
-	if embed.Image != nil {
-		wrapper := gtk.NewBox(gtk.OrientationVertical, 0)
-		wrapper.Add(content)
+	main := gtk.NewBox(gtk.OrientationVertical, 0)
+	main.SetHAlign(gtk.AlignStart)
+	main.Add(body)
 
-		// Do a shitty hack again:
-		content = wrapper
-		content.SetHAlign(gtk.AlignStart)
+	widthHint := 0 // used for calculating requested embed width
 
+	switch {
+	case embed.Video != nil:
+		if poster, w := newEmbedVideoPoster(embed); poster != nil {
+			widthHint = w
+			main.Add(poster)
+		}
+
+	case embed.Image != nil:
 		w, h := int(embed.Image.Width), int(embed.Image.Height)
 		w, h = maxSize(w, h, variables.EmbedMaxWidth, variables.EmbedImgHeight)
 
 		// set width hint to resize embeds accordingly
 		widthHint = w
 
-		wrapper.Add(newExtraImage(
+		main.Add(newExtraImage(
 			sizeToURL(embed.Image.Proxy, w, h),
 			embed.Image.URL, w, h,
 		))
 	}
 
+	if embed.Footer != nil || embed.Timestamp.IsValid() {
+		main.Add(newEmbedFooter(embed.Footer, embed.Timestamp))
+	}
+
 	// Calculate the embed width without padding:
 	var w = clampWidth(variables.EmbedMaxWidth)
 	if widthHint > 0 && w > widthHint {
 		w = widthHint
 	}
 
-	// Wrap the content inside another box:
-	main := gtk.NewBox(gtk.OrientationVertical, 0)
-	main.SetHAlign(gtk.AlignStart)
 	main.SetSizeRequest(w+(variables.EmbedMargin*2), 0)
-	main.Add(content)
 
-	// Apply margin to content:
-	content.SetMarginTop(variables.EmbedMargin) // account for children not having margin top
-	content.SetMarginBottom(variables.EmbedMargin / 2)
+	// Pad the content, since children have no top margin:
+	body.SetMarginTop(variables.EmbedMargin)
+	main.SetMarginBottom(variables.EmbedMargin / 2)
+
+	color := embed.Color
+	if color == 0 {
+		color = discord.DefaultEmbedColor
+	}
 
-	// Add a frame around the main embed:
-	gtkutils.InjectCSS(main, "embed", fmt.Sprintf(EmbedMainCSS, embed.Color))
+	// Add the colored side bar:
+	gtkutils.InjectCSS(main, "embed", fmt.Sprintf(EmbedMainCSS, color))
 
 	return main
 }
+
+func newEmbedProvider(provider *discord.EmbedProvider) gtk.Widgetter {
+	markup := html.EscapeString(provider.Name)
+	if provider.URL != "" {
+		markup = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(provider.URL), markup)
+	}
+
+	label := gtk.NewLabel("")
+	label.SetMarkup(`<span size="small">` + markup + `</span>`)
+	label.SetLineWrap(true)
+	label.SetLineWrapMode(pango.WrapWordChar)
+	label.SetXAlign(0.0)
+	label.SetOpacity(0.75)
+	embedSetMargin(label)
+
+	return label
+}
+
+func newEmbedAuthor(author *discord.EmbedAuthor) gtk.Widgetter {
+	box := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	embedSetMargin(box)
+
+	if author.ProxyIcon != "" {
+		img := roundimage.NewImage(0)
+		img.SetMarginEnd(variables.EmbedMargin)
+		cache.SetImageStreamed(img, author.ProxyIcon, embedIconSize, embedIconSize)
+		box.Add(img)
+	}
+
+	if author.Name != "" {
+		markup := `<span weight="bold" size="small">` + html.EscapeString(author.Name) + `</span>`
+		if author.URL != "" {
+			markup = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(author.URL), markup)
+		}
+
+		label := gtk.NewLabel("")
+		label.SetMarkup(markup)
+		label.SetLineWrap(true)
+		label.SetLineWrapMode(pango.WrapWordChar)
+		label.SetXAlign(0.0)
+
+		box.Add(label)
+	}
+
+	return box
+}
+
+// fieldCell is where a field is placed in the fields grid.
+type fieldCell struct {
+	col, row, width int
+}
+
+// layoutFields puts up to 3 inline fields next to each other. Fields that
+// aren't inline get a row of their own.
+func layoutFields(fields []discord.EmbedField) []fieldCell {
+	cells := make([]fieldCell, len(fields))
+	col, row := 0, 0
+
+	for i, field := range fields {
+		if !field.Inline {
+			if col > 0 {
+				row++
+			}
+
+			cells[i] = fieldCell{0, row, embedFieldColumns}
+			col, row = 0, row+1
+			continue
+		}
+
+		if col == embedFieldColumns {
+			col, row = 0, row+1
+		}
+
+		cells[i] = fieldCell{col, row, 1}
+		col++
+	}
+
+	return cells
+}
+
+func newEmbedFields(s *ningen.State, msg *discord.Message, fields []discord.EmbedField) gtk.Widgetter {
+	grid := gtk.NewGrid()
+	grid.SetRowSpacing(7)
+	grid.SetColumnSpacing(14)
+	grid.SetColumnHomogeneous(true)
+	embedSetMargin(grid)
+
+	for i, cell := range layoutFields(fields) {
+		field := fields[i]
+
+		name := gtk.NewLabel("")
+		name.SetMarkup(`<span weight="heavy" size="small">` + html.EscapeString(field.Name) + `</span>`)
+		name.SetLineWrap(true)
+		name.SetLineWrapMode(pango.WrapWordChar)
+		name.SetXAlign(0.0)
+
+		value := gtk.NewLabel("")
+		value.SetMarkup(string(md.ParseToMarkupWithMessage([]byte(field.Value), s.Cabinet, msg)))
+		value.SetLineWrap(true)
+		value.SetLineWrapMode(pango.WrapWordChar)
+		value.SetXAlign(0.0)
+		value.SetAttributes(gtkutils.PangoAttrs(pango.NewAttrScale(0.9)))
+
+		box := gtk.NewBox(gtk.OrientationVertical, 2)
+		box.SetVAlign(gtk.AlignStart)
+		box.Add(name)
+		box.Add(value)
+
+		grid.Attach(box, cell.col, cell.row, cell.width, 1)
+	}
+
+	return grid
+}
+
+// newEmbedVideoPoster shows the thumbnail of a video that can't be played
+// inline with a play button over it, which opens the video. It also returns
+// the poster's width.
+func newEmbedVideoPoster(embed discord.Embed) (*gtk.EventBox, int) {
+	thumb := embed.Thumbnail
+	if thumb == nil {
+		return nil, 0
+	}
+
+	w, h := int(embed.Video.Width), int(embed.Video.Height)
+	if w == 0 || h == 0 {
+		w, h = int(thumb.Width), int(thumb.Height)
+	}
+	w, h = maxSize(w, h, variables.EmbedMaxWidth, variables.EmbedImgHeight)
+
+	img := gtk.NewImage()
+	img.SetSizeRequest(w, h)
+	cache.SetImageStreamed(img, sizeToURL(thumb.Proxy, w, h), w, h)
+
+	play := gtk.NewImageFromIconName("media-playback-start-symbolic", int(gtk.IconSizeDialog))
+	play.SetHAlign(gtk.AlignCenter)
+	play.SetVAlign(gtk.AlignCenter)
+
+	overlay := gtk.NewOverlay()
+	overlay.Add(img)
+	overlay.AddOverlay(play)
+
+	gtkutils.InjectCSS(play, "embed-play", `
+		.embed-play {
+			color: white;
+			background-color: alpha(black, 0.6);
+			border-radius: 9999px;
+			padding: 12px;
+		}
+	`)
+
+	url := embed.URL
+	if url == "" {
+		url = embed.Video.URL
+	}
+
+	evb := gtk.NewEventBox()
+	evb.Add(overlay)
+	evb.SetHAlign(gtk.AlignStart)
+	evb.SetSizeRequest(w, h)
+	evb.SetTooltipText(url)
+	evb.Connect("button-release-event", func(_ *gtk.EventBox, ev *gdk.Event) {
+		if gtkutils.EventIsLeftClick(ev) {
+			gtkutils.OpenURI(url)
+		}
+	})
+	embedSetMargin(evb)
+
+	return evb, w
+}
+
+func newEmbedFooter(footer *discord.EmbedFooter, timestamp discord.Timestamp) gtk.Widgetter {
+	box := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	embedSetMargin(box)
+
+	var text string
+
+	if footer != nil {
+		if footer.ProxyIcon != "" {
+			img := roundimage.NewImage(0)
+			img.SetMarginEnd(variables.EmbedMargin / 2)
+			img.SetVAlign(gtk.AlignStart)
+			cache.SetImageStreamed(img, footer.ProxyIcon, embedIconSize, embedIconSize)
+			box.Add(img)
+		}
+
+		text = footer.Text
+	}
+
+	if timestamp.IsValid() {
+		if text != "" {
+			text += " • "
+		}
+		text += humanize.TimeAgo(timestamp.Time())
+	}
+
+	label := gtk.NewLabel(text)
+	label.SetLineWrap(true)
+	label.SetLineWrapMode(pango.WrapWordChar)
+	label.SetXAlign(0.0)
+	label.SetOpacity(0.65)
+	label.SetAttributes(gtkutils.PangoAttrs(pango.NewAttrScale(0.85)))
+
+	if timestamp.IsValid() {
+		label.SetTooltipText(timestamp.Time().Local().Format("Monday, January 2, 2006 15:04"))
+	}
+
+	box.Add(label)
+	return box
+}
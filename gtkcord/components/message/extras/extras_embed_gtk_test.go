@@ -0,0 +1,180 @@
+//go:build gtk
+// +build gtk
+
+package extras
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/state"
+	"github.com/diamondburned/arikawa/v2/state/store"
+	"github.com/diamondburned/gotk4/pkg/gdkpixbuf/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/ningen/v2"
+)
+
+var update = flag.Bool("update", false, "update the embed snapshots in testdata")
+
+const (
+	// snapshotTolerance is the fraction of pixels that may differ from a
+	// snapshot, which absorbs small font rendering differences.
+	snapshotTolerance = 0.02
+	// pixelTolerance is how much a color channel may differ.
+	pixelTolerance = 24
+)
+
+var snapshotTime = discord.NewTimestamp(time.Date(2020, 4, 1, 12, 30, 0, 0, time.UTC))
+
+var embedSnapshots = []struct {
+	name  string
+	embed discord.Embed
+}{
+	{
+		name: "fields",
+		embed: discord.Embed{
+			Type:        discord.NormalEmbed,
+			Title:       "Server Stats",
+			URL:         "https://example.com/stats",
+			Description: "**Bold**, *italics*, `code` and [a link](https://example.com).",
+			Color:       0x5865F2,
+			Fields: []discord.EmbedField{
+				{Name: "Members", Value: "1,234", Inline: true},
+				{Name: "Online", Value: "567", Inline: true},
+				{Name: "Channels", Value: "42", Inline: true},
+				{Name: "Roles", Value: "12", Inline: true},
+				{Name: "Description", Value: "A field that isn't inline takes a whole row, even with **markdown**."},
+				{Name: "Boosts", Value: "3", Inline: true},
+			},
+		},
+	},
+	{
+		name: "author-footer",
+		embed: discord.Embed{
+			Type:        discord.NormalEmbed,
+			Title:       "Release v1.0",
+			Description: "> Quoted text\n\n- not a list, just text",
+			Color:       0x2ECC71,
+			Provider:    &discord.EmbedProvider{Name: "Example Provider", URL: "https://example.com"},
+			Author:      &discord.EmbedAuthor{Name: "Someone", URL: "https://example.com/someone"},
+			Footer:      &discord.EmbedFooter{Text: "Sent from the footer"},
+			Timestamp:   snapshotTime,
+		},
+	},
+	{
+		name: "plain",
+		embed: discord.Embed{
+			Type:        discord.ArticleEmbed,
+			Description: "An embed without a color gets the default side bar.",
+			Timestamp:   snapshotTime,
+		},
+	},
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// Keep footer timestamps the same everywhere.
+	time.Local = time.UTC
+
+	gtk.Init()
+	os.Exit(m.Run())
+}
+
+func testState() *ningen.State {
+	return &ningen.State{
+		State: &state.State{Cabinet: store.NoopCabinet},
+	}
+}
+
+func renderEmbed(t *testing.T, embed discord.Embed) *gdkpixbuf.Pixbuf {
+	t.Helper()
+
+	w := newNormalEmbed(testState(), &discord.Message{}, embed)
+
+	win := gtk.NewOffscreenWindow()
+	win.Add(w)
+	win.ShowAll()
+	defer win.Destroy()
+
+	for gtk.EventsPending() {
+		gtk.MainIteration()
+	}
+
+	p := win.Pixbuf()
+	if p == nil {
+		t.Fatal("failed to render the embed")
+	}
+
+	return p
+}
+
+func TestEmbedSnapshots(t *testing.T) {
+	for _, test := range embedSnapshots {
+		t.Run(test.name, func(t *testing.T) {
+			got := renderEmbed(t, test.embed)
+			path := filepath.Join("testdata", "embed-"+test.name+".png")
+
+			if *update {
+				if err := os.MkdirAll("testdata", 0755); err != nil {
+					t.Fatal("failed to create testdata:", err)
+				}
+				if err := got.Savev(path, "png", nil, nil); err != nil {
+					t.Fatal("failed to save snapshot:", err)
+				}
+				t.Log("wrote snapshot", path)
+				return
+			}
+
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				t.Skip("no snapshot, run with -update to make it:", path)
+			}
+
+			want, err := gdkpixbuf.NewPixbufFromFile(path)
+			if err != nil {
+				t.Fatal("failed to load snapshot:", err)
+			}
+
+			if diff := comparePixbufs(got, want); diff > snapshotTolerance {
+				actual := filepath.Join(t.TempDir(), "embed-"+test.name+".actual.png")
+				if err := got.Savev(actual, "png", nil, nil); err != nil {
+					t.Log("failed to save the rendered embed:", err)
+				}
+				t.Errorf("%.1f%% of pixels differ from %s, see %s", diff*100, path, actual)
+			}
+		})
+	}
+}
+
+// comparePixbufs returns the fraction of pixels that differ. Images of
+// different sizes differ completely.
+func comparePixbufs(a, b *gdkpixbuf.Pixbuf) float64 {
+	if a.Width() != b.Width() || a.Height() != b.Height() || a.NChannels() != b.NChannels() {
+		return 1
+	}
+
+	w, h, n := a.Width(), a.Height(), a.NChannels()
+	pa, pb := a.Pixels(), b.Pixels()
+
+	var differ int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ia := y*a.Rowstride() + x*n
+			ib := y*b.Rowstride() + x*n
+
+			for c := 0; c < n; c++ {
+				d := int(pa[ia+c]) - int(pb[ib+c])
+				if d > pixelTolerance || d < -pixelTolerance {
+					differ++
+					break
+				}
+			}
+		}
+	}
+
+	return float64(differ) / float64(w*h)
+}
@@ -0,0 +1,27 @@
+package extras
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+func TestLayoutFields(t *testing.T) {
+	inline := discord.EmbedField{Inline: true}
+	block := discord.EmbedField{}
+
+	fields := []discord.EmbedField{inline, inline, inline, inline, block, inline, block, block}
+	want := []fieldCell{
+		{0, 0, 1}, {1, 0, 1}, {2, 0, 1},
+		{0, 1, 1},
+		{0, 2, 3},
+		{0, 3, 1},
+		{0, 4, 3},
+		{0, 5, 3},
+	}
+
+	if got := layoutFields(fields); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected layout:\ngot  %v\nwant %v", got, want)
+	}
+}
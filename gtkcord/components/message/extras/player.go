@@ -0,0 +1,325 @@
+package extras
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/internal/gst"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+// playerTick is how often the position is updated while playing.
+const playerTick = 250
+
+// mediaKind returns "audio" or "video" if the URL can be played inline, or an
+// empty string otherwise. Nothing can be played without GStreamer.
+func mediaKind(url string) string {
+	if !gst.Available() {
+		return ""
+	}
+
+	ext := path.Ext(strings.Split(url, "?")[0])
+
+	switch MIME := mime.TypeByExtension(ext); {
+	case strings.HasPrefix(MIME, "audio"):
+		return "audio"
+	case strings.HasPrefix(MIME, "video"):
+		return "video"
+	}
+
+	switch ext {
+	case ".opus", ".flac", ".m4a":
+		return "audio"
+	case ".mkv", ".mov":
+		return "video"
+	}
+
+	return ""
+}
+
+// MediaPlayer plays an audio or video attachment inline. The pipeline is only
+// made once it's first played. If the media can't be played, the player turns
+// into a regular attachment row.
+type MediaPlayer struct {
+	*gtk.Box
+	Stack  *gtk.Stack
+	Poster *gtk.Image
+
+	Controls *gtk.Box
+	Play     *gtk.Button
+	Seek     *gtk.Scale
+	Time     *gtk.Label
+	Volume   *gtk.VolumeButton
+
+	url      string
+	video    bool
+	w, h     int
+	fallback func() gtk.Widgetter
+
+	player *gst.Player
+	ticker glib.SourceHandle
+	failed bool
+}
+
+// NewMediaPlayer creates a player for the URL. The poster is shown for videos
+// until they're played, and fallback is shown instead of the player if the
+// media can't be played.
+func NewMediaPlayer(name, url, poster string, w, h int, video bool, fallback func() gtk.Widgetter) *MediaPlayer {
+	m := &MediaPlayer{
+		url:      url,
+		video:    video,
+		w:        w,
+		h:        h,
+		fallback: fallback,
+	}
+
+	m.Play = gtk.NewButtonFromIconName("media-playback-start-symbolic", int(gtk.IconSizeButton))
+	m.Play.SetRelief(gtk.ReliefNone)
+	m.Play.SetTooltipText("Play")
+	m.Play.Connect("clicked", m.toggle)
+
+	m.Seek = gtk.NewScaleWithRange(gtk.OrientationHorizontal, 0, 1, 1)
+	m.Seek.SetDrawValue(false)
+	m.Seek.SetHExpand(true)
+	m.Seek.SetSensitive(false)
+	m.Seek.ConnectChangeValue(func(_ gtk.ScrollType, value float64) bool {
+		if m.player != nil {
+			m.player.Seek(time.Duration(value * float64(time.Second)))
+		}
+		return false
+	})
+
+	m.Time = gtk.NewLabel("")
+	m.Time.StyleContext().AddClass("dim-label")
+
+	m.Volume = gtk.NewVolumeButton()
+	m.Volume.SetValue(1)
+	m.Volume.ConnectValueChanged(func(value float64) {
+		if m.player != nil {
+			m.player.SetVolume(value)
+		}
+	})
+
+	m.Controls = gtk.NewBox(gtk.OrientationHorizontal, 0)
+	m.Controls.PackStart(m.Play, false, false, 0)
+	m.Controls.PackStart(m.Seek, true, true, 0)
+	m.Controls.PackStart(m.Time, false, false, 5)
+	m.Controls.PackStart(m.Volume, false, false, 0)
+
+	m.Box = gtk.NewBox(gtk.OrientationVertical, 0)
+	m.Box.SetHAlign(gtk.AlignStart)
+
+	if video {
+		m.Poster = gtk.NewImageFromIconName("video-x-generic-symbolic", int(gtk.IconSizeDialog))
+		m.Poster.SetSizeRequest(w, h)
+		if poster != "" {
+			cache.SetImageStreamed(m.Poster, poster, w, h)
+		}
+
+		posterEv := gtk.NewEventBox()
+		posterEv.Add(m.Poster)
+		posterEv.Connect("button-release-event", func(_ *gtk.EventBox, ev *gdk.Event) {
+			if gtkutils.EventIsLeftClick(ev) {
+				m.toggle()
+			}
+		})
+
+		m.Stack = gtk.NewStack()
+		m.Stack.SetTransitionType(gtk.StackTransitionTypeCrossfade)
+		m.Stack.AddNamed(posterEv, "poster")
+
+		m.Box.PackStart(m.Stack, false, false, 0)
+		m.Box.SetSizeRequest(w, -1)
+	} else {
+		title := gtk.NewLabel(
+			`<a href="` + html.EscapeString(url) + `">` + html.EscapeString(name) + `</a>`,
+		)
+		title.SetUseMarkup(true)
+		title.SetXAlign(0)
+		title.SetEllipsize(pango.EllipsizeEnd)
+		gtkutils.Margin2(title, 4, 6)
+
+		m.Box.PackStart(title, false, false, 0)
+		m.Box.SetSizeRequest(clampWidth(400), -1)
+	}
+
+	m.Box.PackStart(m.Controls, false, false, 0)
+
+	gtkutils.InjectCSS(m.Box, "attachment", `
+		.attachment { background-color: @darker; }
+	`)
+
+	m.Box.ConnectMap(m.startTicker)
+	m.Box.ConnectUnmap(m.stop)
+	m.Box.Connect("destroy", func() {
+		m.stop()
+		if m.player != nil {
+			m.player.Close()
+			m.player = nil
+		}
+	})
+
+	m.Box.ShowAll()
+	return m
+}
+
+// load creates the player the first time it's played. It returns false if
+// the player can't be made, in which case it's degraded.
+func (m *MediaPlayer) load() bool {
+	if m.failed {
+		return false
+	}
+
+	if m.player != nil {
+		return true
+	}
+
+	p, err := gst.NewPlayer(m.url)
+	if err != nil {
+		// Don't replace the button while its signal is being handled.
+		glib.IdleAdd(func() { m.degrade(err) })
+		return false
+	}
+
+	m.player = p
+	m.player.SetVolume(m.Volume.Value())
+
+	if m.video && p.Widget() != nil {
+		video := gtk.BaseWidget(p.Widget())
+		video.SetSizeRequest(m.w, m.h)
+		video.Show()
+		m.Stack.AddNamed(p.Widget(), "video")
+	}
+
+	m.startTicker()
+	return true
+}
+
+// startTicker polls the player while it's shown, if it's been made.
+func (m *MediaPlayer) startTicker() {
+	if m.player != nil && m.ticker == 0 {
+		m.ticker = glib.TimeoutAdd(playerTick, m.update)
+	}
+}
+
+// stop pauses the player when it's scrolled away.
+func (m *MediaPlayer) stop() {
+	if m.ticker != 0 {
+		glib.SourceRemove(m.ticker)
+		m.ticker = 0
+	}
+
+	if m.player != nil && m.player.Playing() {
+		m.player.Pause()
+		m.setPlaying(false)
+	}
+}
+
+func (m *MediaPlayer) toggle() {
+	if !m.load() {
+		return
+	}
+
+	if m.player.Playing() {
+		m.player.Pause()
+		m.setPlaying(false)
+		return
+	}
+
+	// Start over if it's at the end.
+	pos, _ := m.player.Position()
+	dur, ok := m.player.Duration()
+	if ok && pos >= dur {
+		m.player.Seek(0)
+	}
+
+	m.player.Play()
+	m.setPlaying(true)
+
+	if m.Stack != nil && m.Stack.ChildByName("video") != nil {
+		m.Stack.SetVisibleChildName("video")
+	}
+}
+
+func (m *MediaPlayer) setPlaying(playing bool) {
+	if playing {
+		m.Play.SetImage(gtk.NewImageFromIconName("media-playback-pause-symbolic", int(gtk.IconSizeButton)))
+		m.Play.SetTooltipText("Pause")
+	} else {
+		m.Play.SetImage(gtk.NewImageFromIconName("media-playback-start-symbolic", int(gtk.IconSizeButton)))
+		m.Play.SetTooltipText("Play")
+	}
+}
+
+// update polls the player and updates the controls.
+func (m *MediaPlayer) update() bool {
+	if m.player == nil {
+		m.ticker = 0
+		return false
+	}
+
+	eos, err := m.player.Poll()
+	if err != nil {
+		m.ticker = 0
+		m.degrade(err)
+		return false
+	}
+	if eos {
+		m.setPlaying(false)
+	}
+
+	pos, _ := m.player.Position()
+	dur, ok := m.player.Duration()
+	if ok {
+		m.Seek.SetSensitive(true)
+		m.Seek.SetRange(0, dur.Seconds())
+		m.Seek.SetValue(pos.Seconds())
+		m.Time.SetText(formatDuration(pos) + " / " + formatDuration(dur))
+	}
+
+	return true
+}
+
+// degrade replaces the player with the fallback, which gets the error as its
+// tooltip. Only unexpected errors are logged.
+func (m *MediaPlayer) degrade(err error) {
+	m.failed = true
+
+	if c := errors.Cause(err); c != gst.ErrUnavailable && c != gst.ErrNoDecoder {
+		log.Errorln("failed to play", m.url+":", err)
+	}
+
+	if m.player != nil {
+		m.player.Close()
+		m.player = nil
+	}
+
+	for _, child := range m.Box.Children() {
+		m.Box.Remove(child)
+	}
+
+	w := m.fallback()
+	gtk.BaseWidget(w).SetTooltipText(err.Error())
+	m.Box.PackStart(w, false, false, 0)
+	m.Box.SetSizeRequest(-1, -1)
+	m.Box.StyleContext().RemoveClass("attachment")
+}
+
+func formatDuration(d time.Duration) string {
+	s := int(d.Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,83 @@
+package extras
+
+import (
+	"html"
+	"strconv"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+)
+
+// StickerSize is the size stickers are shown at in messages.
+const StickerSize = 160
+
+// StickerURL returns the URL to the sticker image. APNG stickers are served as
+// PNGs too, and Lottie stickers have no image.
+func StickerURL(id discord.StickerID, size int) string {
+	return "https://media.discordapp.net/stickers/" + id.String() + ".png?size=" + strconv.Itoa(size)
+}
+
+// NewStickers renders the stickers in the message.
+func NewStickers(msg *discord.Message) []gtk.Widgetter {
+	if len(msg.Stickers) == 0 {
+		return nil
+	}
+
+	widgets := make([]gtk.Widgetter, 0, len(msg.Stickers))
+	for _, sticker := range msg.Stickers {
+		widgets = append(widgets, NewSticker(sticker.ID, sticker.Name, sticker.FormatType, StickerSize))
+	}
+
+	return widgets
+}
+
+// NewSticker renders a sticker at the given size with its name as the tooltip.
+// Lottie stickers can't be played, so a placeholder with the name is shown
+// instead.
+func NewSticker(
+	id discord.StickerID, name string, format discord.StickerFormatType, size int) gtk.Widgetter {
+
+	var w gtk.Widgetter
+
+	if format == discord.StickerFormatLottie {
+		w = newStickerPlaceholder(name, size)
+	} else {
+		img := gtk.NewImage()
+		img.SetSizeRequest(size, size)
+		cache.SetImageStreamed(img, StickerURL(id, size), size, size)
+		w = img
+	}
+
+	widget := gtk.BaseWidget(w)
+	widget.SetHAlign(gtk.AlignStart)
+	widget.SetTooltipText(name)
+	widget.Show()
+
+	return w
+}
+
+func newStickerPlaceholder(name string, size int) gtk.Widgetter {
+	icon := gtk.NewImageFromIconName("image-x-generic-symbolic", int(gtk.IconSizeDialog))
+
+	label := gtk.NewLabel("")
+	label.SetMarkup(`<span size="small">` + html.EscapeString(name) + `</span>`)
+	label.SetLineWrap(true)
+	label.SetLineWrapMode(pango.WrapWordChar)
+	label.SetJustify(gtk.JustifyCenter)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 4)
+	box.SetSizeRequest(size, size)
+	box.SetVAlign(gtk.AlignCenter)
+	box.SetOpacity(0.65)
+	box.Add(icon)
+	box.Add(label)
+	box.ShowAll()
+
+	frame := gtk.NewFrame("")
+	frame.SetSizeRequest(size, size)
+	frame.Add(box)
+
+	return frame
+}
@@ -0,0 +1,656 @@
+package extras
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gdkpixbuf/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/internal/humanize"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+const (
+	// DefaultUploadLimit is the total size of files that can be sent in a
+	// message without Nitro or server boosts.
+	DefaultUploadLimit = 8 * 1024 * 1024
+	// MaxAttachments is the maximum number of files in a message.
+	MaxAttachments = 10
+)
+
+// SpoilerPrefix makes Discord hide an attachment behind a spoiler.
+const SpoilerPrefix = "SPOILER_"
+
+const trayThumbSize = 64
+
+// textPreviewSize is how much of a text file is shown, in bytes.
+const textPreviewSize = 200
+
+// processDelay is how long the image options have to stay the same before the
+// image is processed, in milliseconds.
+const processDelay = 300
+
+// StagedFile is a file that will be sent with the next message.
+type StagedFile struct {
+	Path        string
+	Name        string
+	Description string
+	Spoiler     bool
+	Size        int64
+	// Temporary is true if the file was made for the upload, so it's removed
+	// once it's been sent.
+	Temporary bool
+}
+
+// UploadFile returns the file to be uploaded, with the spoiler prefix added if
+// needed.
+func (f StagedFile) UploadFile() UploadFile {
+	name := strings.TrimPrefix(f.Name, SpoilerPrefix)
+	if f.Spoiler {
+		name = SpoilerPrefix + name
+	}
+
+	return UploadFile{
+		Path:        f.Path,
+		Name:        name,
+		Description: f.Description,
+		Temporary:   f.Temporary,
+	}
+}
+
+// Tray shows the files staged for the next message. Each file can be renamed,
+// described, marked as a spoiler or removed before it's sent.
+type Tray struct {
+	*gtk.Revealer
+	Cards   *gtk.Box
+	Warning *gtk.Label
+
+	cards []*trayCard
+	limit int64
+}
+
+func NewTray() *Tray {
+	t := &Tray{limit: DefaultUploadLimit}
+
+	t.Cards = gtk.NewBox(gtk.OrientationHorizontal, 5)
+	gtkutils.Margin2(t.Cards, 5, 10)
+
+	scroll := gtk.NewScrolledWindow(nil, nil)
+	scroll.SetPolicy(gtk.PolicyAutomatic, gtk.PolicyNever)
+	scroll.SetPropagateNaturalHeight(true)
+	scroll.Add(t.Cards)
+
+	t.Warning = gtk.NewLabel("")
+	t.Warning.SetXAlign(0)
+	t.Warning.SetLineWrap(true)
+	t.Warning.SetNoShowAll(true)
+	gtkutils.Margin2(t.Warning, 0, 10)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 0)
+	box.PackStart(scroll, false, false, 0)
+	box.PackStart(t.Warning, false, false, 0)
+
+	t.Revealer = gtk.NewRevealer()
+	t.Revealer.SetTransitionType(gtk.RevealerTransitionTypeSlideUp)
+	t.Revealer.SetRevealChild(false)
+	t.Revealer.Add(box)
+
+	gtkutils.InjectCSS(t.Cards, "attachment-tray", `
+		.attachment-tray > * {
+			background-color: @darker;
+			border-radius: 4px;
+			padding: 5px;
+		}
+	`)
+
+	t.Revealer.ShowAll()
+	return t
+}
+
+// SetLimit sets the total size of files that can be sent in the channel.
+func (t *Tray) SetLimit(limit int64) {
+	t.limit = limit
+	t.update()
+	t.autoCompress()
+}
+
+// autoCompress compresses all images if AutoCompress is on and the files are
+// over the limit.
+func (t *Tray) autoCompress() {
+	if AutoCompress && t.total() > t.limit {
+		for _, c := range t.cards {
+			c.compress()
+		}
+	}
+}
+
+func (t *Tray) total() int64 {
+	var total int64
+	for _, c := range t.cards {
+		total += c.staged().Size
+	}
+	return total
+}
+
+// Add stages the files at the given paths. Directories are skipped.
+func (t *Tray) Add(paths ...string) {
+	for _, path := range paths {
+		t.AddNamed(path, filepath.Base(path))
+	}
+}
+
+// AddNamed stages the file at the path to be sent with the given name.
+func (t *Tray) AddNamed(path, name string) {
+	s, err := os.Stat(path)
+	if err != nil {
+		log.Errorln("failed to stage file:", err)
+		return
+	}
+	if s.IsDir() {
+		return
+	}
+
+	c := newTrayCard(StagedFile{
+		Path: path,
+		Name: name,
+		Size: s.Size(),
+	})
+	c.remove.Connect("clicked", func() { t.remove(c) })
+	c.onChange = t.update
+
+	t.cards = append(t.cards, c)
+	t.Cards.PackStart(c, false, false, 0)
+	t.update()
+	t.autoCompress()
+}
+
+func (t *Tray) remove(c *trayCard) {
+	for i, card := range t.cards {
+		if card == c {
+			t.cards = append(t.cards[:i], t.cards[i+1:]...)
+			break
+		}
+	}
+
+	c.stop()
+	c.discard()
+	t.Cards.Remove(c)
+	t.update()
+}
+
+// Len returns the number of staged files.
+func (t *Tray) Len() int {
+	return len(t.cards)
+}
+
+// Files returns the staged files.
+func (t *Tray) Files() []StagedFile {
+	files := make([]StagedFile, len(t.cards))
+	for i, c := range t.cards {
+		files[i] = c.staged()
+	}
+	return files
+}
+
+// Take returns the files to upload and empties the tray. The processed images
+// are then owned by the upload.
+func (t *Tray) Take() []UploadFile {
+	files := make([]UploadFile, len(t.cards))
+	for i, c := range t.cards {
+		files[i] = c.staged().UploadFile()
+	}
+
+	t.clear()
+	return files
+}
+
+// Clear removes all staged files.
+func (t *Tray) Clear() {
+	for _, c := range t.cards {
+		c.discard()
+	}
+	t.clear()
+}
+
+func (t *Tray) clear() {
+	for _, c := range t.cards {
+		c.stop()
+		t.Cards.Remove(c)
+	}
+	t.cards = nil
+	t.update()
+}
+
+// Problem returns why the staged files can't be sent, or an empty string if
+// they can.
+func (t *Tray) Problem() string {
+	if len(t.cards) > MaxAttachments {
+		return fmt.Sprintf("Only %d files can be sent at once.", MaxAttachments)
+	}
+
+	for _, c := range t.cards {
+		if c.busy {
+			return "Processing images..."
+		}
+	}
+
+	if total := t.total(); total > t.limit {
+		return fmt.Sprintf(
+			"The files are %s, which is over the %s upload limit.",
+			humanize.Size(uint64(total)), humanize.Size(uint64(t.limit)),
+		)
+	}
+
+	return ""
+}
+
+func (t *Tray) update() {
+	t.Revealer.SetRevealChild(len(t.cards) > 0)
+
+	problem := t.Problem()
+	t.Warning.SetMarkup(`<span color="red">` + glib.MarkupEscapeText(problem, -1) + `</span>`)
+	t.Warning.SetVisible(problem != "")
+}
+
+type trayCard struct {
+	*gtk.Box
+	file StagedFile
+
+	thumb       *gtk.Image
+	size        *gtk.Label
+	name        *gtk.Entry
+	description *gtk.Entry
+	spoiler     *gtk.ToggleButton
+	remove      *gtk.Button
+
+	image     *imageControls
+	opts      ImageOptions
+	processed string
+	procSize  int64
+	busy      bool
+	gen       int
+	timer     glib.SourceHandle
+
+	// onChange is called when the file's size changes.
+	onChange func()
+}
+
+func newTrayCard(file StagedFile) *trayCard {
+	c := &trayCard{file: file, opts: DefaultImageOptions}
+
+	c.thumb = gtk.NewImageFromIconName("text-x-generic-symbolic", int(gtk.IconSizeDialog))
+	c.thumb.SetSizeRequest(trayThumbSize, trayThumbSize)
+	c.thumb.SetTooltipText(file.Path)
+
+	if strings.HasPrefix(mime.TypeByExtension(filepath.Ext(file.Path)), "image/") {
+		go func() {
+			p, err := gdkpixbuf.NewPixbufFromFileAtScale(file.Path, trayThumbSize, trayThumbSize, true)
+			if err != nil {
+				log.Errorln("failed to load thumbnail:", err)
+				return
+			}
+			glib.IdleAdd(func() { c.thumb.SetFromPixbuf(p) })
+		}()
+	}
+
+	var preview *gtk.Label
+	if strings.HasPrefix(mime.TypeByExtension(filepath.Ext(file.Path)), "text/") {
+		preview = newTextPreview(file.Path)
+	}
+
+	c.size = gtk.NewLabel("")
+	c.size.StyleContext().AddClass("dim-label")
+
+	c.spoiler = gtk.NewToggleButton()
+	c.spoiler.SetImage(gtk.NewImageFromIconName("view-conceal-symbolic", int(gtk.IconSizeButton)))
+	c.spoiler.SetRelief(gtk.ReliefNone)
+	c.spoiler.SetTooltipText("Mark as Spoiler")
+	c.spoiler.SetActive(strings.HasPrefix(file.Name, SpoilerPrefix))
+	c.spoiler.Connect("toggled", c.updateSpoiler)
+
+	c.remove = gtk.NewButtonFromIconName("window-close-symbolic", int(gtk.IconSizeButton))
+	c.remove.SetRelief(gtk.ReliefNone)
+	c.remove.SetTooltipText("Remove")
+
+	top := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	top.PackStart(c.size, false, false, 0)
+	top.PackEnd(c.remove, false, false, 0)
+	top.PackEnd(c.spoiler, false, false, 0)
+
+	if IsImage(file.Path) {
+		c.image = newImageControls(c)
+		top.PackEnd(c.image.MenuButton, false, false, 0)
+	}
+
+	c.name = gtk.NewEntry()
+	c.name.SetText(strings.TrimPrefix(file.Name, SpoilerPrefix))
+	c.name.SetPlaceholderText("File name")
+	c.name.SetWidthChars(16)
+	c.name.SetTooltipText("File name")
+
+	c.description = gtk.NewEntry()
+	c.description.SetPlaceholderText("Description")
+	c.description.SetWidthChars(16)
+	c.description.SetTooltipText("Describes the file for people who can't see it")
+
+	c.Box = gtk.NewBox(gtk.OrientationVertical, 3)
+	c.Box.PackStart(top, false, false, 0)
+	if preview != nil {
+		c.Box.PackStart(preview, false, false, 0)
+	} else {
+		c.Box.PackStart(c.thumb, false, false, 0)
+	}
+	c.Box.PackStart(c.name, false, false, 0)
+	c.Box.PackStart(c.description, false, false, 0)
+
+	c.updateSpoiler()
+	c.updateSize()
+	c.Box.ShowAll()
+
+	if c.image != nil && c.opts.StripLocation && isJPEG(file.Path) {
+		c.process()
+	}
+
+	return c
+}
+
+// newTextPreview shows the start of a text file.
+func newTextPreview(path string) *gtk.Label {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	b := make([]byte, textPreviewSize)
+	n, _ := io.ReadFull(f, b)
+	text := strings.ToValidUTF8(string(b[:n]), "")
+
+	l := gtk.NewLabel(text)
+	l.SetXAlign(0)
+	l.SetYAlign(0)
+	l.SetLineWrap(true)
+	l.SetLineWrapMode(pango.WrapWordChar)
+	l.SetEllipsize(pango.EllipsizeEnd)
+	l.SetLines(4)
+	l.SetMaxWidthChars(20)
+	l.SetSizeRequest(-1, trayThumbSize)
+	l.SetTooltipText(path)
+	gtkutils.InjectCSS(l, "text-preview", `
+		.text-preview {
+			font-family: monospace;
+			font-size: 0.8em;
+		}
+	`)
+
+	return l
+}
+
+func (c *trayCard) updateSpoiler() {
+	if c.spoiler.Active() {
+		c.thumb.SetOpacity(0.3)
+		c.spoiler.SetTooltipText("Unmark as Spoiler")
+	} else {
+		c.thumb.SetOpacity(1)
+		c.spoiler.SetTooltipText("Mark as Spoiler")
+	}
+}
+
+// compress turns on compression if the card holds an image.
+func (c *trayCard) compress() {
+	if c.image != nil && !c.opts.Compress {
+		c.opts.Compress = true
+		c.image.load(c.opts)
+		c.process()
+	}
+}
+
+// setOptions reprocesses the image with the new options.
+func (c *trayCard) setOptions(opts ImageOptions) {
+	if opts != c.opts {
+		c.opts = opts
+		c.process()
+	}
+}
+
+// stop stops processing the image and drops a result that's still on its way.
+func (c *trayCard) stop() {
+	if c.timer != 0 {
+		glib.SourceRemove(c.timer)
+		c.timer = 0
+	}
+	c.gen++
+}
+
+// process processes the image in the background once the options stop
+// changing for a bit.
+func (c *trayCard) process() {
+	if c.timer != 0 {
+		glib.SourceRemove(c.timer)
+	}
+
+	c.gen++
+	c.busy = true
+	c.updateSize()
+	c.changed()
+
+	gen := c.gen
+	c.timer = glib.TimeoutAdd(processDelay, func() bool {
+		c.timer = 0
+		path, opts := c.file.Path, c.opts
+
+		go func() {
+			out, err := ProcessImage(path, opts)
+			var size int64
+			if err == nil {
+				var s os.FileInfo
+				if s, err = os.Stat(out); err == nil {
+					size = s.Size()
+				}
+			}
+
+			glib.IdleAdd(func() {
+				// The options changed in the meantime.
+				if gen != c.gen {
+					if err == nil && out != path {
+						os.Remove(out)
+					}
+					return
+				}
+
+				c.discard()
+				c.busy = false
+				c.processed, c.procSize = out, size
+				c.size.SetTooltipText("")
+
+				if err != nil {
+					log.Errorln("failed to process image:", err)
+					c.processed = ""
+					c.size.SetTooltipText(err.Error())
+				}
+
+				c.updateSize()
+				c.changed()
+			})
+		}()
+
+		return false
+	})
+}
+
+// updateSize shows the size before and after processing.
+func (c *trayCard) updateSize() {
+	before := humanize.Size(uint64(c.file.Size))
+
+	switch {
+	case c.busy:
+		c.size.SetText(before + " → …")
+	case c.processed != "" && c.processed != c.file.Path:
+		c.size.SetText(before + " → " + humanize.Size(uint64(c.procSize)))
+	default:
+		c.size.SetText(before)
+	}
+}
+
+// discard removes the processed image.
+func (c *trayCard) discard() {
+	if c.processed != "" && c.processed != c.file.Path {
+		os.Remove(c.processed)
+	}
+	c.processed = ""
+}
+
+func (c *trayCard) changed() {
+	if c.onChange != nil {
+		c.onChange()
+	}
+}
+
+// staged returns the file with the user's changes.
+func (c *trayCard) staged() StagedFile {
+	f := c.file
+	f.Description = strings.TrimSpace(c.description.Text())
+	f.Spoiler = c.spoiler.Active()
+
+	if name := strings.TrimSpace(c.name.Text()); name != "" {
+		f.Name = name
+	}
+
+	if c.processed != "" && c.processed != c.file.Path {
+		f.Path = c.processed
+		f.Size = c.procSize
+		f.Temporary = true
+
+		if c.opts.Compress {
+			f.Name = ReplaceExt(f.Name, c.opts.Format)
+		}
+	}
+
+	return f
+}
+
+// imageControls is the popover with a card's image options.
+type imageControls struct {
+	*gtk.MenuButton
+	compress *gtk.Switch
+	format   *gtk.ComboBoxText
+	quality  *gtk.Scale
+	maxSize  *gtk.SpinButton
+	location *gtk.Switch
+
+	loading bool
+}
+
+func newImageControls(c *trayCard) *imageControls {
+	i := &imageControls{}
+
+	i.compress = gtk.NewSwitch()
+	i.compress.SetHAlign(gtk.AlignEnd)
+
+	i.format = gtk.NewComboBoxText()
+	i.format.Append("jpeg", "JPEG")
+	if CanEncode("webp") {
+		i.format.Append("webp", "WebP")
+	}
+
+	i.quality = gtk.NewScaleWithRange(gtk.OrientationHorizontal, 10, 100, 5)
+	i.quality.SetValuePos(gtk.PosRight)
+	i.quality.SetDigits(0)
+	i.quality.SetSizeRequest(150, -1)
+
+	i.maxSize = gtk.NewSpinButtonWithRange(0, 8192, 128)
+	i.maxSize.SetTooltipText("The maximum width and height in pixels, or 0 to keep the size")
+
+	i.location = gtk.NewSwitch()
+	i.location.SetHAlign(gtk.AlignEnd)
+	i.location.SetTooltipText("Remove the location a photo was taken at")
+
+	grid := gtk.NewGrid()
+	grid.SetRowSpacing(5)
+	grid.SetColumnSpacing(10)
+	gtkutils.Margin(grid, 10)
+
+	rows := []struct {
+		label  string
+		widget gtk.Widgetter
+	}{
+		{"Compress", i.compress},
+		{"Format", i.format},
+		{"Quality", i.quality},
+		{"Maximum size", i.maxSize},
+		{"Remove location", i.location},
+	}
+	for n, row := range rows {
+		l := gtk.NewLabel(row.label)
+		l.SetXAlign(0)
+		grid.Attach(l, 0, n, 1, 1)
+		grid.Attach(row.widget, 1, n, 1, 1)
+	}
+	grid.ShowAll()
+
+	popover := gtk.NewPopover(nil)
+	popover.Add(grid)
+
+	i.MenuButton = gtk.NewMenuButton()
+	i.MenuButton.SetImage(gtk.NewImageFromIconName("emblem-system-symbolic", int(gtk.IconSizeButton)))
+	i.MenuButton.SetRelief(gtk.ReliefNone)
+	i.MenuButton.SetTooltipText("Image Options")
+	i.MenuButton.SetPopover(popover)
+
+	i.load(c.opts)
+
+	update := func() {
+		if !i.loading {
+			c.setOptions(i.options(c.opts))
+			i.updateSensitivity()
+		}
+	}
+	i.compress.Connect("notify::active", update)
+	i.format.ConnectChanged(update)
+	i.quality.ConnectValueChanged(update)
+	i.maxSize.ConnectValueChanged(update)
+	i.location.Connect("notify::active", update)
+
+	return i
+}
+
+// load shows the options without triggering any updates.
+func (i *imageControls) load(opts ImageOptions) {
+	i.loading = true
+	defer func() { i.loading = false }()
+
+	i.compress.SetActive(opts.Compress)
+	i.format.SetActiveID(opts.Format)
+	i.quality.SetValue(float64(opts.Quality))
+	i.maxSize.SetValue(float64(opts.MaxDimension))
+	i.location.SetActive(opts.StripLocation)
+	i.updateSensitivity()
+}
+
+func (i *imageControls) options(opts ImageOptions) ImageOptions {
+	opts.Compress = i.compress.Active()
+	opts.Quality = int(i.quality.Value())
+	opts.MaxDimension = i.maxSize.ValueAsInt()
+	opts.StripLocation = i.location.Active()
+	if id := i.format.ActiveID(); id != "" {
+		opts.Format = id
+	}
+	return opts
+}
+
+// updateSensitivity disables the options that only apply to compression.
+// Compressing always removes the location.
+func (i *imageControls) updateSensitivity() {
+	compress := i.compress.Active()
+	i.format.SetSensitive(compress)
+	i.quality.SetSensitive(compress)
+	i.maxSize.SetSensitive(compress)
+	i.location.SetSensitive(!compress)
+}
@@ -1,8 +1,11 @@
 package extras
 
 import (
+	"encoding/json"
 	"io"
+	"mime/multipart"
 	"os"
+	"strconv"
 	"sync/atomic"
 	"unsafe"
 
@@ -54,86 +57,139 @@ func SpawnUploader(callback func(absolutePath []string)) {
 	})
 }
 
+// UploadFile is a file to be sent with a message.
+type UploadFile struct {
+	Path string
+	// Name is the file name shown in Discord. The base name of Path is used if
+	// it's empty.
+	Name        string
+	Description string
+	// Temporary is true if Path is removed once the upload is done.
+	Temporary bool
+}
+
+// SendData is api.SendMessageData with attachment descriptions and stickers,
+// which arikawa doesn't know about.
+type SendData struct {
+	api.SendMessageData
+	Attachments []AttachmentData    `json:"attachments,omitempty"`
+	StickerIDs  []discord.StickerID `json:"sticker_ids,omitempty"`
+}
+
+// AttachmentData describes the file with the same index in Files.
+type AttachmentData struct {
+	ID          int    `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description,omitempty"`
+}
+
+// WriteMultipart writes the files as files[n], which is what the attachment IDs
+// refer to.
+func (s SendData) WriteMultipart(body *multipart.Writer) error {
+	w, err := body.CreateFormField("payload_json")
+	if err != nil {
+		return errors.Wrap(err, "failed to create bodypart for JSON")
+	}
+
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		return errors.Wrap(err, "failed to encode JSON")
+	}
+
+	for i, file := range s.Files {
+		num := strconv.Itoa(i)
+
+		w, err := body.CreateFormFile("files["+num+"]", file.Name)
+		if err != nil {
+			return errors.Wrap(err, "failed to create bodypart for "+num)
+		}
+
+		if _, err := io.Copy(w, file.Reader); err != nil {
+			return errors.Wrap(err, "failed to write for file "+num)
+		}
+	}
+
+	return nil
+}
+
 type MessageUploader struct {
 	*gtk.Box
 	progresses []*ProgressUploader
+	files      []UploadFile
 }
 
-func NewMessageUploader(paths []string) (*MessageUploader, error) {
-	var m MessageUploader
-
-	main := gtk.NewBox(gtk.OrientationVertical, 0)
-	m.Box = main
+// NewMessageUploader opens the files and creates a progress bar for each.
+func NewMessageUploader(files []UploadFile) (*MessageUploader, error) {
+	m := MessageUploader{
+		Box:        gtk.NewBox(gtk.OrientationVertical, 0),
+		progresses: make([]*ProgressUploader, 0, len(files)),
+		files:      files,
+	}
 
-	go func() {
-		type file struct {
-			*os.File
-			err  error
-			name string
-			size int64
+	for _, file := range files {
+		f, err := os.Open(file.Path)
+		if err != nil {
+			m.Close()
+			return nil, errors.Wrap(err, "failed to open")
 		}
 
-		files := make([]file, len(paths))
-		onErr := func(i int, err error, wrap string) {
-			files[i] = file{err: errors.Wrap(err, wrap)}
+		s, err := f.Stat()
+		if err != nil {
+			f.Close()
+			m.Close()
+			return nil, errors.Wrap(err, "failed to stat")
 		}
 
-		for i, path := range paths {
-			f, err := os.Open(path)
-			if err != nil {
-				onErr(i, err, "failed to open")
-				continue
-			}
-
-			s, err := f.Stat()
-			if err != nil {
-				onErr(i, err, "failed to stat")
-				continue
-			}
-
-			files[i] = file{
-				File: f,
-				name: s.Name(),
-				size: s.Size(),
-			}
+		name := file.Name
+		if name == "" {
+			name = s.Name()
 		}
 
-		glib.IdleAdd(func() {
-			m.progresses = make([]*ProgressUploader, len(files))
-			for i, file := range files {
-				m.progresses[i] = NewProgressUploader(file.name, file.File, file.size)
-				m.progresses[i].err = file.err
-
-				m.Box.PackEnd(m.progresses[i], false, false, 5)
-			}
-			m.ShowAll()
-		})
-	}()
+		p := NewProgressUploader(name, f, s.Size())
+		m.progresses = append(m.progresses, p)
+		m.Box.PackEnd(p, false, false, 5)
+	}
 
+	m.ShowAll()
 	return &m, nil
 }
 
-func (m *MessageUploader) MakeSendData(message *discord.Message) api.SendMessageData {
-	s := api.SendMessageData{
-		Content: message.Content,
-		Nonce:   message.Nonce,
-		Files:   make([]sendpart.File, 0, len(m.progresses)),
+func (m *MessageUploader) MakeSendData(message *discord.Message) SendData {
+	s := SendData{
+		SendMessageData: api.SendMessageData{
+			Content: message.Content,
+			Nonce:   message.Nonce,
+			Files:   make([]sendpart.File, 0, len(m.progresses)),
+		},
+		Attachments: make([]AttachmentData, 0, len(m.progresses)),
 	}
 
-	for _, p := range m.progresses {
+	for i, p := range m.progresses {
 		s.Files = append(s.Files, sendpart.File{
 			Name:   p.Name,
 			Reader: p,
 		})
+		s.Attachments = append(s.Attachments, AttachmentData{
+			ID:          i,
+			Filename:    p.Name,
+			Description: m.files[i].Description,
+		})
 	}
 
 	return s
 }
 
+// Close closes the files and removes the temporary ones. It should be called
+// once the upload is done, whether it failed or not.
 func (m *MessageUploader) Close() {
 	for _, p := range m.progresses {
 		p.Close()
 	}
+
+	for _, file := range m.files {
+		if file.Temporary {
+			os.Remove(file.Path)
+		}
+	}
 }
 
 type ProgressUploader struct {
@@ -174,8 +230,7 @@ func NewProgressUploader(Name string, r io.ReadCloser, s int64) *ProgressUploade
 		n := atomic.LoadInt64(&p.n)
 		bar.SetFraction(float64(n) / total)
 
-		if n < s {
-			glib.SourceRemove(p.handle)
+		if n >= s {
 			p.handle = 0
 			return false
 		}
@@ -0,0 +1,567 @@
+package extras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/cairo"
+	"github.com/diamondburned/gotk4/pkg/core/gioutil"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gdkpixbuf/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/downloads"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	minZoom  = 0.05
+	maxZoom  = 20
+	zoomStep = 1.2
+	// minFrameDelay caps GIFs that ask for 0ms frames, like browsers do.
+	minFrameDelay = 20
+)
+
+// Media is an image that can be shown in the viewer.
+type Media struct {
+	Name  string
+	URL   string
+	Proxy string
+}
+
+func newMedia(url, proxy string) Media {
+	if proxy == "" {
+		proxy = url
+	}
+
+	return Media{
+		Name:  path.Base(strings.Split(url, "?")[0]),
+		URL:   url,
+		Proxy: strings.Split(proxy, "?")[0],
+	}
+}
+
+// Gallery, if not nil, returns the images in the current channel in order. The
+// viewer uses it to go to the previous or next image.
+var Gallery func() []Media
+
+// MediaOf returns the images in the message in the order they're shown.
+func MediaOf(msg *discord.Message) []Media {
+	var media []Media
+
+	for _, embed := range msg.Embeds {
+		switch embed.Type {
+		case discord.ImageEmbed:
+			if embed.Thumbnail != nil {
+				media = append(media, newMedia(embed.Thumbnail.URL, embed.Thumbnail.Proxy))
+			}
+		case discord.NormalEmbed, discord.LinkEmbed, discord.ArticleEmbed, discord.VideoEmbed:
+			// Playable videos are shown in a player instead.
+			if embed.Video != nil && mediaKind(embed.Video.URL) == "video" {
+				continue
+			}
+			// Video embeds without an image show their thumbnail as one, which
+			// has the same URL either way.
+			if embed.Thumbnail != nil {
+				media = append(media, newMedia(embed.Thumbnail.URL, embed.Thumbnail.Proxy))
+			}
+			if embed.Image != nil {
+				media = append(media, newMedia(embed.Image.URL, embed.Image.Proxy))
+			}
+		}
+	}
+
+	for _, att := range msg.Attachments {
+		if att.Width > 0 && att.Height > 0 && validExt(att.Proxy, attachmentFormats) {
+			media = append(media, newMedia(att.URL, att.Proxy))
+		}
+	}
+
+	return media
+}
+
+// Viewer is a window that shows images with zooming, panning and rotation.
+type Viewer struct {
+	*gtk.Window
+	Header  *gtk.HeaderBar
+	Area    *gtk.DrawingArea
+	Spinner *gtk.Spinner
+
+	Prev *gtk.Button
+	Next *gtk.Button
+	Fit  *gtk.ToggleButton
+
+	// Gestures must be kept alive for as long as the widget is.
+	zoomGesture *gtk.GestureZoom
+	dragGesture *gtk.GestureDrag
+
+	gallery []Media
+	index   int
+	cancel  context.CancelFunc
+
+	anim   *gdkpixbuf.PixbufAnimation
+	iter   *gdkpixbuf.PixbufAnimationIter
+	frame  *gdkpixbuf.Pixbuf
+	ticker glib.SourceHandle
+
+	fitted   bool
+	zoom     float64
+	rotation int // clockwise quarter turns
+	offsetX  float64
+	offsetY  float64
+
+	pinchZoom float64
+	dragX     float64
+	dragY     float64
+}
+
+// SpawnViewer opens the viewer on the image with the given URL. Other images
+// in the channel are reachable with the arrow keys if Gallery is set.
+func SpawnViewer(proxy, url string) {
+	var gallery []Media
+	if Gallery != nil {
+		gallery = Gallery()
+	}
+
+	index := -1
+	for i, media := range gallery {
+		if media.URL == url {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		gallery = []Media{newMedia(url, proxy)}
+		index = 0
+	}
+
+	v := NewViewer(gallery)
+	v.Show()
+	v.SetIndex(index)
+}
+
+func NewViewer(gallery []Media) *Viewer {
+	v := &Viewer{
+		gallery: gallery,
+		fitted:  true,
+		zoom:    1,
+	}
+
+	w := window.Window.AllocatedWidth()
+	h := window.Window.AllocatedHeight()
+
+	v.Window = gtk.NewWindow(gtk.WindowToplevel)
+	v.Window.SetTransientFor(&window.Window.Window)
+	v.Window.SetDestroyWithParent(true)
+	v.Window.SetDefaultSize(w*85/100, h*80/100)
+	v.Window.Connect("destroy", v.destroy)
+	v.Window.ConnectKeyPressEvent(v.onKeyPress)
+
+	v.Prev = newViewerButton("go-previous-symbolic", "Previous", func() { v.SetIndex(v.index - 1) })
+	v.Next = newViewerButton("go-next-symbolic", "Next", func() { v.SetIndex(v.index + 1) })
+
+	v.Fit = gtk.NewToggleButton()
+	v.Fit.SetImage(gtk.NewImageFromIconName("zoom-fit-best-symbolic", int(gtk.IconSizeButton)))
+	v.Fit.SetTooltipText("Fit to Window")
+	v.Fit.SetActive(true)
+	v.Fit.Connect("toggled", func() {
+		if v.Fit.Active() != v.fitted {
+			v.SetFit(v.Fit.Active())
+		}
+	})
+
+	nav := gtk.NewBox(gtk.OrientationHorizontal, 0)
+	nav.StyleContext().AddClass("linked")
+	nav.Add(v.Prev)
+	nav.Add(v.Next)
+
+	v.Header = gtk.NewHeaderBar()
+	v.Header.SetShowCloseButton(true)
+	v.Header.PackStart(nav)
+	v.Header.PackEnd(newViewerButton("image-x-generic-symbolic", "Open Original", func() {
+		gtkutils.OpenURI(v.current().URL)
+	}))
+	v.Header.PackEnd(newViewerButton("document-save-as-symbolic", "Save As", v.Save))
+	v.Header.PackEnd(newViewerButton("edit-copy-symbolic", "Copy Image", v.Copy))
+	v.Header.PackEnd(v.Fit)
+	v.Header.PackEnd(newViewerButton("object-rotate-right-symbolic", "Rotate Right", func() { v.Rotate(1) }))
+	v.Header.PackEnd(newViewerButton("object-rotate-left-symbolic", "Rotate Left", func() { v.Rotate(-1) }))
+	v.Window.SetTitlebar(v.Header)
+
+	v.Area = gtk.NewDrawingArea()
+	v.Area.SetHExpand(true)
+	v.Area.SetVExpand(true)
+	v.Area.AddEvents(int(gdk.ScrollMask | gdk.SmoothScrollMask | gdk.ButtonPressMask | gdk.ButtonReleaseMask))
+	v.Area.ConnectDraw(v.draw)
+	v.Area.ConnectScrollEvent(v.onScroll)
+
+	v.zoomGesture = gtk.NewGestureZoom(v.Area)
+	v.zoomGesture.ConnectBegin(func(*gdk.EventSequence) { v.pinchZoom = v.scale() })
+	v.zoomGesture.ConnectScaleChanged(func(scale float64) {
+		x, y := v.center()
+		v.zoomAt(v.pinchZoom*scale/v.scale(), x, y)
+	})
+
+	v.dragGesture = gtk.NewGestureDrag(v.Area)
+	v.dragGesture.ConnectDragBegin(func(x, y float64) {
+		v.dragX = v.offsetX
+		v.dragY = v.offsetY
+	})
+	v.dragGesture.ConnectDragUpdate(func(x, y float64) {
+		// There's nothing to pan if the whole image is visible.
+		if v.fitted {
+			return
+		}
+		v.offsetX = v.dragX + x
+		v.offsetY = v.dragY + y
+		v.Area.QueueDraw()
+	})
+
+	v.Spinner = gtk.NewSpinner()
+	v.Spinner.SetSizeRequest(32, 32)
+	v.Spinner.SetHAlign(gtk.AlignCenter)
+	v.Spinner.SetVAlign(gtk.AlignCenter)
+	v.Spinner.SetNoShowAll(true)
+
+	overlay := gtk.NewOverlay()
+	overlay.Add(v.Area)
+	overlay.AddOverlay(v.Spinner)
+	v.Window.Add(overlay)
+
+	overlay.ShowAll()
+	v.Header.ShowAll()
+
+	return v
+}
+
+func newViewerButton(icon, tooltip string, clicked func()) *gtk.Button {
+	b := gtk.NewButtonFromIconName(icon, int(gtk.IconSizeButton))
+	b.SetTooltipText(tooltip)
+	b.Connect("clicked", clicked)
+	return b
+}
+
+func (v *Viewer) current() Media {
+	return v.gallery[v.index]
+}
+
+// SetIndex shows the image at the given index in the gallery.
+func (v *Viewer) SetIndex(index int) {
+	if index < 0 || index >= len(v.gallery) {
+		return
+	}
+
+	v.index = index
+	v.rotation = 0
+	v.SetFit(true)
+
+	media := v.current()
+
+	v.Header.SetTitle(media.Name)
+	if len(v.gallery) > 1 {
+		v.Header.SetSubtitle(fmt.Sprintf("%d of %d", index+1, len(v.gallery)))
+	} else {
+		v.Header.SetSubtitle("")
+	}
+
+	v.Prev.SetVisible(len(v.gallery) > 1)
+	v.Next.SetVisible(len(v.gallery) > 1)
+	v.Prev.SetSensitive(index > 0)
+	v.Next.SetSensitive(index < len(v.gallery)-1)
+
+	v.load(media)
+}
+
+// SetFit fits the image into the window if true, or shows it at its original
+// size otherwise.
+func (v *Viewer) SetFit(fit bool) {
+	v.fitted = fit
+	v.zoom = 1
+	v.offsetX = 0
+	v.offsetY = 0
+
+	v.Fit.SetActive(fit)
+	v.Area.QueueDraw()
+}
+
+// Rotate rotates the image by the given number of clockwise quarter turns.
+func (v *Viewer) Rotate(turns int) {
+	v.rotation = ((v.rotation+turns)%4 + 4) % 4
+	v.offsetX = 0
+	v.offsetY = 0
+	v.Area.QueueDraw()
+}
+
+// Copy copies the current frame into the clipboard.
+func (v *Viewer) Copy() {
+	if v.frame == nil || window.Window.Clipboard == nil {
+		return
+	}
+	window.Window.Clipboard.SetImage(v.frame)
+}
+
+// Save downloads the original image.
+func (v *Viewer) Save() {
+	media := v.current()
+	downloads.Save(media.Name, media.URL)
+}
+
+func (v *Viewer) load(media Media) {
+	if v.cancel != nil {
+		v.cancel()
+	}
+
+	v.stopAnimation()
+	v.anim = nil
+	v.iter = nil
+	v.frame = nil
+	v.Area.QueueDraw()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v.cancel = cancel
+
+	v.Spinner.Show()
+	v.Spinner.Start()
+
+	go func() {
+		anim, err := fetchAnimation(ctx, media.Proxy)
+
+		glib.IdleAdd(func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			v.Spinner.Stop()
+			v.Spinner.Hide()
+
+			if err != nil {
+				log.Errorln("failed to load image:", err)
+				v.Header.SetSubtitle("Error: " + err.Error())
+				return
+			}
+
+			v.setAnimation(anim)
+		})
+	}()
+}
+
+func fetchAnimation(ctx context.Context, url string) (*gdkpixbuf.PixbufAnimation, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+
+	r, err := cache.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to GET")
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return nil, errors.Errorf("bad status code %d", r.StatusCode)
+	}
+
+	loader := gdkpixbuf.NewPixbufLoader()
+
+	if _, err := io.Copy(gioutil.PixbufLoaderWriter(loader), r.Body); err != nil {
+		loader.Close()
+		return nil, errors.Wrap(err, "failed to download")
+	}
+
+	if err := loader.Close(); err != nil {
+		return nil, errors.Wrap(err, "pixbuf load error")
+	}
+
+	return loader.Animation(), nil
+}
+
+func (v *Viewer) setAnimation(anim *gdkpixbuf.PixbufAnimation) {
+	v.anim = anim
+
+	if anim.IsStaticImage() {
+		v.frame = anim.StaticImage()
+	} else {
+		v.iter = anim.Iter(nil)
+		v.frame = v.iter.Pixbuf()
+		v.nextFrame()
+	}
+
+	v.Area.QueueDraw()
+}
+
+// nextFrame schedules the next frame of the animation.
+func (v *Viewer) nextFrame() {
+	delay := v.iter.DelayTime()
+	if delay < 0 {
+		// The frame is shown forever.
+		return
+	}
+	if delay < minFrameDelay {
+		delay = minFrameDelay
+	}
+
+	v.ticker = glib.TimeoutAdd(uint(delay), func() bool {
+		v.ticker = 0
+
+		v.iter.Advance(nil)
+		v.frame = v.iter.Pixbuf()
+		v.Area.QueueDraw()
+
+		v.nextFrame()
+		return false
+	})
+}
+
+func (v *Viewer) stopAnimation() {
+	if v.ticker != 0 {
+		glib.SourceRemove(v.ticker)
+		v.ticker = 0
+	}
+}
+
+func (v *Viewer) destroy() {
+	if v.cancel != nil {
+		v.cancel()
+	}
+	v.stopAnimation()
+}
+
+// imageSize returns the size of the image after rotation.
+func (v *Viewer) imageSize() (w, h float64) {
+	if v.anim == nil {
+		return 0, 0
+	}
+
+	w, h = float64(v.anim.Width()), float64(v.anim.Height())
+	if v.rotation%2 == 1 {
+		w, h = h, w
+	}
+	return w, h
+}
+
+func (v *Viewer) center() (x, y float64) {
+	return float64(v.Area.AllocatedWidth()) / 2, float64(v.Area.AllocatedHeight()) / 2
+}
+
+// scale returns the current zoom level. Images that fit are never enlarged.
+func (v *Viewer) scale() float64 {
+	if !v.fitted {
+		return v.zoom
+	}
+
+	w, h := v.imageSize()
+	if w == 0 || h == 0 {
+		return 1
+	}
+
+	aw, ah := v.center()
+	return math.Min(1, math.Min(aw*2/w, ah*2/h))
+}
+
+// zoomAt multiplies the zoom level by factor while keeping the point at x, y
+// in place.
+func (v *Viewer) zoomAt(factor, x, y float64) {
+	old := v.scale()
+	zoom := math.Max(minZoom, math.Min(maxZoom, old*factor))
+
+	cx, cy := v.center()
+	x -= cx
+	y -= cy
+
+	v.offsetX = x - (x-v.offsetX)*zoom/old
+	v.offsetY = y - (y-v.offsetY)*zoom/old
+	v.zoom = zoom
+
+	v.fitted = false
+	v.Fit.SetActive(false)
+	v.Area.QueueDraw()
+}
+
+func (v *Viewer) draw(cr *cairo.Context) bool {
+	if v.frame == nil {
+		return false
+	}
+
+	cx, cy := v.center()
+	scale := v.scale()
+
+	cr.Translate(cx+v.offsetX, cy+v.offsetY)
+	cr.Scale(scale, scale)
+	cr.Rotate(float64(v.rotation) * math.Pi / 2)
+
+	w, h := float64(v.frame.Width()), float64(v.frame.Height())
+	gdk.CairoSetSourcePixbuf(cr, v.frame, -w/2, -h/2)
+	cr.Paint()
+
+	return true
+}
+
+func (v *Viewer) onScroll(ev *gdk.EventScroll) bool {
+	var factor float64
+
+	switch ev.Direction() {
+	case gdk.ScrollUp:
+		factor = zoomStep
+	case gdk.ScrollDown:
+		factor = 1 / zoomStep
+	case gdk.ScrollSmooth:
+		factor = math.Pow(zoomStep, -ev.DeltaY())
+	default:
+		return false
+	}
+
+	v.zoomAt(factor, ev.X(), ev.Y())
+	return true
+}
+
+func (v *Viewer) onKeyPress(ev *gdk.EventKey) bool {
+	ctrl := ev.State()&gdk.ControlMask != 0
+
+	switch ev.Keyval() {
+	case gdk.KEY_Escape:
+		v.Window.Destroy()
+	case gdk.KEY_Left:
+		v.SetIndex(v.index - 1)
+	case gdk.KEY_Right:
+		v.SetIndex(v.index + 1)
+	case gdk.KEY_plus, gdk.KEY_equal, gdk.KEY_KP_Add:
+		x, y := v.center()
+		v.zoomAt(zoomStep, x, y)
+	case gdk.KEY_minus, gdk.KEY_KP_Subtract:
+		x, y := v.center()
+		v.zoomAt(1/zoomStep, x, y)
+	case gdk.KEY_0, gdk.KEY_1:
+		v.SetFit(false)
+	case gdk.KEY_f:
+		v.SetFit(true)
+	case gdk.KEY_r:
+		v.Rotate(1)
+	case gdk.KEY_R:
+		v.Rotate(-1)
+	case gdk.KEY_c:
+		if !ctrl {
+			return false
+		}
+		v.Copy()
+	case gdk.KEY_s:
+		if !ctrl {
+			return false
+		}
+		v.Save()
+	default:
+		return false
+	}
+
+	return true
+}
@@ -1,13 +1,15 @@
 package message
 
 import (
+	"fmt"
 	"math/rand"
-	"path/filepath"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/diamondburned/arikawa/v2/api"
 	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/sendpart"
 	"github.com/diamondburned/gotk4-handy/pkg/handy"
 	"github.com/diamondburned/gotk4/pkg/gdk/v3"
 	"github.com/diamondburned/gotk4/pkg/gdkpixbuf/v2"
@@ -22,6 +24,7 @@ import (
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
 	"github.com/diamondburned/gtkcord3/gtkcord/variables"
 	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/gtkcord3/internal/split"
 	"github.com/diamondburned/gtkcord3/internal/zwsp"
 	"github.com/diamondburned/ningen/v2"
 	"github.com/pkg/errors"
@@ -36,6 +39,9 @@ type Input struct {
 
 	Completer *completer.State
 
+	// Tray holds the files that are sent with the next message.
+	Tray *extras.Tray
+
 	InputBox *gtk.Box
 	Input    *gtk.TextView
 	InputBuf *gtk.TextBuffer
@@ -52,6 +58,12 @@ type Input struct {
 	EditCancel   *gtk.Button
 
 	Editing *discord.Message
+
+	// offline is true when the gateway is disconnected. Messages are not sent
+	// while offline.
+	offline bool
+	// readOnly is true when the user can't send messages in the channel.
+	readOnly bool
 }
 
 func NewInput(m *Messages) (i *Input) {
@@ -103,6 +115,9 @@ func NewInput(m *Messages) (i *Input) {
 	// Add the completer into the box:
 	i.Completer = completer.New(m.c, i.InputBuf, m)
 
+	i.Tray = extras.NewTray()
+	i.AcceptDrops(gtk.BaseWidget(i.Input))
+
 	i.InputBox = gtk.NewBox(gtk.OrientationHorizontal, 0)
 	i.InputBox.SetHExpand(true)
 	i.InputBox.SetMarginBottom(0) // doing it legit by using label as padding
@@ -112,9 +127,7 @@ func NewInput(m *Messages) (i *Input) {
 	i.Upload.SetVAlign(gtk.AlignBaseline)
 	i.Upload.SetRelief(gtk.ReliefNone)
 	i.Upload.Connect("clicked", func() {
-		extras.SpawnUploader(func(paths []string) {
-			i.upload(i.popContent(), paths)
-		})
+		extras.SpawnUploader(i.Stage)
 	})
 
 	// Emoji popup constructor:
@@ -122,6 +135,7 @@ func NewInput(m *Messages) (i *Input) {
 		i.InputBuf.InsertAtCursor(emoji)
 	})
 	espawner.Refocus = i.Input // refocus on close
+	espawner.Sticker = i.sendSticker
 
 	i.Emoji = gtk.NewButtonFromIconName("face-smile-symbolic", int(variables.InputIconSize))
 	i.Emoji.SetVAlign(gtk.AlignBaseline)
@@ -171,6 +185,7 @@ func NewInput(m *Messages) (i *Input) {
 
 	// Add into the main box:
 	i.Main.Add(i.Completer)
+	i.Main.Add(i.Tray)
 	i.Main.Add(i.InputBox)
 	i.Main.Add(i.Bottom)
 
@@ -228,7 +243,7 @@ func (i *Input) keyDown(ev *gdk.Event) bool {
 		vkey  = key == gdk.KEY_v
 	)
 
-	// If Ctrl-V is pressed:
+	// If Ctrl-V is pressed, and we can actually upload:
 	if cntrl && vkey && window.Window.Clipboard != nil {
 		clipboard := window.Window.Clipboard
 
@@ -239,15 +254,13 @@ func (i *Input) keyDown(ev *gdk.Event) bool {
 		}
 
 		// Yes.
-		text := i.popContent()
-
 		clipboard.RequestImage(func(_ *gtk.Clipboard, pixbuf *gdkpixbuf.Pixbuf) {
 			if pixbuf == nil {
 				log.Errorln("failed to get image from clipboard")
 				return
 			}
 
-			i.paste(text, pixbuf)
+			i.paste(pixbuf)
 		})
 
 		return true
@@ -317,6 +330,11 @@ func (i *Input) keyDown(ev *gdk.Event) bool {
 		return true
 	}
 
+	// Keep the content around if we can't send it yet.
+	if i.offline || i.readOnly {
+		return true
+	}
+
 	i.deleteContent()
 
 	// Shift is not being held, send the message:
@@ -324,6 +342,37 @@ func (i *Input) keyDown(ev *gdk.Event) bool {
 	return true
 }
 
+// SetConnected enables or disables the send path. The input box stays editable
+// while disconnected so that drafts aren't lost.
+func (i *Input) SetConnected(connected bool) {
+	i.offline = !connected
+	i.updateSendable()
+}
+
+// SetCanSend sets whether or not the user can send messages in the channel,
+// which they might not in some threads.
+func (i *Input) SetCanSend(canSend bool) {
+	i.readOnly = !canSend
+	i.Input.SetEditable(canSend)
+	i.updateSendable()
+}
+
+func (i *Input) updateSendable() {
+	sendable := !i.offline && !i.readOnly
+
+	i.Send.SetSensitive(sendable)
+	i.Upload.SetSensitive(sendable)
+
+	switch {
+	case i.readOnly:
+		i.Send.SetTooltipText("You can't send messages here")
+	case i.offline:
+		i.Send.SetTooltipText("Waiting for connection...")
+	default:
+		i.Send.SetTooltipText("")
+	}
+}
+
 func (i *Input) stopEditing() {
 	// Clear the text box:
 	i.InputBuf.SetText("")
@@ -384,10 +433,16 @@ func (i *Input) popContent() string {
 	return text
 }
 
-func (i *Input) makeMessage(content string) *discord.Message {
+// transform returns the content as it will be sent.
+func (i *Input) transform(content string) string {
 	if i.Messages.InputZeroWidth {
-		content = zwsp.Insert(content)
+		return zwsp.Insert(content)
 	}
+	return content
+}
+
+func (i *Input) makeMessage(content string) *discord.Message {
+	content = i.transform(content)
 
 	me, _ := i.Messages.c.Me()
 
@@ -402,21 +457,87 @@ func (i *Input) makeMessage(content string) *discord.Message {
 	}
 }
 
-func (i *Input) paste(content string, pic *gdkpixbuf.Pixbuf) {
+// Stage adds the files to the attachment tray.
+func (i *Input) Stage(paths []string) {
+	i.Tray.SetLimit(i.uploadLimit())
+	i.Tray.Add(paths...)
+}
+
+// AcceptDrops makes files dropped onto the widget staged in the tray.
+func (i *Input) AcceptDrops(w *gtk.Widget) {
+	if w.DragDestGetTargetList() == nil {
+		w.DragDestSet(gtk.DestDefaultAll, nil, gdk.ActionCopy)
+	}
+	w.DragDestAddURITargets()
+
+	w.ConnectDragDataReceived(func(_ gdk.DragContext, _, _ int, data *gtk.SelectionData, _, _ uint) {
+		var paths []string
+		for _, uri := range data.URIs() {
+			_, path, err := glib.FilenameFromURI(uri)
+			if err != nil {
+				log.Errorln("can't upload dropped URI:", err)
+				continue
+			}
+			paths = append(paths, path)
+		}
+
+		i.Stage(paths)
+	})
+}
+
+// paste stages the clipboard image. Each paste is saved to its own file, since
+// earlier ones may still be staged.
+func (i *Input) paste(pic *gdkpixbuf.Pixbuf) {
 	go func() {
-		path := filepath.Join(cache.TmpPath(), "clipboard.png")
+		f, err := os.CreateTemp(cache.TmpPath(), "clipboard-*.png")
+		if err != nil {
+			log.Errorln("failed to create clipboard file:", err)
+			return
+		}
+		f.Close()
 
-		if err := pic.Savev(path, "png", nil, nil); err != nil {
+		if err := pic.Savev(f.Name(), "png", nil, nil); err != nil {
 			log.Errorln("failed to save clipboard PNG:", err)
 			return
 		}
 
 		glib.IdleAdd(func() {
-			i.upload(content, []string{path})
+			i.Tray.SetLimit(i.uploadLimit())
+			i.Tray.AddNamed(f.Name(), "clipboard.png")
 		})
 	}()
 }
 
+// uploadLimit returns the total size of files that can be sent in the current
+// channel, which depends on the user's Nitro and the guild's boost level.
+func (i *Input) uploadLimit() int64 {
+	const mb = 1024 * 1024
+	limit := int64(extras.DefaultUploadLimit)
+
+	if me, err := i.Messages.c.Me(); err == nil {
+		switch me.Nitro {
+		case discord.NitroClassic:
+			limit = 50 * mb
+		case discord.NitroFull:
+			limit = 100 * mb
+		}
+	}
+
+	if guildID := i.Messages.GuildID(); guildID.IsValid() {
+		g, err := i.Messages.c.State.Cabinet.Guild(guildID)
+		if err == nil {
+			switch {
+			case g.NitroBoost >= discord.NitroLevel3 && limit < 100*mb:
+				limit = 100 * mb
+			case g.NitroBoost >= discord.NitroLevel2 && limit < 50*mb:
+				limit = 50 * mb
+			}
+		}
+	}
+
+	return limit
+}
+
 func (i *Input) send(content string) {
 	if i.Editing != nil {
 		edit := i.Editing
@@ -448,65 +569,201 @@ func (i *Input) send(content string) {
 		return
 	}
 
-	// If the content is empty but we're not editing, don't send.
-	if content == "" {
+	// If there's nothing to send and we're not editing, don't send.
+	if content == "" && i.Tray.Len() == 0 {
 		return
 	}
 
-	// An invalid ID keeps the message invalid until it is sent.
-	m := i.makeMessage(content)
-	w := i.Messages.Upsert(m)
+	// The tray shows why the files can't be sent, so keep the message around
+	// until they're sorted out.
+	if i.Tray.Problem() != "" {
+		i.InputBuf.SetText(content)
+		return
+	}
 
-	go func() {
-		_, err := i.Messages.c.State.SendMessageComplex(m.ChannelID, api.SendMessageData{
-			Content: m.Content,
-			Nonce:   m.Nonce,
-		})
-		if err == nil {
+	original := content
+
+	if hook := i.Messages.Hooks.PreSend; hook != nil && content != "" {
+		var ok bool
+		if content, ok = hook(i.Messages.ChannelID(), content); !ok {
 			return
 		}
-		log.Errorln("failed to send message:", err)
-		glib.IdleAdd(func() {
-			w.ShowError(errors.Wrap(err, "failed to send message"))
-		})
+	}
+
+	emojis.RecordContent(content)
+
+	parts := []string{content}
+	if split.Length(i.transform(content)) > split.Limit {
+		var ok bool
+		if parts, ok = i.splitLong(content); !ok {
+			i.InputBuf.SetText(original)
+			return
+		}
+		if parts == nil {
+			return
+		}
+	}
+
+	if i.Tray.Len() == 0 {
+		i.sendParts(parts, nil)
+		return
+	}
+
+	// Files go with the last message, which is sent after the rest.
+	last := parts[len(parts)-1]
+	files := i.Tray.Take()
+
+	i.sendParts(parts[:len(parts)-1], func() {
+		i.upload(last, files)
+	})
+}
+
+// splitLong asks whether to split the content into several messages or to
+// upload it as a file. It returns the messages to send, or nil if the content
+// was staged as a file. ok is false if the user cancelled.
+func (i *Input) splitLong(content string) (parts []string, ok bool) {
+	parts = split.Message(content, split.Limit, i.transform)
+
+	choice := window.Choose(
+		nil, "Message Too Long",
+		fmt.Sprintf(
+			"The message is %d characters long, but only %d can be sent at once.",
+			split.Length(i.transform(content)), split.Limit,
+		),
+		fmt.Sprintf("Split into %d Messages", len(parts)),
+		"Upload as File",
+	)
+
+	switch choice {
+	case 0:
+		return parts, true
+	case 1:
+		i.stageText(content)
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// stageText stages the content as message.txt, which replaces the message.
+func (i *Input) stageText(content string) {
+	f, err := os.CreateTemp(cache.TmpPath(), "message-*.txt")
+	if err != nil {
+		log.Errorln("failed to create message file:", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		log.Errorln("failed to write message file:", err)
+		return
+	}
+
+	i.Tray.SetLimit(i.uploadLimit())
+	i.Tray.AddNamed(f.Name(), "message.txt")
+	i.Input.GrabFocus()
+}
+
+// sendParts sends the messages one by one, so that they arrive in order, then
+// calls done in the main thread. Empty messages are skipped. done may be nil.
+func (i *Input) sendParts(parts []string, done func()) {
+	type part struct {
+		m *discord.Message
+		w *Message
+	}
+
+	pending := make([]part, 0, len(parts))
+	for _, content := range parts {
+		if content == "" {
+			continue
+		}
+
+		// An invalid ID keeps the message invalid until it is sent.
+		m := i.makeMessage(content)
+		pending = append(pending, part{m, i.Messages.Upsert(m)})
+	}
+
+	go func() {
+		for _, p := range pending {
+			_, err := i.Messages.c.State.SendMessageComplex(p.m.ChannelID, api.SendMessageData{
+				Content: p.m.Content,
+				Nonce:   p.m.Nonce,
+			})
+			if err == nil {
+				continue
+			}
+
+			log.Errorln("failed to send message:", err)
+
+			w := p.w
+			glib.IdleAdd(func() {
+				w.ShowError(errors.Wrap(err, "failed to send message"))
+			})
+		}
+
+		if done != nil {
+			glib.IdleAdd(done)
+		}
 	}()
 }
 
-func (i *Input) upload(content string, paths []string) {
+func (i *Input) upload(content string, files []extras.UploadFile) {
 	m := i.makeMessage(content)
 
 	w := NewMessageCustom(m)
 	w.UpdateAuthor(i.Messages.c, m.GuildID, m.Author)
 	i.Messages.Insert(w)
 
+	u, err := extras.NewMessageUploader(files)
+	if err != nil {
+		log.Errorln("failed to upload:", err)
+		w.ShowError(errors.Wrap(err, "failed to upload"))
+		return
+	}
+
+	w.rightBottom.Add(u)
+
 	go func() {
-		if err := upload(i.Messages.c, w, m, paths); err != nil {
+		defer u.Close()
+
+		if err := upload(i.Messages.c, m, u.MakeSendData(m)); err != nil {
 			log.Errorln("failed to upload:", err)
 			glib.IdleAdd(func() {
 				w.ShowError(errors.Wrap(err, "failed to upload"))
 			})
+			return
 		}
+
+		glib.IdleAdd(func() { w.rightBottom.Remove(u) })
 	}()
 }
 
-func upload(n *ningen.State, w *Message, m *discord.Message, paths []string) error {
-	u, err := extras.NewMessageUploader(paths)
-	if err != nil {
-		return err
-	}
-	defer u.Close()
+// sendSticker sends the sticker as a message of its own.
+func (i *Input) sendSticker(sticker emojis.GuildSticker) {
+	m := i.makeMessage("")
+	m.Stickers = []discord.Sticker{sticker.Sticker()}
 
-	s := u.MakeSendData(m)
+	w := i.Messages.Upsert(m)
 
-	glib.IdleAdd(func() { w.rightBottom.Add(u) })
+	go func() {
+		data := extras.SendData{
+			SendMessageData: api.SendMessageData{Nonce: m.Nonce},
+			StickerIDs:      []discord.StickerID{sticker.ID},
+		}
 
-	_, err = n.SendMessageComplex(m.ChannelID, s)
-	if err != nil {
-		return err
-	}
+		if err := upload(i.Messages.c, m, data); err != nil {
+			log.Errorln("failed to send sticker:", err)
+			glib.IdleAdd(func() {
+				w.ShowError(errors.Wrap(err, "failed to send sticker"))
+			})
+		}
+	}()
+}
 
-	glib.IdleAdd(func() { w.rightBottom.Remove(u) })
-	return nil
+func upload(n *ningen.State, m *discord.Message, s extras.SendData) error {
+	var msg *discord.Message
+	url := api.EndpointChannels + m.ChannelID.String() + "/messages"
+	return sendpart.POST(n.Client.Client, s, &msg, url)
 }
 
 func randString() string {
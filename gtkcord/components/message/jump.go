@@ -0,0 +1,83 @@
+package message
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+)
+
+// jumpFetches is how many times older messages are fetched to find the message
+// to jump to before giving up.
+const jumpFetches = 5
+
+// highlightDuration is how long the message that's jumped to stays highlighted
+// in milliseconds.
+const highlightDuration = 2000
+
+type jumpTarget struct {
+	channelID discord.ChannelID
+	messageID discord.MessageID
+}
+
+// JumpTo scrolls to the message and highlights it once the channel is loaded.
+// If the channel is already shown, it scrolls right away. It should be called
+// before the channel is loaded.
+func (m *Messages) JumpTo(channelID discord.ChannelID, messageID discord.MessageID) {
+	m.jumpTo = jumpTarget{channelID, messageID}
+
+	if m.channelID == channelID && len(m.messages) > 0 {
+		m.jump(0)
+	}
+}
+
+// jump scrolls to the message to jump to, fetching older messages until it's
+// found.
+func (m *Messages) jump(fetches int) {
+	target := m.jumpTo
+	if target.channelID != m.channelID || !target.messageID.IsValid() {
+		return
+	}
+
+	for _, msg := range m.messages {
+		if msg.ID == target.messageID {
+			m.jumpTo = jumpTarget{}
+			m.scrollTo(msg)
+			return
+		}
+	}
+
+	// Give up if the message is newer than the oldest one, since it's been
+	// deleted.
+	if len(m.messages) == 0 || m.messages[0].ID < target.messageID || fetches >= jumpFetches {
+		m.jumpTo = jumpTarget{}
+		return
+	}
+
+	m.fetchMore(func() {
+		if m.jumpTo == target {
+			m.jump(fetches + 1)
+		}
+	})
+}
+
+// scrollTo scrolls so that the message is in the middle, then highlights it
+// for a bit.
+func (m *Messages) scrollTo(msg *Message) {
+	m.bottomed = false
+
+	// Wait for the messages to be allocated, like ScrollToBottom.
+	glib.IdleAdd(func() {
+		_, y, ok := msg.TranslateCoordinates(m.Column, 0, 0)
+		if !ok {
+			return
+		}
+
+		vAdj := m.Scroll.VAdjustment()
+		vAdj.SetValue(float64(y) - (vAdj.PageSize()-float64(msg.AllocatedHeight()))/2)
+
+		msg.style.AddClass("highlighted")
+		glib.TimeoutAdd(highlightDuration, func() bool {
+			msg.style.RemoveClass("highlighted")
+			return false
+		})
+	})
+}
@@ -7,6 +7,36 @@ import (
 	"github.com/diamondburned/gtkcord3/internal/log"
 )
 
+func (m *Messages) menuAddReact(msg *Message, menuContainer gtk.Containerer) {
+	// Messages that aren't sent yet can't be reacted to.
+	if !msg.ID.IsValid() {
+		return
+	}
+
+	menu := gtk.BaseContainer(menuContainer)
+
+	if guildID := m.GuildID(); guildID.IsValid() {
+		me, _ := m.c.Me()
+
+		p, err := m.c.Permissions(m.ChannelID(), me.ID)
+		if err != nil {
+			log.Errorln("failed to get permissions:", err)
+			return
+		}
+
+		if !p.Has(discord.PermissionAddReactions) {
+			return
+		}
+	}
+
+	iReact := gtk.NewMenuItemWithLabel("Add Reaction")
+	iReact.Connect("activate", func() {
+		m.onReactClick(msg, msg.main)
+	})
+	iReact.Show()
+	menu.Add(iReact)
+}
+
 func (m *Messages) menuAddAdmin(msg *Message, menuContainer gtk.Containerer) {
 	menu := gtk.BaseContainer(menuContainer)
 	me, _ := m.c.Me()
@@ -70,4 +100,18 @@ func (m *Messages) menuAddDebug(msg *Message, menuContainer gtk.Containerer) {
 		cpgID.Show()
 		menu.Add(cpgID)
 	}
+
+	if m.Inspect != nil {
+		inspect := gtk.NewMenuItemWithLabel("Inspect Message")
+		inspect.Connect("activate", func() {
+			dm, err := m.c.Cabinet.Message(m.ChannelID(), msg.ID)
+			if err != nil {
+				log.Errorln("failed to get message:", err)
+				return
+			}
+			m.Inspect("Message "+dm.ID.String(), dm)
+		})
+		inspect.Show()
+		menu.Add(inspect)
+	}
 }
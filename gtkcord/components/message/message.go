@@ -15,6 +15,7 @@ import (
 	"github.com/diamondburned/gtkcord3/gtkcord/components/message/reactions"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
 	"github.com/diamondburned/gtkcord3/gtkcord/md"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
 	"github.com/diamondburned/gtkcord3/gtkcord/variables"
 	"github.com/diamondburned/gtkcord3/internal/humanize"
 	"github.com/diamondburned/ningen/v2"
@@ -58,11 +59,23 @@ type Message struct {
 
 	errorLabel *gtk.Label
 
+	// reactButton is shown over the message on hover.
+	reactButton *gtk.Button
+
+	// threadID is the thread that the message started, which has the same ID
+	// as the message unless it's a "thread started" message.
+	threadID    discord.ChannelID
+	threadName  string
+	threadLink  *gtk.Button
+	threadLabel *gtk.Label
+
 	Condensed      bool
 	CondenseOffset time.Duration
 
-	OnUserClick  func(m *Message)
-	OnRightClick func(m *Message, btn *gdk.EventButton)
+	OnUserClick   func(m *Message)
+	OnRightClick  func(m *Message, btn *gdk.EventButton)
+	OnReactClick  func(m *Message, relative gtk.Widgetter)
+	OnThreadClick func(m *Message)
 
 	busy int32
 }
@@ -102,6 +115,14 @@ func NewMessage(s *ningen.State, m *discord.Message) *Message {
 		messageText = "The server is now Nitro Boosted to Tier 2."
 	case discord.NitroTier3Message:
 		messageText = "The server is now Nitro Boosted to Tier 3."
+	case threads.ThreadCreatedMessage:
+		messageText = "Started a thread: " + html.EscapeString(m.Content) + "."
+		message.threadName = m.Content
+		if m.Reference != nil {
+			message.threadID = m.Reference.ChannelID
+		}
+	case threads.ThreadStarterMessage:
+		messageText = "Started the thread from a message."
 	}
 
 	if messageText == "" {
@@ -138,6 +159,8 @@ func NewMessageCustom(message *discord.Message) *Message {
 		textReveal:  gtk.NewRevealer(),
 		textView:    gtk.NewTextView(),
 		errorLabel:  gtk.NewLabel(""),
+		reactButton: gtk.NewButtonFromIconName("face-smile-symbolic", int(gtk.IconSizeButton)),
+		threadID:    discord.ChannelID(message.ID),
 	}
 
 	m.content = m.textView.Buffer()
@@ -154,7 +177,21 @@ func NewMessageCustom(message *discord.Message) *Message {
 		gdk.EnterNotifyMask |
 		gdk.LeaveNotifyMask,
 	))
-	mainEv.Add(m.main)
+	m.reactButton.SetTooltipText("Add Reaction")
+	m.reactButton.SetHAlign(gtk.AlignEnd)
+	m.reactButton.SetVAlign(gtk.AlignStart)
+	m.reactButton.SetMarginEnd(variables.AvatarPadding)
+	m.reactButton.SetNoShowAll(true)
+	m.reactButton.Connect("clicked", func() {
+		m.OnReactClick(&m, m.reactButton)
+	})
+	gtkutils.InjectCSS(m.reactButton, "react-button", "")
+
+	overlay := gtk.NewOverlay()
+	overlay.Add(m.main)
+	overlay.AddOverlay(m.reactButton)
+
+	mainEv.Add(overlay)
 	m.ListBoxRow.Add(mainEv)
 
 	// On message (which is in event box) right click:
@@ -172,8 +209,19 @@ func NewMessageCustom(message *discord.Message) *Message {
 	m.avatar.SetInitials(message.Author.Username)
 
 	// On message hover, play the avatar animation.
-	mainEv.Connect("enter-notify-event", func() { m.avatar.SetPlayAnimation(true) })
-	mainEv.Connect("leave-notify-event", func() { m.avatar.SetPlayAnimation(false) })
+	mainEv.Connect("enter-notify-event", func() {
+		m.avatar.SetPlayAnimation(true)
+		// Messages that aren't sent yet can't be reacted to.
+		m.reactButton.SetVisible(m.ID.IsValid())
+	})
+	mainEv.Connect("leave-notify-event", func(ev *gdk.Event) {
+		// Moving onto the react button leaves towards it.
+		if ev.AsCrossing().Detail() == gdk.NotifyInferior {
+			return
+		}
+		m.avatar.SetPlayAnimation(false)
+		m.reactButton.Hide()
+	})
 
 	gtkutils.InjectCSS(m.avatar, "avatar", "")
 
@@ -276,6 +324,12 @@ func (m *Message) setOffset(last *Message) {
 	m.CondenseOffset = offs
 }
 
+// AddDecoration adds a widget below the message content. It is meant for
+// plugins.
+func (m *Message) AddDecoration(w gtk.Widgetter) {
+	m.rightBottom.Add(w)
+}
+
 // ShowError shows an error on a message.
 func (m *Message) ShowError(err error) {
 	if err == nil {
@@ -407,6 +461,7 @@ func (m *Message) UpdateExtras(s *ningen.State, update *discord.Message) {
 	m.extras = nil
 	m.extras = append(m.extras, extras.NewEmbed(s, update)...)
 	m.extras = append(m.extras, extras.NewAttachment(update)...)
+	m.extras = append(m.extras, extras.NewStickers(update)...)
 
 	for _, extra := range m.extras {
 		m.rightBottom.Add(extra)
@@ -9,7 +9,9 @@ import (
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/loadstatus"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/message/extras"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
 	"github.com/diamondburned/gtkcord3/gtkcord/variables"
 	"github.com/diamondburned/gtkcord3/internal/log"
 	"github.com/diamondburned/ningen/v2"
@@ -20,12 +22,14 @@ const scrollMinDelta = 500
 type Messages struct {
 	*loadstatus.Page
 	Opts
+	Hooks Hooks
 
 	channelID discord.ChannelID
 	guildID   discord.GuildID
 
-	c     *ningen.State
-	fetch int // max messages
+	c       *ningen.State
+	threads *threads.State
+	fetch   int // max messages
 
 	Main   *gtk.Box
 	Column *handy.Clamp
@@ -42,6 +46,8 @@ type Messages struct {
 	Scroll   *gtk.ScrolledWindow
 	Viewport *gtk.Viewport
 	bottomed bool
+
+	jumpTo jumpTarget
 }
 
 type Opts struct {
@@ -53,6 +59,24 @@ type Opts struct {
 	// InputOnTyping sets whether or not gtkcord3 should send typing events to
 	// the Discord server and announce it.
 	InputOnTyping bool // true
+	// Inspect, if not nil, is called with the message when the user picks
+	// "Inspect Message" from the context menu.
+	Inspect func(name string, v interface{})
+	// OpenThread, if not nil, is called with the thread's ID when the user
+	// opens a thread from a message or creates one.
+	OpenThread func(threadID discord.ChannelID)
+}
+
+// Hooks are optional callbacks that extend messages, usually set by plugins.
+// They are all called in the main thread.
+type Hooks struct {
+	// PreSend is called with the content of a new message before it is sent.
+	// It returns the content to send, or false to not send anything.
+	PreSend func(chID discord.ChannelID, content string) (string, bool)
+	// Render is called after a message widget is created.
+	Render func(msg *Message)
+	// Menu is called when a message's context menu is opened.
+	Menu func(msg *Message, menu *gtk.Menu)
 }
 
 var messagesCSS = gtkutils.CSSAdder(`
@@ -61,11 +85,12 @@ var messagesCSS = gtkutils.CSSAdder(`
 	}
 `)
 
-func NewMessages(s *ningen.State, opts Opts) *Messages {
+func NewMessages(s *ningen.State, threadState *threads.State, opts Opts) *Messages {
 	m := Messages{
-		Opts:  opts,
-		c:     s,
-		fetch: s.Cabinet.MaxMessages() / 2,
+		Opts:    opts,
+		c:       s,
+		threads: threadState,
+		fetch:   s.Cabinet.MaxMessages() / 2,
 	}
 
 	m.Main = gtk.NewBox(gtk.OrientationVertical, 0)
@@ -144,6 +169,10 @@ func NewMessages(s *ningen.State, opts Opts) *Messages {
 	// Add what's needed afterwards:
 	m.Main.PackEnd(m.Input, false, false, 0)
 
+	// Files dropped onto the messages are staged like ones dropped onto the
+	// input.
+	m.Input.AcceptDrops(gtk.BaseWidget(m.Scroll))
+
 	// Set the proper scrolls
 	m.Messages.SetFocusHAdjustment(m.Scroll.HAdjustment())
 	m.Messages.SetFocusVAdjustment(m.Scroll.VAdjustment())
@@ -169,6 +198,21 @@ func NewMessages(s *ningen.State, opts Opts) *Messages {
 	// Set maximum widths
 	m.SetWidth(opts.MessageWidth)
 
+	// Let the image viewer page through the channel's images.
+	extras.Gallery = m.gallery
+
+	// Keep the number of messages in the thread links up to date.
+	threadState.OnUpdate(func(guildID discord.GuildID) {
+		glib.IdleAdd(func() {
+			if guildID != m.guildID {
+				return
+			}
+			for _, msg := range m.messages {
+				m.updateThread(msg)
+			}
+		})
+	})
+
 	m.injectHandlers()
 	m.injectPopup()
 	m.ShowAll()
@@ -186,6 +230,12 @@ func (m *Messages) SetWidth(width int) {
 	m.Input.Clamp.SetMaximumSize(width)
 }
 
+// SetConnected sets whether or not the gateway is connected. Sending is
+// disabled while disconnected, but the messages stay readable.
+func (m *Messages) SetConnected(connected bool) {
+	m.Input.SetConnected(connected)
+}
+
 // Focus on the input box
 func (m *Messages) Focus() {
 	m.Input.Input.GrabFocus()
@@ -195,6 +245,21 @@ func (m *Messages) ChannelID() discord.ChannelID {
 	return m.channelID
 }
 
+// gallery returns the images in the current channel, oldest first.
+func (m *Messages) gallery() []extras.Media {
+	messages, err := m.c.Cabinet.Messages(m.channelID)
+	if err != nil {
+		return nil
+	}
+
+	var media []extras.Media
+	for i := len(messages) - 1; i >= 0; i-- {
+		media = append(media, extras.MediaOf(&messages[i])...)
+	}
+
+	return media
+}
+
 func (m *Messages) GuildID() discord.GuildID {
 	return m.guildID
 }
@@ -263,6 +328,15 @@ func (m *Messages) Load(channelID discord.ChannelID) {
 
 		isInGuild := len(messages) > 0 && messages[0].GuildID.IsValid()
 
+		// Fetch the guild's threads so the messages can link to theirs.
+		if isInGuild {
+			if _, err := m.threads.Active(messages[0].GuildID); err != nil {
+				log.Errorln("failed to get active threads:", err)
+			}
+		}
+
+		canSend := m.canSend(channelID)
+
 		// Sort so that latest is last:
 		sort.Slice(messages, func(i, j int) bool {
 			return messages[i].ID < messages[j].ID
@@ -297,9 +371,13 @@ func (m *Messages) Load(channelID discord.ChannelID) {
 				m.guildID = messages[0].GuildID
 			}
 
+			m.Input.SetCanSend(canSend)
+
 			m.bottomed = true
 			m.ScrollToBottom()
 			m.setMainScreen()
+
+			m.jump(0)
 		})
 
 		if isInGuild {
@@ -314,6 +392,7 @@ func (m *Messages) lastMessageFrom(author discord.UserID) *Message {
 
 func (m *Messages) Cleanup() {
 	m.Input.Typing.Stop()
+	m.Input.Tray.Clear()
 
 	for _, msg := range m.messages {
 		msg.Destroy()
@@ -371,8 +450,11 @@ func (m *Messages) onEdgeReached(_ *gtk.ScrolledWindow, pos gtk.PositionType) {
 	}()
 }
 
+// fetchMore prepends older messages. fetched is called in the main thread
+// afterwards, even if nothing was fetched.
 func (m *Messages) fetchMore(fetched func()) {
 	if len(m.messages) < m.fetch {
+		fetched()
 		return
 	}
 
@@ -388,6 +470,7 @@ func (m *Messages) fetchMore(fetched func()) {
 		if err != nil {
 			// TODO: error popup
 			log.Errorln("Failed to fetch past messages:", err)
+			glib.IdleAdd(fetched)
 			return
 		}
 
@@ -400,6 +483,7 @@ func (m *Messages) fetchMore(fetched func()) {
 			// Verify that the new messages still belong to the same channel.
 			if m.channelID != channelID {
 				// Drop all if not.
+				fetched()
 				return
 			}
 
@@ -432,6 +516,8 @@ func (m *Messages) fetchMore(fetched func()) {
 
 			// Prepend into the slice as well:
 			m.messages = append(oldMsgs, m.messages...)
+
+			fetched()
 		})
 	}()
 }
@@ -521,6 +607,10 @@ func (m *Messages) Update(update *discord.Message) (*Message, bool) {
 	}
 
 	target.ID = update.ID
+	target.reactions.MessageID = update.ID
+	if target.threadName == "" {
+		target.threadID = discord.ChannelID(update.ID)
+	}
 
 	// Clear the nonce, if any:
 	if !target.getAvailable() {
@@ -9,6 +9,14 @@ import (
 func injectMessage(m *Messages, w *Message) {
 	w.OnUserClick = m.onAvatarClick
 	w.OnRightClick = m.onRightClick
+	w.OnReactClick = m.onReactClick
+	w.OnThreadClick = m.onThreadClick
+
+	m.updateThread(w)
+
+	if m.Hooks.Render != nil {
+		m.Hooks.Render(w)
+	}
 }
 
 func shouldCondense(msgs []*Message, msg, lastSameAuthor *Message) bool {
@@ -4,6 +4,8 @@ import (
 	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/diamondburned/gotk4/pkg/gdk/v3"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/emojis"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/message/reactions"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/popup"
 	"github.com/diamondburned/gtkcord3/gtkcord/md"
 )
@@ -49,9 +51,24 @@ func (m *Messages) onAvatarClick(msg *Message) {
 func (m *Messages) onRightClick(msg *Message, btn *gdk.EventButton) {
 	menu := gtk.NewMenu()
 
+	m.menuAddReact(msg, menu)
+	m.menuAddThread(msg, menu)
 	m.menuAddAdmin(msg, menu)
 	m.menuAddDebug(msg, menu)
 
+	if m.Hooks.Menu != nil {
+		m.Hooks.Menu(msg, menu)
+	}
+
 	menu.PopupAtPointer(gdk.CopyEventer(btn))
 	menu.GrabFocus()
 }
+
+// onReactClick opens the emoji picker to add a reaction to the message.
+func (m *Messages) onReactClick(msg *Message, relative gtk.Widgetter) {
+	spawner := emojis.New(m.c, func(emoji string) {
+		msg.reactions.React(reactions.ParseEmoji(emoji))
+	})
+
+	spawner.Spawn(relative, m.GuildID()).Popup()
+}
@@ -6,10 +6,12 @@ import (
 	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/diamondburned/arikawa/v2/gateway"
 	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/emojis"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
 	"github.com/diamondburned/gtkcord3/gtkcord/md"
 	"github.com/diamondburned/ningen/v2"
 
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gtkcord3/internal/log"
@@ -61,6 +63,7 @@ func (c *Container) SetState(s *ningen.State) {
 
 func (c *Container) addReaction(reaction discord.Reaction) {
 	r := newReaction(reaction.Emoji, reaction.Count, reaction.Me)
+	r.emojiID = reaction.Emoji.ID
 	c.FlowBox.Add(r)
 	c.Reactions[r.String] = r
 
@@ -70,10 +73,54 @@ func (c *Container) addReaction(reaction discord.Reaction) {
 	r.Button.Connect("toggled", func() {
 		c.clicked(r)
 	})
+	r.Button.Connect("button-press-event", func(ev *gdk.Event) bool {
+		if ev.AsButton().Button() != gdk.BUTTON_SECONDARY || c.state == nil {
+			return false
+		}
+
+		newReactors(c, r).Popup()
+		return true
+	})
+}
+
+// React adds the current user's reaction with the emoji.
+func (c *Container) React(emoji discord.APIEmoji) {
+	if r, ok := c.Reactions[emoji]; ok {
+		// Toggling the button reacts.
+		r.Button.SetActive(true)
+		return
+	}
+
+	if c.state == nil {
+		return
+	}
+
+	emojis.RecordUse(apiEmojiID(emoji))
+
+	go func() {
+		if err := c.state.React(c.ChannelID, c.MessageID, emoji); err != nil {
+			log.Errorln("failed to react:", err)
+		}
+	}()
+}
+
+// canManage returns true if the current user can remove others' reactions.
+func (c *Container) canManage() bool {
+	me, err := c.state.Me()
+	if err != nil {
+		return false
+	}
+
+	p, err := c.state.Permissions(c.ChannelID, me.ID)
+	if err != nil {
+		return false
+	}
+
+	return p.Has(discord.PermissionManageMessages)
 }
 
 func (c *Container) ReactAdd(r *gateway.MessageReactionAddEvent) {
-	if r.MessageID != r.MessageID || r.ChannelID != c.ChannelID {
+	if r.MessageID != c.MessageID || r.ChannelID != c.ChannelID {
 		return
 	}
 	glib.IdleAdd(func() {
@@ -82,7 +129,7 @@ func (c *Container) ReactAdd(r *gateway.MessageReactionAddEvent) {
 }
 
 func (c *Container) ReactRemove(r *gateway.MessageReactionRemoveEvent) {
-	if r.MessageID != r.MessageID || r.ChannelID != c.ChannelID {
+	if r.MessageID != c.MessageID || r.ChannelID != c.ChannelID {
 		return
 	}
 	glib.IdleAdd(func() {
@@ -168,6 +215,7 @@ func (c *Container) clicked(r *Reaction) {
 		// Only increment the counter by the event. If react() fails, it
 		// will deactivate the button.
 		c.react(r)
+		emojis.RecordUse(r.emojiID)
 	} else {
 		// Same as above, but decrement.
 		c.unreact(r)
@@ -210,6 +258,8 @@ type Reaction struct {
 	Emoji  gtk.Widgetter // *gtk.Image or *gtk.Label
 
 	String discord.APIEmoji
+
+	emojiID discord.EmojiID
 }
 
 func newReaction(emoji discord.Emoji, count int, me bool) *Reaction {
@@ -222,6 +272,7 @@ func newReaction(emoji discord.Emoji, count int, me bool) *Reaction {
 	b.SetAlwaysShowImage(true)
 	b.SetImagePosition(gtk.PosLeft)
 	b.SetLabel(strconv.Itoa(count))
+	b.SetTooltipText("Right-click to see who reacted")
 	b.Show()
 
 	f.Add(b)
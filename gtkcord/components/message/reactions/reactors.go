@@ -0,0 +1,205 @@
+package reactions
+
+import (
+	"html"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gotk4/pkg/pango"
+	"github.com/diamondburned/gtkcord3/gtkcord/cache"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/roundimage"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// reactorsPageSize is the number of users fetched at once.
+	reactorsPageSize = 25
+	reactorAvatar    = 24
+)
+
+// ParseEmoji turns an emoji from the emoji picker, which is either a unicode
+// emoji or <:name:id>, into the form the API takes.
+func ParseEmoji(emoji string) discord.APIEmoji {
+	if !strings.HasPrefix(emoji, "<") || !strings.HasSuffix(emoji, ">") {
+		return discord.APIEmoji(emoji)
+	}
+
+	emoji = strings.Trim(emoji, "<>")
+	emoji = strings.TrimPrefix(emoji, "a")
+	emoji = strings.TrimPrefix(emoji, ":")
+
+	return discord.APIEmoji(emoji)
+}
+
+// apiEmojiID returns the ID of a custom emoji from ParseEmoji, or 0 if it's a
+// Unicode one.
+func apiEmojiID(emoji discord.APIEmoji) discord.EmojiID {
+	i := strings.LastIndexByte(string(emoji), ':')
+	if i < 0 {
+		return 0
+	}
+
+	id, err := discord.ParseSnowflake(string(emoji[i+1:]))
+	if err != nil {
+		return 0
+	}
+
+	return discord.EmojiID(id)
+}
+
+// Reactors is a popover listing the users who reacted with an emoji. More users
+// are fetched as they're asked for.
+type Reactors struct {
+	*gtk.Popover
+	List  *gtk.ListBox
+	More  *gtk.Button
+	Error *gtk.Label
+
+	container *Container
+	emoji     discord.APIEmoji
+	after     discord.UserID
+	canRemove bool
+}
+
+func newReactors(c *Container, r *Reaction) *Reactors {
+	reactors := &Reactors{
+		container: c,
+		emoji:     r.String,
+		canRemove: c.canManage(),
+	}
+
+	reactors.List = gtk.NewListBox()
+	reactors.List.SetSelectionMode(gtk.SelectionNone)
+
+	reactors.More = gtk.NewButtonWithLabel("Load More")
+	reactors.More.SetRelief(gtk.ReliefNone)
+	reactors.More.Connect("clicked", reactors.load)
+
+	reactors.Error = gtk.NewLabel("")
+	reactors.Error.SetLineWrap(true)
+	reactors.Error.SetNoShowAll(true)
+
+	box := gtk.NewBox(gtk.OrientationVertical, 0)
+	box.Add(reactors.List)
+	box.Add(reactors.More)
+	box.Add(reactors.Error)
+
+	scroll := gtk.NewScrolledWindow(nil, nil)
+	scroll.SetPolicy(gtk.PolicyNever, gtk.PolicyAutomatic)
+	scroll.SetPropagateNaturalHeight(true)
+	scroll.SetMaxContentHeight(300)
+	scroll.SetSizeRequest(220, -1)
+	scroll.Add(box)
+	scroll.ShowAll()
+
+	reactors.Popover = gtk.NewPopover(r.Button)
+	reactors.Popover.SetPosition(gtk.PosTop)
+	reactors.Popover.Add(scroll)
+	reactors.Popover.Connect("closed", reactors.Popover.Destroy)
+
+	reactors.load()
+	return reactors
+}
+
+func (r *Reactors) load() {
+	r.More.SetSensitive(false)
+
+	c := r.container
+	after := r.after
+
+	go func() {
+		users, err := c.state.ReactionsAfter(c.ChannelID, c.MessageID, after, r.emoji, reactorsPageSize)
+		if err != nil {
+			log.Errorln("failed to get reactions:", err)
+		}
+
+		glib.IdleAdd(func() {
+			r.More.SetSensitive(true)
+
+			if err != nil {
+				r.showError(errors.Wrap(err, "failed to get reactions"))
+				return
+			}
+
+			for _, user := range users {
+				r.List.Add(r.newRow(user))
+			}
+
+			if len(users) > 0 {
+				r.after = users[len(users)-1].ID
+			}
+
+			r.More.SetVisible(len(users) == reactorsPageSize)
+		})
+	}()
+}
+
+func (r *Reactors) newRow(user discord.User) *gtk.ListBoxRow {
+	avatar := roundimage.NewImage(0)
+	avatar.SetSizeRequest(reactorAvatar, reactorAvatar)
+	avatar.SetInitials(user.Username)
+	if url := user.AvatarURL(); url != "" {
+		cache.SetImageURLScaled(avatar, url+"?size=32", reactorAvatar, reactorAvatar)
+	}
+
+	name := gtk.NewLabel("")
+	name.SetMarkup(html.EscapeString(user.Username) +
+		`<span alpha="50%">#` + user.Discriminator + `</span>`)
+	name.SetEllipsize(pango.EllipsizeEnd)
+	name.SetXAlign(0)
+	name.SetHExpand(true)
+
+	box := gtk.NewBox(gtk.OrientationHorizontal, 6)
+	gtkutils.Margin2(box, 2, 6)
+	box.Add(avatar)
+	box.Add(name)
+
+	row := gtk.NewListBoxRow()
+	row.Add(box)
+
+	if r.canRemove {
+		remove := gtk.NewButtonFromIconName("list-remove-symbolic", int(gtk.IconSizeButton))
+		remove.SetRelief(gtk.ReliefNone)
+		remove.SetTooltipText("Remove Reaction")
+		remove.Connect("clicked", func() {
+			remove.SetSensitive(false)
+			r.remove(row, user.ID, func() { remove.SetSensitive(true) })
+		})
+		box.Add(remove)
+	}
+
+	row.ShowAll()
+	return row
+}
+
+// remove removes the user's reaction. The reaction count is updated by the
+// event.
+func (r *Reactors) remove(row *gtk.ListBoxRow, userID discord.UserID, failed func()) {
+	c := r.container
+
+	go func() {
+		err := c.state.DeleteUserReaction(c.ChannelID, c.MessageID, userID, r.emoji)
+		if err != nil {
+			log.Errorln("failed to remove reaction:", err)
+		}
+
+		glib.IdleAdd(func() {
+			if err != nil {
+				failed()
+				r.showError(errors.Wrap(err, "failed to remove reaction"))
+				return
+			}
+
+			row.Destroy()
+		})
+	}()
+}
+
+func (r *Reactors) showError(err error) {
+	r.Error.SetMarkup(`<span color="red">` + html.EscapeString(err.Error()) + `</span>`)
+	r.Error.Show()
+}
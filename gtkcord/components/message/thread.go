@@ -0,0 +1,182 @@
+package message
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+// threadNameLength is how long the suggested name of a new thread can be.
+const threadNameLength = 40
+
+// setThread shows a link to the thread below the message. The number of
+// messages isn't shown if it's negative. The link is made once and updated
+// afterwards.
+func (m *Message) setThread(name string, count int) {
+	var text string
+	switch {
+	case count < 0:
+		text = "See Thread"
+	case count >= 50:
+		text = "50+ Messages"
+	case count == 1:
+		text = "1 Message"
+	default:
+		text = fmt.Sprintf("%d Messages", count)
+	}
+
+	if m.threadLink == nil {
+		m.threadLabel = gtk.NewLabel("")
+		m.threadLabel.SetXAlign(0)
+
+		icon := gtk.NewImageFromIconName("mail-reply-sender-symbolic", int(gtk.IconSizeMenu))
+
+		box := gtk.NewBox(gtk.OrientationHorizontal, 6)
+		box.Add(icon)
+		box.Add(m.threadLabel)
+
+		m.threadLink = gtk.NewButton()
+		m.threadLink.SetRelief(gtk.ReliefNone)
+		m.threadLink.SetHAlign(gtk.AlignStart)
+		m.threadLink.SetTooltipText("Open Thread")
+		m.threadLink.Add(box)
+		m.threadLink.Connect("clicked", func() { m.OnThreadClick(m) })
+		m.threadLink.ShowAll()
+		gtkutils.InjectCSS(m.threadLink, "thread-link", "")
+
+		m.rightBottom.Add(m.threadLink)
+	}
+
+	name = `<b>` + html.EscapeString(name) + `</b>`
+	m.threadLabel.SetMarkup(name + " · " + `<span color="#00B0F4">` + text + " ›</span>")
+}
+
+// updateThread shows the thread that was started from the message, if any.
+func (m *Messages) updateThread(w *Message) {
+	t, ok := m.threads.Thread(w.threadID)
+
+	switch {
+	case ok:
+		w.setThread(t.Name, t.MessageCount)
+	case w.threadName != "":
+		// The thread isn't active anymore, but it can still be opened.
+		w.setThread(w.threadName, -1)
+	}
+}
+
+// canSend returns false if the channel is a thread that the user isn't allowed
+// to send messages in. Other channels are left to Discord.
+func (m *Messages) canSend(chID discord.ChannelID) bool {
+	t, ok := m.threads.Thread(chID)
+	if !ok {
+		return true
+	}
+
+	me, err := m.c.Me()
+	if err != nil {
+		return true
+	}
+
+	p, err := m.c.Permissions(t.ParentID(), me.ID)
+	if err != nil {
+		log.Errorln("failed to get permissions:", err)
+		return true
+	}
+
+	return p.Has(threads.PermissionSendMessagesInThreads)
+}
+
+func (m *Messages) onThreadClick(msg *Message) {
+	if m.OpenThread != nil {
+		m.OpenThread(msg.threadID)
+	}
+}
+
+// menuAddThread adds the item that starts a thread from the message.
+func (m *Messages) menuAddThread(msg *Message, menuContainer gtk.Containerer) {
+	guildID := m.GuildID()
+
+	// Threads can't be started in DMs, in threads, or twice from a message.
+	if !msg.ID.IsValid() || !guildID.IsValid() || msg.threadLink != nil {
+		return
+	}
+
+	if ch, err := m.c.Cabinet.Channel(m.ChannelID()); err != nil || threads.IsThread(ch.Type) {
+		return
+	}
+
+	me, _ := m.c.Me()
+
+	p, err := m.c.Permissions(m.ChannelID(), me.ID)
+	if err != nil {
+		log.Errorln("failed to get permissions:", err)
+		return
+	}
+
+	if !p.Has(threads.PermissionCreatePublicThreads) {
+		return
+	}
+
+	iThread := gtk.NewMenuItemWithLabel("Create Thread")
+	iThread.Connect("activate", func() {
+		m.createThread(msg)
+	})
+	iThread.Show()
+	gtk.BaseContainer(menuContainer).Add(iThread)
+}
+
+// createThread asks for a name, then starts a thread from the message and
+// opens it.
+func (m *Messages) createThread(msg *Message) {
+	var suggested string
+	if dm, err := m.c.Cabinet.Message(m.ChannelID(), msg.ID); err == nil {
+		suggested = threadName(dm.Content)
+	}
+
+	name, ok := window.Prompt(nil, "Create Thread", "Name the new thread:", "Create", suggested)
+	if !ok {
+		return
+	}
+
+	chID := m.ChannelID()
+
+	go func() {
+		t, err := threads.StartFromMessage(m.c, chID, msg.ID, name)
+		if err != nil {
+			log.Errorln("failed to create thread:", err)
+			glib.IdleAdd(func() {
+				msg.ShowError(errors.Wrap(err, "failed to create thread"))
+			})
+			return
+		}
+
+		glib.IdleAdd(func() {
+			if m.OpenThread != nil {
+				m.OpenThread(t.ID)
+			}
+		})
+	}()
+}
+
+// threadName suggests a thread name from the first line of the message.
+func threadName(content string) string {
+	if i := strings.IndexByte(content, '\n'); i > -1 {
+		content = content[:i]
+	}
+
+	runes := []rune(strings.TrimSpace(content))
+	if len(runes) > threadNameLength {
+		return strings.TrimSpace(string(runes[:threadNameLength])) + "…"
+	}
+
+	return string(runes)
+}
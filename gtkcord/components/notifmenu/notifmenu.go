@@ -0,0 +1,149 @@
+// Package notifmenu makes the menu items that mute channels and guilds and
+// change which of their messages notify.
+package notifmenu
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+type level struct {
+	name  string
+	level gateway.UserNotification
+}
+
+var guildLevels = []level{
+	{"All Messages", gateway.AllNotifications},
+	{"Only @mentions", gateway.OnlyMentions},
+	{"Nothing", gateway.NoNotifications},
+}
+
+var channelLevels = append([]level{{"Use Server Default", gateway.GuildDefaults}}, guildLevels...)
+
+// AddChannel adds the items of the channel or category to the menu.
+func AddChannel(s *ningen.State, menu *gtk.Menu, guildID discord.GuildID, chID discord.ChannelID) {
+	name := "Channel"
+	if ch, err := s.Cabinet.Channel(chID); err == nil && ch.Type == discord.GuildCategory {
+		name = "Category"
+	}
+
+	menu.Add(muteItem(name, s.MutedState.Channel(chID), func(d time.Duration) error {
+		return notifications.MuteChannel(s, guildID, chID, d)
+	}))
+
+	// Direct messages can only be muted.
+	if !guildID.IsValid() {
+		return
+	}
+
+	menu.Add(levelItem(channelLevels, notifications.ChannelLevel(s, chID),
+		func(l gateway.UserNotification) error {
+			return notifications.SetChannelLevel(s, guildID, chID, l)
+		},
+	))
+}
+
+// AddGuild adds the items of the guild to the menu.
+func AddGuild(s *ningen.State, menu *gtk.Menu, guildID discord.GuildID) {
+	settings := s.MutedState.GuildSettings(guildID)
+
+	menu.Add(muteItem("Server", notifications.GuildMuted(s, guildID), func(d time.Duration) error {
+		return notifications.MuteGuild(s, guildID, d)
+	}))
+
+	menu.Add(levelItem(guildLevels, settings.Notifications, func(l gateway.UserNotification) error {
+		return notifications.SetGuildLevel(s, guildID, l)
+	}))
+
+	everyone := gtk.NewCheckMenuItemWithLabel("Suppress @everyone and @here")
+	everyone.SetActive(settings.SuppressEveryone)
+	everyone.Show()
+	menu.Add(everyone)
+
+	roles := gtk.NewCheckMenuItemWithLabel("Suppress All Role @mentions")
+	roles.SetActive(settings.SuppressRoles)
+	roles.Show()
+	menu.Add(roles)
+
+	suppress := func() {
+		everyone, roles := everyone.Active(), roles.Active()
+		run(func() error { return notifications.SetSuppressed(s, guildID, everyone, roles) })
+	}
+
+	everyone.Connect("activate", suppress)
+	roles.Connect("activate", suppress)
+}
+
+// muteItem unmutes if muted, or has a submenu with the mute durations.
+func muteItem(name string, muted bool, mute func(time.Duration) error) *gtk.MenuItem {
+	if muted {
+		item := gtk.NewMenuItemWithLabel("Unmute " + name)
+		item.Connect("activate", func() {
+			run(func() error { return mute(0) })
+		})
+		item.Show()
+		return item
+	}
+
+	submenu := gtk.NewMenu()
+
+	for _, duration := range notifications.MuteDurations {
+		d := duration.Duration
+
+		item := gtk.NewMenuItemWithLabel(duration.Name)
+		item.Connect("activate", func() {
+			run(func() error { return mute(d) })
+		})
+		item.Show()
+		submenu.Add(item)
+	}
+
+	item := gtk.NewMenuItemWithLabel("Mute " + name)
+	item.SetSubmenu(submenu)
+	item.Show()
+	return item
+}
+
+// levelItem has a submenu that picks one of the notification levels.
+func levelItem(
+	levels []level, current gateway.UserNotification,
+	set func(gateway.UserNotification) error) *gtk.MenuItem {
+
+	submenu := gtk.NewMenu()
+
+	for _, l := range levels {
+		l := l
+
+		item := gtk.NewCheckMenuItemWithLabel(l.name)
+		item.SetDrawAsRadio(true)
+		// Activating it before connecting doesn't change the setting.
+		item.SetActive(l.level == current)
+		item.Connect("activate", func() {
+			if item.Active() {
+				run(func() error { return set(l.level) })
+			}
+		})
+		item.Show()
+		submenu.Add(item)
+	}
+
+	item := gtk.NewMenuItemWithLabel("Notifications")
+	item.SetSubmenu(submenu)
+	item.Show()
+	return item
+}
+
+// run runs the request in the background and logs its error.
+func run(request func() error) {
+	go func() {
+		if err := request(); err != nil {
+			log.Errorln("failed to change notification settings:", err)
+		}
+	}()
+}
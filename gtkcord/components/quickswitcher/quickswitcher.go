@@ -211,6 +211,8 @@ func (d *Dialog) onActivate(_ *gtk.ListBox, r *gtk.ListBoxRow) {
 		d.OnChannel(entry.ChannelID, entry.GuildID)
 	case entry.GuildID.IsValid():
 		d.OnGuild(entry.GuildID)
+	case entry.FriendID.IsValid() && d.OnFriend != nil:
+		d.OnFriend(entry.FriendID)
 	}
 }
 
@@ -331,6 +333,27 @@ func populateEntries(s *ningen.State) []Entry {
 	// Batch append:
 	list = append(list, dmEntries...)
 
+	// Friends without a DM channel yet:
+	hasDM := make(map[discord.UserID]bool, len(dm))
+	for _, c := range dm {
+		if c.Type == discord.DirectMessage && len(c.DMRecipients) > 0 {
+			hasDM[c.DMRecipients[0].ID] = true
+		}
+	}
+
+	s.RelationshipState.Each(func(r *discord.Relationship) bool {
+		if r.Type == discord.FriendRelationship && !hasDM[r.UserID] {
+			list = append(list, Entry{
+				PrimaryText:   r.User.Username,
+				SecondaryText: "#" + r.User.Discriminator,
+				IconURL:       r.User.AvatarURL(),
+				IconChar:      '@',
+				FriendID:      r.UserID,
+			})
+		}
+		return false
+	})
+
 	// Form long strings:
 	for i, l := range list {
 		list[i].longString = string(l.IconChar) +
@@ -0,0 +1,106 @@
+package window
+
+import (
+	"strings"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+)
+
+// Confirm shows a modal dialog asking the user to confirm a destructive action.
+// It blocks until the user answers and returns true if they accepted. If parent
+// is nil, the main window is used.
+func Confirm(parent *gtk.Window, title, body, accept string) bool {
+	d := newDialog(parent, title, body)
+
+	d.AddButton("Cancel", int(gtk.ResponseCancel))
+	ok := d.AddButton(accept, int(gtk.ResponseAccept))
+	gtk.BaseWidget(ok).StyleContext().AddClass("destructive-action")
+	d.SetDefaultResponse(int(gtk.ResponseCancel))
+
+	resp := d.Run()
+	d.Destroy()
+
+	return gtk.ResponseType(resp) == gtk.ResponseAccept
+}
+
+// Choose shows a modal dialog with a button for each choice and a Cancel
+// button. It blocks until the user answers and returns the index of the chosen
+// choice, or -1 if they cancelled. If parent is nil, the main window is used.
+func Choose(parent *gtk.Window, title, body string, choices ...string) int {
+	d := newDialog(parent, title, body)
+
+	d.AddButton("Cancel", int(gtk.ResponseCancel))
+	for i, choice := range choices {
+		d.AddButton(choice, i)
+	}
+	d.SetDefaultResponse(int(gtk.ResponseCancel))
+
+	resp := d.Run()
+	d.Destroy()
+
+	if resp < 0 || resp >= len(choices) {
+		return -1
+	}
+	return resp
+}
+
+// Prompt shows a modal dialog asking the user to type in some text, starting
+// with the given text. It blocks until the user answers and returns the text,
+// or false if they cancelled or left it empty. If parent is nil, the main
+// window is used.
+func Prompt(parent *gtk.Window, title, body, accept, text string) (string, bool) {
+	text, ok := prompt(parent, title, body, accept, text)
+	return text, ok && text != ""
+}
+
+// PromptAllowEmpty is like Prompt, but it also returns true if the text is left
+// empty.
+func PromptAllowEmpty(parent *gtk.Window, title, body, accept, text string) (string, bool) {
+	return prompt(parent, title, body, accept, text)
+}
+
+func prompt(parent *gtk.Window, title, body, accept, text string) (string, bool) {
+	d := newDialog(parent, title, body)
+
+	entry := gtk.NewEntry()
+	entry.SetText(text)
+	entry.SetActivatesDefault(true)
+	entry.SetMarginStart(15)
+	entry.SetMarginEnd(15)
+	entry.SetMarginBottom(15)
+	entry.Show()
+	d.ContentArea().Add(entry)
+
+	d.AddButton("Cancel", int(gtk.ResponseCancel))
+	ok := d.AddButton(accept, int(gtk.ResponseAccept))
+	gtk.BaseWidget(ok).StyleContext().AddClass("suggested-action")
+	d.SetDefaultResponse(int(gtk.ResponseAccept))
+
+	resp := d.Run()
+	text = strings.TrimSpace(entry.Text())
+	d.Destroy()
+
+	return text, gtk.ResponseType(resp) == gtk.ResponseAccept
+}
+
+func newDialog(parent *gtk.Window, title, body string) *gtk.Dialog {
+	if parent == nil {
+		parent = &Window.Window
+	}
+
+	d := gtk.NewDialogWithFlags(title, parent, gtk.DialogModal|gtk.DialogDestroyWithParent)
+	d.SetResizable(false)
+
+	label := gtk.NewLabel(body)
+	label.SetLineWrap(true)
+	label.SetMaxWidthChars(50)
+	label.SetXAlign(0)
+	label.SetMarginTop(15)
+	label.SetMarginBottom(15)
+	label.SetMarginStart(15)
+	label.SetMarginEnd(15)
+	label.Show()
+
+	d.ContentArea().Add(label)
+	return d
+}
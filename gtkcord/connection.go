@@ -0,0 +1,160 @@
+package gtkcord
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/connstatus"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// connection tracks the gateway state for the connection banner. All fields
+// are only accessed from the main thread.
+type connection struct {
+	offline bool
+	resume  bool // true if the last session could be resumed
+	tries   uint
+
+	next       glib.SourceHandle // the next reconnection attempt
+	connecting bool              // true while an attempt is running
+}
+
+// stop cancels the next reconnection attempt.
+func (c *connection) stop() {
+	if c.next != 0 {
+		glib.SourceRemove(c.next)
+		c.next = 0
+	}
+}
+
+// reconnectBackoff is the delay before the next reconnection attempt, like the
+// gateway's own.
+func reconnectBackoff(try uint) time.Duration {
+	wait := time.Duration(4+2*try) * time.Second
+	if wait > 60*time.Second {
+		wait = 60 * time.Second
+	}
+	return wait
+}
+
+// bindConnection hooks the gateway callbacks to the connection banner instead
+// of replacing the whole window with a loading screen.
+func (a *Application) bindConnection(s *ningen.State) {
+	var conn connection
+
+	// The gateway only makes one attempt by itself. The next ones are made
+	// here, so that retrying can skip the wait without racing another loop.
+	s.Gateway.ReconnectAttempts = 1
+
+	reconnect := func() {
+		conn.stop()
+		if conn.connecting || a.State != s {
+			return
+		}
+		conn.connecting = true
+
+		go func() {
+			err := s.Gateway.Open()
+			glib.IdleAdd(func() { conn.connecting = false })
+
+			if err != nil {
+				s.Gateway.ErrorLog(err)
+			}
+		}()
+	}
+
+	a.Banner = connstatus.NewBanner()
+	a.Banner.OnRetry = func() {
+		// There's nothing to skip if an attempt is already running.
+		if conn.next != 0 {
+			reconnect()
+		}
+	}
+
+	// The websocket may close if it's disconnected unexpectedly.
+	s.Gateway.AfterClose = func(err error) {
+		// Is the application already dead?
+		if a.Application == nil {
+			return
+		}
+
+		resumable := s.Gateway.SessionID() != ""
+
+		// Run this asynchronously. This guarantees that the UI thread would
+		// never be hardlocked.
+		glib.IdleAdd(func() {
+			// Don't bother if we've logged out.
+			if a.State != s {
+				conn.stop()
+				return
+			}
+
+			conn.offline = true
+			conn.resume = resumable
+
+			if resumable {
+				a.Banner.SetResuming(err)
+			} else {
+				a.Banner.SetDisconnected(err, 0)
+			}
+
+			a.Messages.SetConnected(false)
+		})
+	}
+
+	// Set gateway error functions to our own:
+	s.Gateway.ErrorLog = func(err error) {
+		log.Errorln(err)
+
+		glib.IdleAdd(func() {
+			// Errors while we're online are not reconnection failures.
+			if a.State != s || !conn.offline {
+				return
+			}
+
+			conn.tries++
+			a.Banner.SetDisconnected(err, reconnectBackoff(conn.tries))
+
+			// The attempt that's running will report its own error.
+			if conn.connecting {
+				return
+			}
+
+			conn.stop()
+			conn.next = glib.TimeoutAdd(
+				uint(reconnectBackoff(conn.tries)/time.Millisecond),
+				func() bool {
+					conn.next = 0
+					reconnect()
+					return false
+				},
+			)
+		})
+	}
+
+	s.AddHandler(func(c *ningen.Connected) {
+		_, ready := c.Event.(*gateway.ReadyEvent)
+
+		glib.IdleAdd(func() {
+			if a.State != s {
+				return
+			}
+
+			// Getting a Ready instead of a Resumed event means that Discord
+			// invalidated the session we were trying to resume.
+			invalid := conn.offline && conn.resume && ready
+			conn.stop()
+			conn = connection{}
+
+			if invalid {
+				a.Banner.SetInvalidSession()
+			} else {
+				a.Banner.SetConnected()
+			}
+
+			a.Messages.SetConnected(true)
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package gtkcord
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+// ShowFriends shows the friends page instead of the messages.
+func (a *Application) ShowFriends() {
+	a.Messages.Cleanup()
+	a.Privates.List.UnselectAll()
+
+	a.Right.SetChild(a.Friends)
+
+	a.Header.UpdateChannel("Friends")
+	a.Header.ChMenuBtn.SetRevealChild(false)
+	window.SetTitle("Friends - gtkcord")
+
+	if a.Main.Folded() {
+		a.Main.SetRevealFlap(false)
+	}
+}
+
+// MessageUser opens the direct message with the user, which is created if
+// there isn't one yet.
+func (a *Application) MessageUser(userID discord.UserID) {
+	go func() {
+		ch, err := a.State.CreatePrivateChannel(userID)
+		if err != nil {
+			log.Errorln("failed to create private channel:", err)
+			return
+		}
+
+		glib.IdleAdd(func() {
+			wasDM := a.leftIsDM()
+
+			if a.SwitchToID(ch.ID, 0) || !wasDM {
+				return
+			}
+
+			// The channel is new, so the list has to be loaded again to have it.
+			// It's opened once it's loaded.
+			a.Privates.Load()
+		})
+	}()
+}
@@ -14,10 +14,14 @@ import (
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/channel"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/connstatus"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/friends"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/greet"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/guild"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/hamburger"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/header"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/inbox"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/inspector"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/login"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/logo"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/message"
@@ -26,6 +30,8 @@ import (
 	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils/gdbus"
+	"github.com/diamondburned/gtkcord3/gtkcord/mentions"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
 	"github.com/diamondburned/ningen/v2"
 
 	"github.com/diamondburned/gtkcord3/internal/keyring"
@@ -74,9 +80,12 @@ type Application struct {
 	MPRIS      *gdbus.MPRISWatcher
 	mprisState *mprisState
 
-	Plugins []*Plugin
+	Plugins  []*Plugin
+	external externalPlugins
 
-	State *ningen.State
+	State    *ningen.State
+	Threads  *threads.State
+	Mentions *mentions.State
 
 	// Preferences window, hidden by default
 	Settings *Settings
@@ -84,7 +93,8 @@ type Application struct {
 	// Main Grid, left is always LeftGrid - *gtk.Grid
 	Main       *handy.Flap // LeftGrid -- Right
 	RightWhole *gtk.Box
-	Right      *singlebox.Box // Stack of Messages or full screen server details TODO
+	Banner     *connstatus.Banner // connection state, above Right
+	Right      *singlebox.Box     // Stack of Messages or full screen server details TODO
 	// <grid>        <item>       <item>
 	// | Left        | Right    |
 	// | Left Grid   | Messages |
@@ -102,18 +112,16 @@ type Application struct {
 	Privates *channel.PrivateChannels
 	Channels *channel.Channels
 	Messages *message.Messages
+	Friends  *friends.Page
+
+	// Developer tools, disabled by default
+	Inspector *inspector.Inspector
 }
 
 // New is not thread-safe.
 func New(app *gtk.Application) *Application {
-	plugins, err := loadPlugins()
-	if err != nil {
-		log.Fatalln("Failed to load plugins:", err)
-	}
-
 	return &Application{
 		Application: app,
-		Plugins:     plugins,
 	}
 }
 
@@ -121,6 +129,9 @@ func (a *Application) Close() {
 	// Mark application as exited:
 	a.Application = nil
 
+	// Let plugins clean up before the session is gone:
+	a.shutdownPlugins()
+
 	// Close session on exit:
 	if a.State != nil {
 		a.State.Close()
@@ -193,40 +204,11 @@ func (a *Application) displayMain() {
 
 func (a *Application) Ready(s *ningen.State) error {
 	a.State = s
+	a.external.setState(s)
 
-	var reconnecting glib.SourceHandle
-
-	// When the websocket closes, the screen must be changed to a busy one. The
-	// websocket may close if it's disconnected unexpectedly.
-	s.Gateway.AfterClose = func(error) {
-		// Is the application already dead?
-		if a.Application == nil {
-			return
-		}
-
-		// Run this asynchronously. This guarantees that the UI thread would
-		// never be hardlocked.
-		reconnecting = glib.TimeoutSecondsAdd(3, func() {
-			window.NowLoading()
-			reconnecting = 0
-		})
-	}
-
-	// Show the main screen once everything is resumed. See above NowLoading
-	// call.
-	s.AddHandler(func(c *ningen.Connected) {
-		glib.IdleAdd(func() {
-			if reconnecting == 0 {
-				a.displayMain()
-				return
-			}
-
-			// We reconnected before 3 seconds, so skip the loading screen
-			// entirely.
-			glib.SourceRemove(reconnecting)
-			reconnecting = 0
-		})
-	})
+	// Show the connection state in a banner instead of a loading screen when
+	// the websocket disconnects.
+	a.bindConnection(s)
 
 	// Store the token:
 	go func() {
@@ -234,11 +216,6 @@ func (a *Application) Ready(s *ningen.State) error {
 		log.Println("saved token")
 	}()
 
-	// Set gateway error functions to our own:
-	s.Gateway.ErrorLog = func(err error) {
-		log.Errorln(err)
-	}
-
 	// Make the main widgets:
 	a.init()
 	a.displayMain()
@@ -257,19 +234,26 @@ func (a *Application) Ready(s *ningen.State) error {
 		State:    s,
 		Settings: a.Settings.Show,
 		LogOut:   a.LogOut,
+		MarkRead: a.MarkAllRead,
 	})
 
 	// Bind stuff
 	a.bindActions()
 	a.bindNotifier()
+	a.bindMarkRead()
+	a.bindNavigation()
 
 	// Guilds
 
 	a.Guilds = guild.NewGuilds(s)
 	a.Guilds.OnSelect = a.SwitchGuild
 	a.Guilds.DMButton.OnClick = a.SwitchDM
+	guild.Menu = a.guildMenu
 
-	a.Channels = channel.NewChannels(s, func(ch *channel.Channel) {
+	a.Threads = threads.NewState(s)
+	a.Mentions = mentions.NewState(s)
+
+	a.Channels = channel.NewChannels(s, a.Threads, func(ch *channel.Channel) {
 		a.SwitchChannel(ch)
 		a.FocusMessages()
 	})
@@ -279,12 +263,25 @@ func (a *Application) Ready(s *ningen.State) error {
 		a.FocusMessages()
 	})
 
-	a.Messages = message.NewMessages(s, message.Opts{
+	// The page from the last Ready would still get events.
+	if a.Friends != nil {
+		a.Friends.Destroy()
+	}
+
+	a.Friends = friends.NewPage(s)
+	a.Friends.OnMessage = a.MessageUser
+	a.Privates.OnFriends = a.ShowFriends
+
+	a.Messages = message.NewMessages(s, a.Threads, message.Opts{
 		InputZeroWidth: a.Settings.General.Behavior.ZeroWidth,
 		InputOnTyping:  a.Settings.General.Behavior.OnTyping,
 		MessageWidth:   a.Settings.General.Customization.MessageWidth,
+		OpenThread:     a.OpenThread,
 	})
 
+	// Make the inspector, which might be enabled in the settings:
+	a.bindInspector(s)
+
 	greeter := greet.NewGreeter()
 	greeter.SetSurface(logo.Surface(greet.IconSize, 2))
 	a.Messages.SetPlaceholder(greeter)
@@ -307,6 +304,9 @@ func (a *Application) Ready(s *ningen.State) error {
 		return header.NewChMenuBody(p, s, guID, chID)
 	})
 
+	// Add the mentions inbox next to the channel menu:
+	a.Header.Right.PackEnd(inbox.NewButton(s, a.Mentions, a.OpenMention))
+
 	// // Bind to set-focus-child so swiping left works too.
 	// a.Main.Connect("set-focus-child", func(w gtk.Widgetter) {
 	// 	if w == nil {
@@ -359,6 +359,7 @@ func (a *Application) Ready(s *ningen.State) error {
 	a.RightWhole = gtk.NewBox(gtk.OrientationVertical, 0)
 	a.RightWhole.SetHExpand(true)
 	a.RightWhole.PackStart(a.Header.Right, false, false, 0)
+	a.RightWhole.PackStart(a.Banner, false, false, 0)
 	a.RightWhole.PackStart(a.Right, true, true, 0)
 	gtkutils.InjectCSS(a.RightWhole, "right-whole", "")
 	a.RightWhole.Show()
@@ -381,6 +382,7 @@ func (a *Application) Ready(s *ningen.State) error {
 		OnChannel: func(chID discord.ChannelID, gID discord.GuildID) {
 			a.SwitchToID(chID, gID)
 		},
+		OnFriend: a.MessageUser,
 	})
 
 	// Finally, mark plugins as ready:
@@ -395,6 +397,7 @@ func (a *Application) LogOut() {
 
 	state := a.State
 	a.State = nil
+	a.external.setState(nil)
 
 	go func() {
 		// First we need to close the session:
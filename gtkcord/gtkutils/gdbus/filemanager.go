@@ -0,0 +1,37 @@
+package gdbus
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/diamondburned/gotk4/pkg/gio/v2"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+)
+
+// ShowInFolder asks the file manager to open the folder of the file with the
+// file selected. It blocks until the file manager replies.
+func ShowInFolder(path string) error {
+	c, err := SessionBusSync()
+	if err != nil {
+		return err
+	}
+
+	uri := (&url.URL{Scheme: "file", Path: path}).String()
+
+	_, err = c.CallSync(
+		context.Background(),
+		"org.freedesktop.FileManager1",
+		"/org/freedesktop/FileManager1",
+		"org.freedesktop.FileManager1",
+		"ShowItems",
+		glib.NewVariantTuple([]*glib.Variant{
+			glib.NewVariantStrv([]string{uri}),
+			glib.NewVariantString(""), // startup ID
+		}),
+		nil,
+		gio.DBusCallFlagsNone,
+		5000,
+	)
+
+	return err
+}
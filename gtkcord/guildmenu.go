@@ -0,0 +1,212 @@
+package gtkcord
+
+import (
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/channel"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/guild"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/notifmenu"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/overview"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// guildMenu adds the items of the menu that's shown when a guild is
+// right-clicked, either on its own or in a folder.
+func (a *Application) guildMenu(g *guild.Guild, menu *gtk.Menu) {
+	addMenuItem(menu, "Mark Server As Read", func() { a.MarkGuildRead(g.ID) })
+	addSeparator(menu)
+
+	notifmenu.AddGuild(a.State, menu, g.ID)
+	addSeparator(menu)
+
+	addMenuItem(menu, "Collapse All Categories", func() { a.Channels.SetAllCollapsed(g.ID, true) })
+	addMenuItem(menu, "Expand All Categories", func() { a.Channels.SetAllCollapsed(g.ID, false) })
+	addSeparator(menu)
+
+	addMenuItem(menu, "Server Details", func() { a.showGuildDetails(g.ID) })
+	addMenuItem(menu, "Change Nickname", func() { a.changeNickname(g.ID) })
+	menu.Add(a.allowDMsItem(g.ID))
+	addMenuItem(menu, "Copy Server ID", func() { copyText(g.ID.String()) })
+
+	// Owners can't leave their own guild.
+	if gd, err := a.State.Cabinet.Guild(g.ID); err == nil {
+		if me, err := a.State.Me(); err == nil && gd.OwnerID == me.ID {
+			return
+		}
+	}
+
+	addSeparator(menu)
+	addMenuItem(menu, "Leave Server", func() { a.leaveGuild(g.ID, g.Name) })
+}
+
+func addMenuItem(menu *gtk.Menu, label string, activate func()) {
+	item := gtk.NewMenuItemWithLabel(label)
+	item.Connect("activate", activate)
+	item.Show()
+	menu.Add(item)
+}
+
+func addSeparator(menu *gtk.Menu) {
+	separator := gtk.NewSeparatorMenuItem()
+	separator.Show()
+	menu.Add(separator)
+}
+
+func copyText(text string) {
+	if window.Window.Clipboard != nil {
+		window.Window.Clipboard.SetText(text, len(text))
+	}
+}
+
+// showGuildDetails shows the overview of the guild with the selected channel,
+// or its first text channel if another guild is shown.
+func (a *Application) showGuildDetails(guildID discord.GuildID) {
+	chID := a.Messages.ChannelID()
+
+	if a.Messages.GuildID() != guildID {
+		chID = 0
+
+		channels, err := a.State.Channels(guildID)
+		if err != nil {
+			log.Errorln("failed to get guild channels:", err)
+			return
+		}
+
+		for _, ch := range channel.FilterChannels(a.State, channels) {
+			if ch.Type == discord.GuildText {
+				chID = ch.ID
+				break
+			}
+		}
+	}
+
+	overview.SpawnDialog(overview.NewContainer(a.State, guildID, chID))
+}
+
+// changeNickname asks for the new nickname in the guild. An empty one removes
+// it.
+func (a *Application) changeNickname(guildID discord.GuildID) {
+	var nick string
+	if me, err := a.State.Me(); err == nil {
+		if member, err := a.State.Cabinet.Member(guildID, me.ID); err == nil {
+			nick = member.Nick
+		}
+	}
+
+	nick, ok := window.PromptAllowEmpty(nil, "Change Nickname", "Leave it empty to remove it.", "Save", nick)
+	if !ok {
+		return
+	}
+
+	go func() {
+		if err := a.State.ChangeOwnNickname(guildID, nick); err != nil {
+			log.Errorln("failed to change nickname:", err)
+		}
+	}()
+}
+
+// allowDMsItem toggles whether members of the guild can send direct messages.
+func (a *Application) allowDMsItem(guildID discord.GuildID) *gtk.CheckMenuItem {
+	item := gtk.NewCheckMenuItemWithLabel("Allow Direct Messages")
+	item.SetActive(!dmsRestricted(a.State, guildID))
+	item.Connect("activate", func() {
+		settings := a.State.Ready().UserSettings
+		if settings == nil {
+			log.Errorln("failed to change privacy settings: no user settings")
+			return
+		}
+
+		restricted := restrictGuild(settings.RestrictedGuilds, guildID, !item.Active())
+
+		go func() {
+			if err := setRestrictedGuilds(a.State, settings, restricted); err != nil {
+				log.Errorln("failed to change privacy settings:", err)
+			}
+		}()
+	})
+	item.Show()
+
+	return item
+}
+
+func dmsRestricted(s *ningen.State, guildID discord.GuildID) bool {
+	settings := s.Ready().UserSettings
+	if settings == nil {
+		return false
+	}
+
+	for _, id := range settings.RestrictedGuilds {
+		if id == guildID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restrictGuild returns a copy of the restricted guilds with the guild added
+// or removed.
+func restrictGuild(guildIDs []discord.GuildID, guildID discord.GuildID, restrict bool) []discord.GuildID {
+	restricted := make([]discord.GuildID, 0, len(guildIDs)+1)
+	for _, id := range guildIDs {
+		if id != guildID {
+			restricted = append(restricted, id)
+		}
+	}
+	if restrict {
+		restricted = append(restricted, guildID)
+	}
+
+	return restricted
+}
+
+// setRestrictedGuilds changes the guilds that can't send direct messages, then
+// updates the given settings in the main thread. Events from Handler.Call skip
+// the state, so the settings that Ready points to are changed as well.
+func setRestrictedGuilds(
+	s *ningen.State, settings *gateway.UserSettings, restricted []discord.GuildID) error {
+
+	var data struct {
+		RestrictedGuilds []discord.GuildID `json:"restricted_guilds"`
+	}
+	data.RestrictedGuilds = restricted
+
+	var updated gateway.UserSettings
+
+	err := s.Client.Client.RequestJSON(
+		&updated, "PATCH", api.EndpointMe+"/settings",
+		httputil.WithJSONBody(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	glib.IdleAdd(func() {
+		*settings = updated
+		s.State.Handler.Call(&gateway.UserSettingsUpdateEvent{UserSettings: updated})
+	})
+	return nil
+}
+
+// leaveGuild asks for confirmation, then leaves the guild. The guild is
+// removed from the list when the gateway says so.
+func (a *Application) leaveGuild(guildID discord.GuildID, name string) {
+	body := "Are you sure you want to leave " + name + "? You won't be able to " +
+		"rejoin it unless you are re-invited."
+
+	if !window.Confirm(nil, "Leave Server", body, "Leave Server") {
+		return
+	}
+
+	go func() {
+		if err := a.State.LeaveGuild(guildID); err != nil {
+			log.Errorln("failed to leave guild:", err)
+		}
+	}()
+}
@@ -0,0 +1,25 @@
+package gtkcord
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+// OpenMention opens the message's channel and jumps to the message.
+func (a *Application) OpenMention(msg discord.Message) {
+	a.Messages.JumpTo(msg.ChannelID, msg.ID)
+
+	// The message is scrolled to right away if the channel is already open.
+	if a.Messages.ChannelID() == msg.ChannelID {
+		a.FocusMessages()
+		return
+	}
+
+	ch, err := a.State.Cabinet.Channel(msg.ChannelID)
+	if err != nil {
+		// Threads aren't in the state.
+		a.OpenThread(msg.ChannelID)
+		return
+	}
+
+	a.SwitchToID(ch.ID, ch.GuildID)
+}
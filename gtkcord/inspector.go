@@ -0,0 +1,48 @@
+package gtkcord
+
+import (
+	"sync"
+
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/inspector"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/ningen/v2"
+)
+
+const AccelInspector = "<gtkcord>/inspector.Present"
+
+var inspectorAccelOnce sync.Once
+
+// bindInspector creates the developer inspector and applies its setting. It
+// must be called after Messages is made.
+func (a *Application) bindInspector(s *ningen.State) {
+	a.Inspector = inspector.New(s)
+	a.setInspector(a.Settings.General.Developer.Inspector)
+
+	inspectorAccelOnce.Do(func() {
+		gtk.AccelMapAddEntry(AccelInspector, gdk.KEY_I, gdk.ControlMask|gdk.ShiftMask)
+	})
+
+	window.Window.Accel.ConnectByPath(AccelInspector, func() {
+		if a.Inspector != nil && a.Inspector.Enabled() {
+			a.Inspector.Present()
+		}
+	})
+}
+
+// setInspector enables or disables the inspector. This might be called before
+// Ready, in which case it does nothing.
+func (a *Application) setInspector(enabled bool) {
+	if a.Inspector == nil {
+		return
+	}
+
+	a.Inspector.SetEnabled(enabled)
+
+	if enabled {
+		a.Messages.Inspect = a.Inspector.Inspect
+	} else {
+		a.Messages.Inspect = nil
+	}
+}
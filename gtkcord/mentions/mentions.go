@@ -0,0 +1,222 @@
+// Package mentions keeps the recent messages that mention the user, like the
+// inbox of the official client.
+package mentions
+
+import (
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// Limit is how many mentions are kept, which is also the most that Discord
+// returns at once.
+const Limit = 50
+
+// Fetch gets the recent messages that mention the user directly, with one of
+// their roles or with @everyone, newest first. If the guild ID is 0, they're
+// from every guild.
+func Fetch(s *ningen.State, guildID discord.GuildID, limit uint) ([]discord.Message, error) {
+	var param struct {
+		Limit    uint            `schema:"limit"`
+		Roles    bool            `schema:"roles"`
+		Everyone bool            `schema:"everyone"`
+		GuildID  discord.GuildID `schema:"guild_id,omitempty"`
+	}
+
+	param.Limit = limit
+	param.Roles = true
+	param.Everyone = true
+	param.GuildID = guildID
+
+	var msgs []discord.Message
+	return msgs, s.Client.Client.RequestJSON(
+		&msgs, "GET", api.EndpointMe+"/mentions",
+		httputil.WithSchema(s.Client, param),
+	)
+}
+
+// Dismiss removes the message from the user's mentions.
+func Dismiss(s *ningen.State, msgID discord.MessageID) error {
+	return s.Client.Client.FastRequest("DELETE", api.EndpointMe+"/mentions/"+msgID.String())
+}
+
+// State keeps the recent mentions. They're fetched the first time they're
+// asked for, then kept up to date with the gateway events.
+type State struct {
+	state *ningen.State
+
+	mutex    sync.RWMutex
+	messages []discord.Message // newest first
+	fetched  bool
+	onUpdate []func()
+}
+
+// NewState creates a new mentions state and binds it to the gateway.
+func NewState(s *ningen.State) *State {
+	m := &State{state: s}
+
+	s.AddHandler(m.onMessageCreate)
+	s.AddHandler(m.onMessageUpdate)
+	s.AddHandler(func(ev *gateway.MessageDeleteEvent) { m.remove(ev.ID) })
+	s.AddHandler(func(ev *gateway.MessageDeleteBulkEvent) { m.remove(ev.IDs...) })
+
+	return m
+}
+
+// OnUpdate adds a callback that's called when the mentions change. It's not
+// called in the main thread.
+func (m *State) OnUpdate(fn func()) {
+	m.mutex.Lock()
+	m.onUpdate = append(m.onUpdate, fn)
+	m.mutex.Unlock()
+}
+
+func (m *State) updated() {
+	m.mutex.RLock()
+	callbacks := m.onUpdate
+	m.mutex.RUnlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+// Messages returns the recent mentions, newest first. They're fetched if this
+// is the first time. This method should not be called in the main thread.
+func (m *State) Messages() ([]discord.Message, error) {
+	m.mutex.RLock()
+	fetched := m.fetched
+	m.mutex.RUnlock()
+
+	if !fetched {
+		msgs, err := Fetch(m.state, 0, Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		m.mutex.Lock()
+		// Keep the mentions that came while fetching.
+		m.messages = merge(m.messages, msgs)
+		m.fetched = true
+		m.mutex.Unlock()
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]discord.Message(nil), m.messages...), nil
+}
+
+// Dismiss removes the message from the mentions.
+func (m *State) Dismiss(msgID discord.MessageID) error {
+	if err := Dismiss(m.state, msgID); err != nil {
+		return err
+	}
+
+	m.remove(msgID)
+	return nil
+}
+
+func (m *State) onMessageCreate(ev *gateway.MessageCreateEvent) {
+	if me, err := m.state.Me(); err != nil || ev.Author.ID == me.ID {
+		return
+	}
+
+	if !notifications.Mentions(m.state, ev.Message) {
+		return
+	}
+
+	m.mutex.Lock()
+	m.messages = merge([]discord.Message{ev.Message}, m.messages)
+	m.mutex.Unlock()
+
+	m.updated()
+}
+
+func (m *State) onMessageUpdate(ev *gateway.MessageUpdateEvent) {
+	m.mutex.Lock()
+
+	var found bool
+	for i, msg := range m.messages {
+		if msg.ID == ev.ID {
+			// Updates might not have everything.
+			if ev.Content != "" {
+				m.messages[i].Content = ev.Content
+			}
+			m.messages[i].EditedTimestamp = ev.EditedTimestamp
+			found = true
+			break
+		}
+	}
+
+	m.mutex.Unlock()
+
+	if found {
+		m.updated()
+	}
+}
+
+func (m *State) remove(ids ...discord.MessageID) {
+	m.mutex.Lock()
+
+	var removed bool
+	filtered := m.messages[:0]
+
+	for _, msg := range m.messages {
+		if containsID(ids, msg.ID) {
+			removed = true
+			continue
+		}
+		filtered = append(filtered, msg)
+	}
+
+	m.messages = filtered
+	m.mutex.Unlock()
+
+	if removed {
+		m.updated()
+	}
+}
+
+// merge merges both lists of messages, newest first, without duplicates and
+// up to the limit.
+func merge(a, b []discord.Message) []discord.Message {
+	merged := make([]discord.Message, 0, len(a)+len(b))
+
+	for len(a) > 0 || len(b) > 0 {
+		var next discord.Message
+
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0].ID >= b[0].ID):
+			next, a = a[0], a[1:]
+		default:
+			next, b = b[0], b[1:]
+		}
+
+		if n := len(merged); n > 0 && merged[n-1].ID == next.ID {
+			continue
+		}
+
+		merged = append(merged, next)
+	}
+
+	if len(merged) > Limit {
+		merged = merged[:Limit]
+	}
+
+	return merged
+}
+
+func containsID(ids []discord.MessageID, id discord.MessageID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
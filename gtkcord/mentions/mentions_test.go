@@ -0,0 +1,42 @@
+package mentions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+func messages(ids ...discord.MessageID) []discord.Message {
+	msgs := make([]discord.Message, len(ids))
+	for i, id := range ids {
+		msgs[i].ID = id
+	}
+	return msgs
+}
+
+func messageIDs(msgs []discord.Message) []discord.MessageID {
+	ids := make([]discord.MessageID, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+func TestMerge(t *testing.T) {
+	got := messageIDs(merge(messages(9, 5, 2), messages(7, 5, 1)))
+	want := []discord.MessageID{9, 7, 5, 2, 1}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	many := make([]discord.MessageID, Limit+10)
+	for i := range many {
+		many[i] = discord.MessageID(len(many) - i)
+	}
+
+	if merged := merge(messages(many...), nil); len(merged) != Limit {
+		t.Errorf("got %d messages, want %d", len(merged), Limit)
+	}
+}
@@ -0,0 +1,251 @@
+package gtkcord
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/ack"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/channel"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+const (
+	AccelPreviousChannel = "<gtkcord>/navigate.PreviousChannel"
+	AccelNextChannel     = "<gtkcord>/navigate.NextChannel"
+	AccelPreviousUnread  = "<gtkcord>/navigate.PreviousUnread"
+	AccelNextUnread      = "<gtkcord>/navigate.NextUnread"
+	AccelPreviousGuild   = "<gtkcord>/navigate.PreviousGuild"
+	AccelNextGuild       = "<gtkcord>/navigate.NextGuild"
+	AccelLatestMention   = "<gtkcord>/navigate.LatestMention"
+)
+
+var navigateAccelOnce sync.Once
+
+// bindNavigation binds the shortcuts that move between channels and guilds.
+func (a *Application) bindNavigation() {
+	navigateAccelOnce.Do(func() {
+		gtk.AccelMapAddEntry(AccelPreviousChannel, gdk.KEY_Up, gdk.Mod1Mask)
+		gtk.AccelMapAddEntry(AccelNextChannel, gdk.KEY_Down, gdk.Mod1Mask)
+		gtk.AccelMapAddEntry(AccelPreviousUnread, gdk.KEY_Up, gdk.Mod1Mask|gdk.ShiftMask)
+		gtk.AccelMapAddEntry(AccelNextUnread, gdk.KEY_Down, gdk.Mod1Mask|gdk.ShiftMask)
+		gtk.AccelMapAddEntry(AccelPreviousGuild, gdk.KEY_Up, gdk.ControlMask|gdk.Mod1Mask)
+		gtk.AccelMapAddEntry(AccelNextGuild, gdk.KEY_Down, gdk.ControlMask|gdk.Mod1Mask)
+		gtk.AccelMapAddEntry(AccelLatestMention, gdk.KEY_M, gdk.ControlMask|gdk.ShiftMask)
+	})
+
+	accel := window.Window.Accel
+	accel.ConnectByPath(AccelPreviousChannel, func() { a.moveChannel(-1) })
+	accel.ConnectByPath(AccelNextChannel, func() { a.moveChannel(1) })
+	accel.ConnectByPath(AccelPreviousUnread, func() { a.moveUnread(-1) })
+	accel.ConnectByPath(AccelNextUnread, func() { a.moveUnread(1) })
+	accel.ConnectByPath(AccelPreviousGuild, func() { a.moveGuild(-1) })
+	accel.ConnectByPath(AccelNextGuild, func() { a.moveGuild(1) })
+	accel.ConnectByPath(AccelLatestMention, a.openLatestMention)
+}
+
+// moveChannel opens the channel that's listed before or after the current one
+// in the sidebar. Categories, voice channels and collapsed channels are
+// skipped.
+func (a *Application) moveChannel(dir int) {
+	var list *gtk.ListBox
+	var rows []*gtk.ListBoxRow
+
+	switch {
+	case a.leftIsGuild():
+		list = a.Channels.ChList
+		for _, ch := range a.Channels.Channels {
+			if !ch.Category && ch.Participants == nil && ch.Row.Visible() {
+				rows = append(rows, ch.Row)
+			}
+		}
+	case a.leftIsDM():
+		list = a.Privates.List
+		for i := 0; ; i++ {
+			row := a.Privates.List.RowAtIndex(i)
+			if row == nil {
+				break
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		return
+	}
+
+	current := -1
+	for i, row := range rows {
+		if row.IsSelected() {
+			current = i
+			break
+		}
+	}
+
+	row := rows[wrapIndex(current, dir, len(rows))]
+	list.SelectRow(row)
+	row.Activate()
+}
+
+// moveUnread opens the unread channel that's before or after the current one,
+// going through the direct messages and then every guild in the sidebar order.
+func (a *Application) moveUnread(dir int) {
+	channels := a.sidebarChannels()
+	if len(channels) == 0 {
+		return
+	}
+
+	current := -1
+	for i, ch := range channels {
+		if ch.ID == a.ChannelID() {
+			current = i
+			break
+		}
+	}
+
+	i := current
+	for range channels {
+		i = wrapIndex(i, dir, len(channels))
+		if i == current {
+			return
+		}
+
+		if a.navigateUnread(channels[i]) {
+			a.SwitchToID(channels[i].ID, channels[i].GuildID)
+			return
+		}
+	}
+}
+
+// moveGuild opens the guild before or after the current one. The direct
+// messages are above the first guild.
+func (a *Application) moveGuild(dir int) {
+	// 0 is the direct messages.
+	guildIDs := append([]discord.GuildID{0}, a.Guilds.GuildIDs()...)
+
+	current := 0
+	if a.leftIsGuild() {
+		for i, id := range guildIDs {
+			if id == a.Channels.GuildID {
+				current = i
+				break
+			}
+		}
+	}
+
+	next := guildIDs[wrapIndex(current, dir, len(guildIDs))]
+	if !next.IsValid() {
+		a.Guilds.ListBox.SelectRow(a.Guilds.DMButton.ListBoxRow)
+		a.Guilds.DMButton.Activate()
+		return
+	}
+
+	g, folder := a.Guilds.FindByID(next)
+	if g == nil {
+		return
+	}
+
+	if folder != nil {
+		folder.List.SelectRow(g.ListBoxRow)
+	} else {
+		a.Guilds.ListBox.SelectRow(g.ListBoxRow)
+	}
+
+	g.Activate()
+}
+
+// openLatestMention opens the channel with the newest message that mentions
+// the user.
+func (a *Application) openLatestMention() {
+	var latest *discord.Channel
+
+	channels := a.sidebarChannels()
+	for i, ch := range channels {
+		rs := a.State.ReadState.FindLast(ch.ID)
+		if rs == nil || rs.MentionCount == 0 || !ack.Unread(a.State, ch) {
+			continue
+		}
+
+		if latest == nil || ch.LastMessageID > latest.LastMessageID {
+			latest = &channels[i]
+		}
+	}
+
+	if latest != nil {
+		a.SwitchToID(latest.ID, latest.GuildID)
+	}
+}
+
+// navigateUnread returns true if the channel is unread, in which case it's
+// shown as unread in the sidebar. Muted channels only count if they mention
+// the user.
+func (a *Application) navigateUnread(ch discord.Channel) bool {
+	if !ack.Unread(a.State, ch) {
+		return false
+	}
+
+	muted := notifications.ChannelMuted(a.State, ch.ID) ||
+		(ch.GuildID.IsValid() && notifications.GuildMuted(a.State, ch.GuildID))
+	if !muted {
+		return true
+	}
+
+	rs := a.State.ReadState.FindLast(ch.ID)
+	return rs != nil && rs.MentionCount > 0
+}
+
+// sidebarChannels returns the channels that can be read, in the order of the
+// sidebar: direct messages from the newest, then each guild's channels with
+// their threads.
+func (a *Application) sidebarChannels() []discord.Channel {
+	channels, err := a.State.PrivateChannels()
+	if err != nil {
+		log.Errorln("failed to get private channels:", err)
+	}
+
+	sort.SliceStable(channels, func(i, j int) bool {
+		return channels[i].LastMessageID > channels[j].LastMessageID
+	})
+
+	threads := map[discord.ChannelID][]discord.Channel{}
+	for _, t := range a.Threads.Known() {
+		threads[t.ParentID()] = append(threads[t.ParentID()], t.Channel)
+	}
+
+	for _, guildID := range a.Guilds.GuildIDs() {
+		guildChannels, err := a.State.Channels(guildID)
+		if err != nil {
+			continue
+		}
+
+		guildChannels = channel.FilterChannels(a.State, guildChannels)
+
+		for _, ch := range channel.SortChannels(guildChannels) {
+			if ch.Type == discord.GuildCategory || channel.IsVoice(ch.Type) {
+				continue
+			}
+
+			channels = append(channels, ch)
+			channels = append(channels, threads[ch.ID]...)
+		}
+	}
+
+	return channels
+}
+
+// wrapIndex moves the index in the direction, wrapping around. An index of -1
+// means that nothing is selected, so the first or last one is returned.
+func wrapIndex(i, dir, length int) int {
+	if i < 0 {
+		if dir > 0 {
+			return 0
+		}
+		return length - 1
+	}
+
+	return ((i+dir)%length + length) % length
+}
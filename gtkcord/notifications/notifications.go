@@ -0,0 +1,138 @@
+// Package notifications decides which channels are muted and which messages
+// notify, following the guild settings that Discord syncs, and changes them.
+package notifications
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// ChannelMuted returns true if the channel or its category is muted. Timed
+// mutes that ended don't count.
+func ChannelMuted(s *ningen.State, chID discord.ChannelID) bool {
+	return s.MutedState.Channel(chID) || s.MutedState.Category(chID)
+}
+
+// GuildMuted returns true if the whole guild is muted.
+func GuildMuted(s *ningen.State, guildID discord.GuildID) bool {
+	return s.MutedState.Guild(guildID, false)
+}
+
+// ChannelLevel returns the notification level that the channel overrides,
+// which is GuildDefaults if it doesn't.
+func ChannelLevel(s *ningen.State, chID discord.ChannelID) gateway.UserNotification {
+	ch, err := s.Cabinet.Channel(chID)
+	if err != nil {
+		return gateway.GuildDefaults
+	}
+
+	for _, override := range s.MutedState.GuildSettings(ch.GuildID).ChannelOverrides {
+		if override.ChannelID == chID {
+			return override.Notifications
+		}
+	}
+
+	return gateway.GuildDefaults
+}
+
+// Level returns the notification level of the channel. The channel's own
+// setting wins over its category's, which wins over the guild's.
+func Level(s *ningen.State, chID discord.ChannelID) gateway.UserNotification {
+	ch, err := s.Cabinet.Channel(chID)
+	if err != nil {
+		return gateway.AllNotifications
+	}
+
+	if level := ChannelLevel(s, ch.ID); level != gateway.GuildDefaults {
+		return level
+	}
+
+	if ch.CategoryID.IsValid() {
+		if level := ChannelLevel(s, ch.CategoryID); level != gateway.GuildDefaults {
+			return level
+		}
+	}
+
+	level := s.MutedState.GuildSettings(ch.GuildID).Notifications
+	if level == gateway.GuildDefaults {
+		level = gateway.AllNotifications
+	}
+
+	return level
+}
+
+// Mentions returns true if the message mentions the current user, either
+// directly, with @everyone or @here, or with one of their roles. The guild can
+// suppress the last two.
+func Mentions(s *ningen.State, msg discord.Message) bool {
+	me, err := s.Me()
+	if err != nil {
+		return false
+	}
+
+	for _, user := range msg.Mentions {
+		if user.ID == me.ID {
+			return true
+		}
+	}
+
+	if !msg.GuildID.IsValid() {
+		return false
+	}
+
+	settings := s.MutedState.GuildSettings(msg.GuildID)
+
+	if msg.MentionEveryone && !settings.SuppressEveryone {
+		return true
+	}
+
+	if len(msg.MentionRoleIDs) == 0 || settings.SuppressRoles {
+		return false
+	}
+
+	member, err := s.Cabinet.Member(msg.GuildID, me.ID)
+	if err != nil {
+		return false
+	}
+
+	for _, mentioned := range msg.MentionRoleIDs {
+		for _, role := range member.RoleIDs {
+			if role == mentioned {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ShouldNotify returns true if the message should send a notification. Muted
+// channels and guilds never do.
+func ShouldNotify(s *ningen.State, msg discord.Message) bool {
+	if me, err := s.Me(); err != nil || msg.Author.ID == me.ID {
+		return false
+	}
+
+	if ChannelMuted(s, msg.ChannelID) {
+		return false
+	}
+
+	// Direct messages always notify.
+	if !msg.GuildID.IsValid() {
+		return true
+	}
+
+	if GuildMuted(s, msg.GuildID) {
+		return false
+	}
+
+	switch Level(s, msg.ChannelID) {
+	case gateway.AllNotifications:
+		return true
+	case gateway.NoNotifications:
+		return false
+	default:
+		return Mentions(s, msg)
+	}
+}
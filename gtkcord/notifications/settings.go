@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// Forever is the mute duration that lasts until it's unmuted.
+const Forever time.Duration = -1
+
+// MuteDuration is a choice in the mute menus.
+type MuteDuration struct {
+	Name     string
+	Duration time.Duration
+}
+
+// MuteDurations are the durations that Discord offers.
+var MuteDurations = []MuteDuration{
+	{"For 15 Minutes", 15 * time.Minute},
+	{"For 1 Hour", time.Hour},
+	{"For 8 Hours", 8 * time.Hour},
+	{"For 24 Hours", 24 * time.Hour},
+	{"Until I Turn It Back On", Forever},
+}
+
+type muteConfig struct {
+	// SelectedTimeWindow is in seconds, or -1 for forever.
+	SelectedTimeWindow int               `json:"selected_time_window"`
+	EndTime            discord.Timestamp `json:"end_time"`
+}
+
+func newMuteConfig(d time.Duration) *muteConfig {
+	if d == Forever {
+		return &muteConfig{SelectedTimeWindow: -1}
+	}
+
+	return &muteConfig{
+		SelectedTimeWindow: int(d / time.Second),
+		EndTime:            discord.NewTimestamp(time.Now().Add(d)),
+	}
+}
+
+type channelOverrideData struct {
+	Muted         *bool                     `json:"muted,omitempty"`
+	MuteConfig    *muteConfig               `json:"mute_config,omitempty"`
+	Notifications *gateway.UserNotification `json:"message_notifications,omitempty"`
+}
+
+type guildSettingsData struct {
+	Muted            *bool                     `json:"muted,omitempty"`
+	MuteConfig       *muteConfig               `json:"mute_config,omitempty"`
+	Notifications    *gateway.UserNotification `json:"message_notifications,omitempty"`
+	SuppressEveryone *bool                     `json:"suppress_everyone,omitempty"`
+	SuppressRoles    *bool                     `json:"suppress_roles,omitempty"`
+
+	ChannelOverrides map[discord.ChannelID]channelOverrideData `json:"channel_overrides,omitempty"`
+}
+
+// modify changes the guild settings, then updates the state without waiting
+// for the gateway. The guild ID is 0 for direct messages.
+func modify(s *ningen.State, guildID discord.GuildID, data guildSettingsData) error {
+	id := "@me"
+	if guildID.IsValid() {
+		id = guildID.String()
+	}
+
+	var settings gateway.UserGuildSetting
+
+	err := s.Client.Client.RequestJSON(
+		&settings, "PATCH", api.EndpointMe+"/guilds/"+id+"/settings",
+		httputil.WithJSONBody(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.State.Handler.Call(&gateway.UserGuildSettingsUpdateEvent{UserGuildSetting: settings})
+	return nil
+}
+
+// MuteGuild mutes the guild for the duration, or unmutes it if the duration is
+// 0.
+func MuteGuild(s *ningen.State, guildID discord.GuildID, d time.Duration) error {
+	muted := d != 0
+
+	data := guildSettingsData{Muted: &muted}
+	if muted {
+		data.MuteConfig = newMuteConfig(d)
+	}
+
+	return modify(s, guildID, data)
+}
+
+// SetGuildLevel sets the notification level of the guild.
+func SetGuildLevel(s *ningen.State, guildID discord.GuildID, level gateway.UserNotification) error {
+	return modify(s, guildID, guildSettingsData{Notifications: &level})
+}
+
+// SetSuppressed sets whether @everyone, @here and role mentions are ignored in
+// the guild.
+func SetSuppressed(s *ningen.State, guildID discord.GuildID, everyone, roles bool) error {
+	return modify(s, guildID, guildSettingsData{
+		SuppressEveryone: &everyone,
+		SuppressRoles:    &roles,
+	})
+}
+
+// MuteChannel mutes the channel or category for the duration, or unmutes it if
+// the duration is 0.
+func MuteChannel(s *ningen.State, guildID discord.GuildID, chID discord.ChannelID, d time.Duration) error {
+	muted := d != 0
+
+	override := channelOverrideData{Muted: &muted}
+	if muted {
+		override.MuteConfig = newMuteConfig(d)
+	}
+
+	return modify(s, guildID, guildSettingsData{
+		ChannelOverrides: map[discord.ChannelID]channelOverrideData{chID: override},
+	})
+}
+
+// SetChannelLevel sets the notification level of the channel. GuildDefaults
+// makes it follow the guild again.
+func SetChannelLevel(
+	s *ningen.State,
+	guildID discord.GuildID, chID discord.ChannelID, level gateway.UserNotification) error {
+
+	return modify(s, guildID, guildSettingsData{
+		ChannelOverrides: map[discord.ChannelID]channelOverrideData{
+			chID: {Notifications: &level},
+		},
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils/gdbus"
 	"github.com/diamondburned/gtkcord3/gtkcord/md"
+	"github.com/diamondburned/gtkcord3/gtkcord/notifications"
 	"github.com/diamondburned/gtkcord3/internal/humanize"
 	"github.com/diamondburned/gtkcord3/internal/log"
 	"github.com/diamondburned/ningen/v2"
@@ -16,8 +17,8 @@ import (
 func (a *Application) bindNotifier() {
 	a.MPRIS.OnPlayback = a.onMPRISEvent
 	a.State.AddHandler(func(create *gateway.MessageCreateEvent) {
-		// Check if the message should trigger a mention.
-		if !a.State.MessageMentions(create.Message) {
+		// Check if the message should trigger a notification.
+		if !notifications.ShouldNotify(a.State, create.Message) {
 			return
 		}
 
@@ -38,10 +39,13 @@ func (a *Application) onMessageCreate(create *gateway.MessageCreateEvent) {
 		}
 
 		title := state.AuthorDisplayName(create)
-		if !create.GuildID.IsValid() {
+		switch {
+		case !create.GuildID.IsValid():
 			title += " sent you a message"
-		} else {
+		case notifications.Mentions(state, create.Message):
 			title += " mentioned you"
+		default:
+			title += " sent a message"
 		}
 
 		markup := md.ParseToSimpleMarkupWithMessage(
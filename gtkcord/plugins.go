@@ -4,32 +4,188 @@ import (
 	"os"
 	"path/filepath"
 	"plugin"
+	"strings"
 
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4-handy/pkg/handy"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/message"
 	"github.com/diamondburned/gtkcord3/gtkcord/config"
+	"github.com/diamondburned/gtkcord3/gtkcord/rpcplugin"
 	"github.com/diamondburned/gtkcord3/internal/log"
 	"github.com/pkg/errors"
 )
 
-// TODO add functions in here to have plugins handle
+// PluginAPIVersion is the version of the plugin API. A plugin must export an
+// APIVersion variable with this exact value, since Go plugins have no stable
+// ABI.
+//
+// A plugin exports the following symbols:
+//
+//	var APIVersion = gtkcord.PluginAPIVersion
+//	var Name = "Plugin name"    // optional
+//	var Author = "Plugin author" // optional
+//	func Hooks() gtkcord.PluginHooks
+const PluginAPIVersion = 2
+
+// PluginHooks are the hooks that a plugin implements. All fields are optional.
+// Unless stated otherwise, hooks are called in the main thread.
+type PluginHooks struct {
+	// Ready is called once the application is ready.
+	Ready func(a *Application)
+	// PreSend transforms the content of a message before it is sent. Returning
+	// an empty string cancels sending.
+	PreSend func(chID discord.ChannelID, content string) string
+	// Decorate is called after a message widget is created. Use
+	// (*message.Message).AddDecoration to add widgets.
+	Decorate func(msg *message.Message)
+	// MessageMenu is called when a message's context menu is opened.
+	MessageMenu func(msg *message.Message, menu *gtk.Menu)
+	// Commands are local slash commands, which are never sent to Discord.
+	Commands []PluginCommand
+	// Preferences returns a page that's added into the preferences window.
+	Preferences func() *handy.PreferencesPage
+	// Shutdown is called before the application exits.
+	Shutdown func()
+}
+
+// PluginCommand is a local slash command, such as /shrug.
+type PluginCommand struct {
+	Name        string // without the slash
+	Description string
+	// Run is called with everything after the command name. The returned
+	// string, if not empty, is sent as a message.
+	Run func(chID discord.ChannelID, args string) (string, error)
+}
 
 type Plugin struct {
 	// Plugin constants/functions
-	Name    string // optional
-	Author  string // optional
-	OnReady func(a *Application)
+	Name   string // optional
+	Author string // optional
+	Hooks  PluginHooks
 
 	// Auto-detected
 	Path string
 	Err  error
+
+	// Disabled plugins are listed but never loaded or started, and their
+	// hooks aren't called.
+	Disabled bool
+
+	// External is true if the plugin is an executable run out-of-process.
+	// Process is started once the application is ready.
+	External bool
+	Process  *rpcplugin.Process
+}
+
+// MainThread runs f in the main thread. It is safe to call from any
+// goroutine, and it does not wait for f to finish. Plugins must use this to
+// touch any widget from a goroutine.
+func MainThread(f func()) {
+	glib.IdleAdd(f)
 }
 
 func (a *Application) readyPlugins() {
+	a.Messages.Hooks = message.Hooks{
+		PreSend: a.pluginPreSend,
+		Render:  a.pluginDecorate,
+		Menu:    a.pluginMessageMenu,
+	}
+
+	for _, plugin := range a.Plugins {
+		if plugin.Err == nil && !plugin.Disabled && plugin.Hooks.Ready != nil {
+			plugin.Hooks.Ready(a)
+		}
+	}
+
+	a.startExternalPlugins()
+}
+
+func (a *Application) shutdownPlugins() {
+	for _, plugin := range a.Plugins {
+		if plugin.Err == nil && plugin.Hooks.Shutdown != nil {
+			plugin.Hooks.Shutdown()
+		}
+	}
+
+	a.stopExternalPlugins()
+}
+
+func (a *Application) pluginPreSend(chID discord.ChannelID, content string) (string, bool) {
+	if strings.HasPrefix(content, "/") {
+		name, args := splitCommand(content)
+
+		if cmd := a.findCommand(name); cmd != nil {
+			send, err := cmd.Run(chID, args)
+			if err != nil {
+				log.Errorln("command /"+name+" failed:", err)
+				return "", false
+			}
+			if send == "" {
+				return "", false
+			}
+			content = send
+		}
+	}
+
+	for _, plugin := range a.Plugins {
+		if plugin.Err != nil || plugin.Disabled || plugin.Hooks.PreSend == nil {
+			continue
+		}
+
+		if content = plugin.Hooks.PreSend(chID, content); content == "" {
+			return "", false
+		}
+	}
+
+	return content, true
+}
+
+func (a *Application) pluginDecorate(msg *message.Message) {
+	for _, plugin := range a.Plugins {
+		if plugin.Err == nil && !plugin.Disabled && plugin.Hooks.Decorate != nil {
+			plugin.Hooks.Decorate(msg)
+		}
+	}
+}
+
+func (a *Application) pluginMessageMenu(msg *message.Message, menu *gtk.Menu) {
+	for _, plugin := range a.Plugins {
+		if plugin.Err == nil && !plugin.Disabled && plugin.Hooks.MessageMenu != nil {
+			plugin.Hooks.MessageMenu(msg, menu)
+		}
+	}
+}
+
+// findCommand finds the command with the given name. The first plugin wins if
+// more than one plugin has the same command.
+func (a *Application) findCommand(name string) *PluginCommand {
 	for _, plugin := range a.Plugins {
-		if plugin.Err != nil {
+		if plugin.Err != nil || plugin.Disabled {
 			continue
 		}
-		plugin.OnReady(a)
+
+		for i, cmd := range plugin.Hooks.Commands {
+			if cmd.Name == name && cmd.Run != nil {
+				return &plugin.Hooks.Commands[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitCommand splits "/name args" into the name and the arguments.
+func splitCommand(content string) (name, args string) {
+	content = strings.TrimPrefix(content, "/")
+
+	parts := strings.SplitN(content, " ", 2)
+	if len(parts) == 2 {
+		return parts[0], strings.TrimSpace(parts[1])
 	}
+
+	return parts[0], ""
 }
 
 func (a *Application) removePlugin(path string) bool {
@@ -37,6 +193,7 @@ func (a *Application) removePlugin(path string) bool {
 		if plugin.Path == path {
 			// Remove from list
 			a.Plugins = append(a.Plugins[:i], a.Plugins[i+1:]...)
+			a.stopExternalPlugin(plugin)
 
 			// Remove from the filesystem:
 			if err := os.Remove(path); err != nil {
@@ -50,7 +207,9 @@ func (a *Application) removePlugin(path string) bool {
 	return false
 }
 
-func loadPlugins() ([]*Plugin, error) {
+// loadPlugins loads all plugins in the plugins directory. Plugins whose file
+// names are in disabled are listed but not loaded.
+func loadPlugins(disabled []string) ([]*Plugin, error) {
 	files, path, err := config.MustRead("plugins")
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to read plugins")
@@ -63,51 +222,100 @@ func loadPlugins() ([]*Plugin, error) {
 			continue // skip because it's a folder
 		}
 
-		p := loadPlugin(filepath.Join(path, f.Name()))
+		p := newPlugin(filepath.Join(path, f.Name()), isExternalPlugin(f), disabled)
 		plugins = append(plugins, p)
 	}
 
 	return plugins, nil
 }
 
+// newPlugin makes a plugin from the file at path. Only Go plugins are loaded
+// here; external plugins are started once the application is ready.
+func newPlugin(path string, external bool, disabled []string) *Plugin {
+	name := filepath.Base(path)
+
+	for _, d := range disabled {
+		if d == name {
+			return &Plugin{Name: name, Path: path, External: external, Disabled: true}
+		}
+	}
+
+	if external {
+		return &Plugin{Name: name, Path: path, External: true}
+	}
+
+	return loadPlugin(path)
+}
+
 func loadPlugin(path string) *Plugin {
 	p, err := plugin.Open(path)
 	if err != nil {
 		return newErrPlugin(path, err)
 	}
 
-	s, err := p.Lookup("Ready")
+	if err := checkAPIVersion(p); err != nil {
+		return newErrPlugin(path, err)
+	}
+
+	s, err := p.Lookup("Hooks")
 	if err != nil {
 		return newErrPlugin(path, err)
 	}
 
-	rd, ok := s.(func(*Application))
+	hooks, ok := s.(func() PluginHooks)
 	if !ok {
-		return newErrPlugin(path, errors.New("Ready() is not func(*gtkcord.Application)"))
+		return newErrPlugin(path, errors.New("Hooks() is not func() gtkcord.PluginHooks"))
 	}
 
 	plugin := &Plugin{
-		Path:    path,
-		OnReady: rd,
-		Name:    filepath.Base(path),
+		Path:  path,
+		Hooks: hooks(),
+		Name:  filepath.Base(path),
 	}
 
 	// Everything beyond is optional
 	if v, err := p.Lookup("Name"); err == nil {
-		if name, ok := v.(string); ok {
-			plugin.Name = name
+		if name, ok := v.(*string); ok {
+			plugin.Name = *name
 		}
 	}
 
 	if v, err := p.Lookup("Author"); err == nil {
-		if auth, ok := v.(string); ok {
-			plugin.Author = auth
+		if auth, ok := v.(*string); ok {
+			plugin.Author = *auth
 		}
 	}
 
 	return plugin
 }
 
+func checkAPIVersion(p *plugin.Plugin) error {
+	v, err := p.Lookup("APIVersion")
+	if err != nil {
+		if _, err := p.Lookup("Ready"); err == nil {
+			return errors.Errorf(
+				"plugin was written for API v1, which is unsupported; update it to API v%d",
+				PluginAPIVersion,
+			)
+		}
+		return errors.New("plugin does not export APIVersion")
+	}
+
+	version, ok := v.(*int)
+	if !ok {
+		return errors.New("APIVersion is not an int")
+	}
+
+	if *version != PluginAPIVersion {
+		return errors.Errorf(
+			"plugin uses API v%d, but gtkcord needs API v%d",
+			*version, PluginAPIVersion,
+		)
+	}
+
+	return nil
+}
+
 func newErrPlugin(path string, err error) *Plugin {
 	return &Plugin{
 		Name: filepath.Base(path),
@@ -0,0 +1,179 @@
+package gtkcord
+
+import (
+	"html"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gtkcord3/gtkcord/gtkutils/gdbus"
+	"github.com/diamondburned/gtkcord3/gtkcord/rpcplugin"
+	"github.com/diamondburned/gtkcord3/internal/eventname"
+	"github.com/diamondburned/ningen/v2"
+	"github.com/pkg/errors"
+)
+
+// isExternalPlugin returns true if the file is an executable that should be
+// run as an out-of-process plugin. Go plugins must have the .so extension,
+// which is what go build -buildmode=plugin outputs.
+func isExternalPlugin(info os.FileInfo) bool {
+	return !strings.HasSuffix(info.Name(), ".so") && info.Mode()&0111 != 0
+}
+
+// externalPlugins is the list of running out-of-process plugins. It has its
+// own lock, since the gateway handler and the plugins' calls read it from
+// other goroutines.
+type externalPlugins struct {
+	mu    sync.Mutex
+	procs []*rpcplugin.Process
+	// state is a copy of Application.State for the plugins' calls.
+	state *ningen.State
+
+	// unbind removes the gateway handler. It's only used in the main thread.
+	unbind func()
+}
+
+func (e *externalPlugins) add(proc *rpcplugin.Process) {
+	e.mu.Lock()
+	e.procs = append(e.procs, proc)
+	e.mu.Unlock()
+}
+
+func (e *externalPlugins) remove(proc *rpcplugin.Process) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, p := range e.procs {
+		if p == proc {
+			e.procs = append(e.procs[:i], e.procs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (e *externalPlugins) setState(s *ningen.State) {
+	e.mu.Lock()
+	e.state = s
+	e.mu.Unlock()
+}
+
+func (e *externalPlugins) getState() *ningen.State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.state
+}
+
+func (e *externalPlugins) list() []*rpcplugin.Process {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]*rpcplugin.Process(nil), e.procs...)
+}
+
+// startExternalPlugins starts all enabled out-of-process plugins and forwards
+// gateway events to them. The handler from the last call is removed, since
+// this is called on every Ready.
+func (a *Application) startExternalPlugins() {
+	for _, plugin := range a.Plugins {
+		a.startExternalPlugin(plugin)
+	}
+
+	if a.external.unbind != nil {
+		a.external.unbind()
+	}
+
+	a.external.unbind = a.State.AddHandler(func(ev interface{}) {
+		name, ok := eventname.Of(ev)
+		if !ok {
+			return
+		}
+
+		for _, proc := range a.external.list() {
+			proc.Dispatch(name, ev)
+		}
+	})
+}
+
+// startExternalPlugin starts the plugin if it's an enabled out-of-process
+// plugin that isn't running yet.
+func (a *Application) startExternalPlugin(plugin *Plugin) {
+	if !plugin.External || plugin.Disabled || plugin.Process != nil {
+		return
+	}
+
+	plugin.Process = rpcplugin.Start(plugin.Path, rpcActions{a})
+	a.external.add(plugin.Process)
+}
+
+// stopExternalPlugin stops the plugin in the background if it's running.
+func (a *Application) stopExternalPlugin(plugin *Plugin) {
+	if plugin.Process == nil {
+		return
+	}
+
+	proc := plugin.Process
+	plugin.Process = nil
+
+	a.external.remove(proc)
+	go proc.Stop()
+}
+
+// stopExternalPlugins stops all out-of-process plugins in parallel.
+func (a *Application) stopExternalPlugins() {
+	var wg sync.WaitGroup
+
+	for _, proc := range a.external.list() {
+		wg.Add(1)
+		go func(proc *rpcplugin.Process) {
+			proc.Stop()
+			wg.Done()
+		}(proc)
+	}
+
+	wg.Wait()
+}
+
+// rpcActions implements the actions that out-of-process plugins may call.
+type rpcActions struct {
+	a *Application
+}
+
+func (r rpcActions) state() (*ningen.State, error) {
+	if s := r.a.external.getState(); s != nil {
+		return s, nil
+	}
+	return nil, errors.New("not logged in")
+}
+
+func (r rpcActions) SendMessage(chID discord.ChannelID, content string) error {
+	s, err := r.state()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.SendText(chID, content)
+	return err
+}
+
+func (r rpcActions) AddReaction(
+	chID discord.ChannelID, msgID discord.MessageID, emoji discord.APIEmoji) error {
+
+	s, err := r.state()
+	if err != nil {
+		return err
+	}
+
+	return s.React(chID, msgID, emoji)
+}
+
+func (r rpcActions) Notify(title, body string) error {
+	_, err := r.a.Notifier.Notify(gdbus.Notification{
+		AppName: "gtkcord3",
+		AppIcon: "application-x-addon",
+		Title:   title,
+		Message: html.EscapeString(body),
+	})
+	return err
+}
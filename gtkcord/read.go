@@ -0,0 +1,57 @@
+package gtkcord
+
+import (
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/gdk/v3"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/ack"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
+	"github.com/diamondburned/gtkcord3/internal/log"
+)
+
+const AccelMarkAllRead = "<gtkcord>/read.MarkAllRead"
+
+var markReadAccelOnce sync.Once
+
+// bindMarkRead binds the shortcut that marks everything as read.
+func (a *Application) bindMarkRead() {
+	markReadAccelOnce.Do(func() {
+		gtk.AccelMapAddEntry(AccelMarkAllRead, gdk.KEY_Escape, gdk.ShiftMask)
+	})
+
+	window.Window.Accel.ConnectByPath(AccelMarkAllRead, a.MarkAllRead)
+}
+
+// MarkGuildRead marks every channel and thread in the guild as read.
+func (a *Application) MarkGuildRead(guildID discord.GuildID) {
+	go func() {
+		active, err := a.Threads.Active(guildID)
+		if err != nil {
+			log.Errorln("failed to get active threads:", err)
+		}
+
+		if err := ack.Guild(a.State, guildID, threadChannels(active)...); err != nil {
+			log.Errorln("failed to mark guild as read:", err)
+		}
+	}()
+}
+
+// MarkAllRead marks every direct message and every guild as read.
+func (a *Application) MarkAllRead() {
+	go func() {
+		if err := ack.All(a.State, threadChannels(a.Threads.Known())...); err != nil {
+			log.Errorln("failed to mark everything as read:", err)
+		}
+	}()
+}
+
+func threadChannels(list []threads.Thread) []discord.Channel {
+	channels := make([]discord.Channel, len(list))
+	for i, thread := range list {
+		channels[i] = thread.Channel
+	}
+	return channels
+}
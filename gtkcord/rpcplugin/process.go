@@ -0,0 +1,433 @@
+// Package rpcplugin runs out-of-process plugins. A plugin is any executable
+// that speaks newline-delimited JSON-RPC 2.0 over its stdin and stdout; its
+// stderr is logged.
+//
+// gtkcord first sends an initialize request, to which the plugin replies with
+// its name and the gateway events it wants. Events are then sent as
+// notifications, and the plugin may call a small set of actions back. A plugin
+// that exits is restarted with a backoff.
+package rpcplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+// Actions are what plugins are allowed to do. Methods may be called from any
+// goroutine.
+type Actions interface {
+	SendMessage(chID discord.ChannelID, content string) error
+	AddReaction(chID discord.ChannelID, msgID discord.MessageID, emoji discord.APIEmoji) error
+	Notify(title, body string) error
+}
+
+var (
+	// InitTimeout is how long a plugin has to reply to initialize.
+	InitTimeout = 10 * time.Second
+	// StopTimeout is how long a plugin has to exit after its stdin is closed
+	// before it's killed.
+	StopTimeout = 2 * time.Second
+
+	// RestartDelay is the first delay before a crashed plugin is restarted.
+	// It doubles on every crash up to MaxRestartDelay, and it's reset once the
+	// plugin stays up for StableAfter.
+	RestartDelay    = time.Second
+	MaxRestartDelay = time.Minute
+	StableAfter     = time.Minute
+
+	// EventQueueSize is how many events may wait to be sent to a plugin.
+	// Events are dropped while the queue is full, so that a plugin that
+	// doesn't read them can't block the gateway.
+	EventQueueSize = 256
+)
+
+// Process is a running plugin.
+type Process struct {
+	Path string
+
+	actions Actions
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu       sync.Mutex
+	conn     *conn
+	queue    chan EventParams
+	info     InitializeResult
+	events   map[string]bool
+	err      error
+	restarts int
+}
+
+// Start starts the plugin at the given path and keeps it running until Stop is
+// called.
+func Start(path string, actions Actions) *Process {
+	p := &Process{
+		Path:    path,
+		actions: actions,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		info:    InitializeResult{Name: filepath.Base(path)},
+	}
+
+	go p.supervise()
+	return p
+}
+
+// Info returns what the plugin replied to initialize with. The name defaults
+// to the file name until then.
+func (p *Process) Info() InitializeResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.info
+}
+
+// Running returns true if the plugin is initialized and running.
+func (p *Process) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.conn != nil
+}
+
+// Err returns the error that the plugin last exited with, if any.
+func (p *Process) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// Restarts returns the number of times the plugin was restarted.
+func (p *Process) Restarts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.restarts
+}
+
+// Subscribed returns true if the plugin wants the given event.
+func (p *Process) Subscribed(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.events[name]
+}
+
+// Dispatch queues the event for the plugin if it's running and subscribed to
+// it. It doesn't block; the event is dropped if the queue is full.
+func (p *Process) Dispatch(name string, ev interface{}) {
+	p.mu.Lock()
+	queue := p.queue
+	subscribed := p.events[name]
+	p.mu.Unlock()
+
+	if queue == nil || !subscribed {
+		return
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		log.Errorln("failed to marshal event for plugin:", err)
+		return
+	}
+
+	select {
+	case queue <- EventParams{Name: name, Data: b}:
+	default:
+		log.Debugln("plugin", p.Path, "is behind, dropping", name)
+	}
+}
+
+// sendEvents sends the queued events to the plugin until done is closed.
+func (p *Process) sendEvents(c *conn, queue <-chan EventParams, done <-chan struct{}) {
+	for {
+		select {
+		case ev := <-queue:
+			if err := c.notify(MethodEvent, ev); err != nil {
+				log.Errorln("failed to send event to plugin", p.Path+":", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// Stop stops the plugin and waits for it to exit. It must only be called once.
+func (p *Process) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *Process) supervise() {
+	defer close(p.done)
+
+	delay := RestartDelay
+
+	for {
+		started := time.Now()
+		err := p.run()
+
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		if err == nil {
+			err = errors.New("plugin exited")
+		}
+
+		log.Errorln("plugin", p.Path, "stopped, restarting in", delay.String()+":", err)
+
+		p.mu.Lock()
+		p.err = err
+		p.restarts++
+		p.mu.Unlock()
+
+		if time.Since(started) > StableAfter {
+			delay = RestartDelay
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > MaxRestartDelay {
+			delay = MaxRestartDelay
+		}
+	}
+}
+
+// run runs the plugin once and blocks until it exits or Stop is called.
+func (p *Process) run() error {
+	cmd := exec.Command(p.Path)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "failed to get stdin")
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "failed to get stdout")
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.Wrap(err, "failed to get stderr")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "failed to start")
+	}
+
+	go p.logStderr(stderr)
+
+	c := newConn(stdout, stdin)
+
+	readErr := make(chan error, 1)
+	go func() { readErr <- c.readLoop(func(msg *message) { go p.handle(c, msg) }) }()
+
+	// Wait for the plugin to exit in the background, so that we can stop
+	// waiting on it if Stop is called.
+	exited := make(chan error, 1)
+	go func() {
+		err := <-readErr
+		if werr := cmd.Wait(); werr != nil {
+			err = werr
+		}
+		exited <- err
+	}()
+
+	if err := p.initialize(c); err != nil {
+		cmd.Process.Kill()
+		<-exited
+		return err
+	}
+
+	queue := make(chan EventParams, EventQueueSize)
+	sending := make(chan struct{})
+	defer close(sending)
+
+	go p.sendEvents(c, queue, sending)
+
+	p.mu.Lock()
+	p.conn = c
+	p.queue = queue
+	p.err = nil
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.conn = nil
+		p.queue = nil
+		p.mu.Unlock()
+	}()
+
+	select {
+	case err := <-exited:
+		return err
+	case <-p.stop:
+	}
+
+	// Ask the plugin to exit nicely first. Don't wait for long, since its
+	// stdin might be full.
+	shutdown := make(chan struct{})
+	go func() {
+		c.notify(MethodShutdown, struct{}{})
+		close(shutdown)
+	}()
+
+	select {
+	case <-shutdown:
+	case <-time.After(StopTimeout):
+	}
+
+	stdin.Close()
+
+	select {
+	case <-exited:
+	case <-time.After(StopTimeout):
+		cmd.Process.Kill()
+		<-exited
+	}
+
+	return nil
+}
+
+func (p *Process) initialize(c *conn) error {
+	ch, err := c.call(MethodInitialize, InitializeParams{APIVersion: APIVersion})
+	if err != nil {
+		return errors.Wrap(err, "failed to send initialize")
+	}
+
+	var resp *message
+
+	select {
+	case resp = <-ch:
+	case <-time.After(InitTimeout):
+		return errors.New("timed out waiting for initialize")
+	}
+
+	if resp == nil {
+		return errors.New("plugin exited before initializing")
+	}
+	if resp.Error != nil {
+		return errors.Wrap(resp.Error, "initialize failed")
+	}
+
+	var result InitializeResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return errors.Wrap(err, "invalid initialize result")
+	}
+
+	events := make(map[string]bool, len(result.Events))
+	for _, ev := range result.Events {
+		events[ev] = true
+	}
+
+	p.mu.Lock()
+	if result.Name != "" {
+		p.info.Name = result.Name
+	}
+	p.info.Author = result.Author
+	p.info.Events = result.Events
+	p.events = events
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *Process) logStderr(r io.Reader) {
+	name := filepath.Base(p.Path)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		log.Println("plugin " + name + ": " + scanner.Text())
+	}
+}
+
+// handle handles a request or notification from the plugin.
+func (p *Process) handle(c *conn, msg *message) {
+	result, rerr := p.call(msg.Method, msg.Params)
+
+	// Notifications don't get a response.
+	if len(msg.ID) == 0 {
+		if rerr != nil {
+			log.Errorln("plugin", p.Path, "notification failed:", rerr)
+		}
+		return
+	}
+
+	if err := c.reply(msg.ID, result, rerr); err != nil {
+		log.Errorln("failed to reply to plugin", p.Path+":", err)
+	}
+}
+
+func (p *Process) call(method string, params json.RawMessage) (interface{}, *Error) {
+	switch method {
+	case MethodSendMessage:
+		var args SendMessageParams
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+
+		chID, err := discord.ParseSnowflake(args.ChannelID)
+		if err != nil {
+			return nil, &Error{CodeInvalidParams, "invalid channel_id"}
+		}
+
+		return done(p.actions.SendMessage(discord.ChannelID(chID), args.Content))
+
+	case MethodAddReaction:
+		var args AddReactionParams
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+
+		chID, err := discord.ParseSnowflake(args.ChannelID)
+		if err != nil {
+			return nil, &Error{CodeInvalidParams, "invalid channel_id"}
+		}
+
+		msgID, err := discord.ParseSnowflake(args.MessageID)
+		if err != nil {
+			return nil, &Error{CodeInvalidParams, "invalid message_id"}
+		}
+
+		return done(p.actions.AddReaction(
+			discord.ChannelID(chID), discord.MessageID(msgID), discord.APIEmoji(args.Emoji),
+		))
+
+	case MethodNotify:
+		var args NotifyParams
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+
+		return done(p.actions.Notify(args.Title, args.Body))
+
+	default:
+		return nil, &Error{CodeMethodNotFound, "method not allowed: " + method}
+	}
+}
+
+// done turns an action error into a JSON-RPC result.
+func done(err error) (interface{}, *Error) {
+	if err != nil {
+		return nil, &Error{CodeInternalError, err.Error()}
+	}
+	return true, nil
+}
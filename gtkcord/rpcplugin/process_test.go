@@ -0,0 +1,252 @@
+package rpcplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+)
+
+// fakePluginEnv makes the test binary act as a plugin instead of running the
+// tests. Its value is the fake plugin's mode.
+const fakePluginEnv = "GTKCORD_FAKE_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakePluginEnv); mode != "" {
+		fakePlugin(mode)
+		os.Exit(0)
+	}
+
+	RestartDelay = 10 * time.Millisecond
+	MaxRestartDelay = 50 * time.Millisecond
+	StopTimeout = time.Second
+
+	os.Exit(m.Run())
+}
+
+// fakePlugin is the plugin side of the tests. In "echo" mode, it echoes
+// MESSAGE_CREATE events back with send_message. In "crash" mode, it exits
+// right after initializing. In "forbidden" mode, it calls a method that isn't
+// whitelisted and reports the error code with notify. In "stuck" mode, it stops
+// reading after initializing.
+func fakePlugin(mode string) {
+	in := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+
+	nextID := 0
+	call := func(method string, params interface{}) {
+		nextID++
+		b, _ := json.Marshal(params)
+		out.Encode(message{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage(strconv.Itoa(nextID)),
+			Method:  method,
+			Params:  b,
+		})
+	}
+
+	for in.Scan() {
+		var msg message
+		if err := json.Unmarshal(in.Bytes(), &msg); err != nil {
+			fmt.Fprintln(os.Stderr, "bad message:", err)
+			os.Exit(2)
+		}
+
+		switch msg.Method {
+		case MethodInitialize:
+			b, _ := json.Marshal(InitializeResult{
+				Name:   "Fake",
+				Author: "Tests",
+				Events: []string{"MESSAGE_CREATE"},
+			})
+			out.Encode(message{JSONRPC: "2.0", ID: msg.ID, Result: b})
+
+			switch mode {
+			case "crash":
+				os.Exit(1)
+			case "forbidden":
+				call("delete_guild", struct{}{})
+			case "stuck":
+				select {}
+			}
+
+		case MethodEvent:
+			var ev EventParams
+			json.Unmarshal(msg.Params, &ev)
+
+			var create gateway.MessageCreateEvent
+			json.Unmarshal(ev.Data, &create)
+
+			call(MethodSendMessage, SendMessageParams{
+				ChannelID: create.ChannelID.String(),
+				Content:   "echo: " + create.Content,
+			})
+
+		case MethodShutdown:
+			return
+
+		case "":
+			// A response to one of our calls.
+			if mode == "forbidden" && msg.Error != nil {
+				call(MethodNotify, NotifyParams{Title: strconv.Itoa(msg.Error.Code)})
+			}
+		}
+	}
+}
+
+type fakeActions struct {
+	mu       sync.Mutex
+	sent     []string
+	notified []string
+}
+
+func (a *fakeActions) SendMessage(chID discord.ChannelID, content string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sent = append(a.sent, chID.String()+" "+content)
+	return nil
+}
+
+func (a *fakeActions) AddReaction(discord.ChannelID, discord.MessageID, discord.APIEmoji) error {
+	return nil
+}
+
+func (a *fakeActions) Notify(title, body string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.notified = append(a.notified, title)
+	return nil
+}
+
+// startFake starts the test binary as a plugin. A small script is used as the
+// plugin's executable, since plugins are started without arguments and with
+// gtkcord's environment.
+func startFake(t *testing.T, mode string, actions Actions) *Process {
+	t.Helper()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal("failed to get test executable:", err)
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\nexec env %s=%s %q\n", fakePluginEnv, mode, exe)
+	path := filepath.Join(t.TempDir(), "fake-plugin")
+
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal("failed to write fake plugin:", err)
+	}
+
+	p := Start(path, actions)
+	waitFor(t, "plugin to start", func() bool { return p.Running() || p.Restarts() > 0 })
+
+	return p
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProcessEvents(t *testing.T) {
+	actions := &fakeActions{}
+
+	p := startFake(t, "echo", actions)
+	defer p.Stop()
+
+	if info := p.Info(); info.Name != "Fake" || info.Author != "Tests" {
+		t.Fatalf("unexpected info: %#v", info)
+	}
+
+	// Not subscribed, so this must be dropped.
+	p.Dispatch("TYPING_START", &gateway.TypingStartEvent{})
+
+	ev := &gateway.MessageCreateEvent{}
+	ev.ChannelID = 1234
+	ev.Content = "hello"
+	p.Dispatch("MESSAGE_CREATE", ev)
+
+	waitFor(t, "send_message", func() bool {
+		actions.mu.Lock()
+		defer actions.mu.Unlock()
+		return len(actions.sent) > 0
+	})
+
+	actions.mu.Lock()
+	defer actions.mu.Unlock()
+
+	if len(actions.sent) != 1 || actions.sent[0] != "1234 echo: hello" {
+		t.Fatalf("unexpected messages sent: %q", actions.sent)
+	}
+}
+
+func TestProcessStuck(t *testing.T) {
+	p := startFake(t, "stuck", &fakeActions{})
+	defer p.Stop()
+
+	ev := &gateway.MessageCreateEvent{}
+	ev.Content = strings.Repeat("a", 64*1024)
+
+	// This would block once the pipe is full if events weren't queued.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < EventQueueSize*2; i++ {
+			p.Dispatch("MESSAGE_CREATE", ev)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out dispatching to a stuck plugin")
+	}
+}
+
+func TestProcessForbiddenMethod(t *testing.T) {
+	actions := &fakeActions{}
+
+	p := startFake(t, "forbidden", actions)
+	defer p.Stop()
+
+	waitFor(t, "notify", func() bool {
+		actions.mu.Lock()
+		defer actions.mu.Unlock()
+		return len(actions.notified) > 0
+	})
+
+	actions.mu.Lock()
+	defer actions.mu.Unlock()
+
+	if code := strconv.Itoa(CodeMethodNotFound); actions.notified[0] != code {
+		t.Fatalf("expected error code %s, got %q", code, actions.notified[0])
+	}
+}
+
+func TestProcessRestart(t *testing.T) {
+	p := startFake(t, "crash", &fakeActions{})
+	defer p.Stop()
+
+	waitFor(t, "restarts", func() bool { return p.Restarts() >= 2 })
+
+	if p.Err() == nil {
+		t.Fatal("expected the crash to be recorded")
+	}
+}
@@ -0,0 +1,231 @@
+package rpcplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// APIVersion is the version of the protocol, sent to the plugin on initialize.
+const APIVersion = 1
+
+// Methods called by gtkcord on the plugin.
+const (
+	// MethodInitialize is the first request sent to the plugin. Its params are
+	// InitializeParams, and the plugin replies with InitializeResult.
+	MethodInitialize = "initialize"
+	// MethodEvent is a notification with EventParams for each subscribed
+	// gateway event.
+	MethodEvent = "event"
+	// MethodShutdown is a notification sent before the plugin's stdin is
+	// closed.
+	MethodShutdown = "shutdown"
+)
+
+// Methods that the plugin may call on gtkcord.
+const (
+	MethodSendMessage = "send_message" // SendMessageParams
+	MethodAddReaction = "add_reaction" // AddReactionParams
+	MethodNotify      = "notify"       // NotifyParams
+)
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+type InitializeParams struct {
+	APIVersion int `json:"api_version"`
+}
+
+type InitializeResult struct {
+	Name   string `json:"name"`
+	Author string `json:"author,omitempty"`
+	// Events is the list of gateway event names to subscribe to, such as
+	// MESSAGE_CREATE.
+	Events []string `json:"events,omitempty"`
+}
+
+type EventParams struct {
+	Name string          `json:"name"`
+	Data json.RawMessage `json:"data"`
+}
+
+type SendMessageParams struct {
+	ChannelID string `json:"channel_id"`
+	Content   string `json:"content"`
+}
+
+type AddReactionParams struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	// Emoji is either a unicode emoji or name:id for custom emojis.
+	Emoji string `json:"emoji"`
+}
+
+type NotifyParams struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *Error) Error() string {
+	return "rpc error " + strconv.Itoa(err.Code) + ": " + err.Message
+}
+
+// message is any JSON-RPC 2.0 message. Requests have an ID and a Method,
+// notifications only have a Method, and responses only have an ID.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// conn is a newline-delimited JSON-RPC connection.
+type conn struct {
+	wmu sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+	r   *bufio.Scanner
+
+	pmu     sync.Mutex
+	pending map[string]chan *message
+	nextID  int64
+	closed  bool
+}
+
+// maxLineSize is the maximum size of a single message from the plugin.
+const maxLineSize = 16 * 1024 * 1024
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	return &conn{
+		w:       w,
+		enc:     json.NewEncoder(w),
+		r:       scanner,
+		pending: make(map[string]chan *message),
+	}
+}
+
+func (c *conn) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	return c.enc.Encode(msg)
+}
+
+// notify sends a notification, which has no response.
+func (c *conn) notify(method string, params interface{}) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal params")
+	}
+
+	return c.write(&message{Method: method, Params: b})
+}
+
+// call sends a request and returns a channel that receives the response. The
+// channel is closed without a value if the connection is closed first.
+func (c *conn) call(method string, params interface{}) (<-chan *message, error) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal params")
+	}
+
+	ch := make(chan *message, 1)
+
+	c.pmu.Lock()
+	if c.closed {
+		c.pmu.Unlock()
+		return nil, errors.New("connection closed")
+	}
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	c.pending[id] = ch
+	c.pmu.Unlock()
+
+	if err := c.write(&message{ID: json.RawMessage(id), Method: method, Params: b}); err != nil {
+		c.pmu.Lock()
+		delete(c.pending, id)
+		c.pmu.Unlock()
+		return nil, err
+	}
+
+	return ch, nil
+}
+
+// reply sends a response to the request with the given ID.
+func (c *conn) reply(id json.RawMessage, result interface{}, rerr *Error) error {
+	msg := message{ID: id, Error: rerr}
+
+	if rerr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal result")
+		}
+		msg.Result = b
+	}
+
+	return c.write(&msg)
+}
+
+// readLoop reads messages until the reader is closed. Responses are routed to
+// their callers, and requests and notifications are given to handle.
+func (c *conn) readLoop(handle func(*message)) error {
+	defer c.close()
+
+	for c.r.Scan() {
+		var msg message
+		if err := json.Unmarshal(c.r.Bytes(), &msg); err != nil {
+			c.reply(json.RawMessage("null"), nil, &Error{CodeParseError, err.Error()})
+			continue
+		}
+
+		if msg.Method == "" {
+			c.pmu.Lock()
+			ch, ok := c.pending[string(msg.ID)]
+			delete(c.pending, string(msg.ID))
+			c.pmu.Unlock()
+
+			if ok {
+				ch <- &msg
+			}
+			continue
+		}
+
+		handle(&msg)
+	}
+
+	return c.r.Err()
+}
+
+func (c *conn) close() {
+	c.pmu.Lock()
+	defer c.pmu.Unlock()
+
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
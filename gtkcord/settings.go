@@ -1,10 +1,11 @@
 package gtkcord
 
 import (
-	"strings"
-
 	"github.com/diamondburned/gotk4-handy/pkg/handy"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/downloads"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/message/extras"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/preferences"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
 	"github.com/diamondburned/gtkcord3/gtkcord/config"
@@ -41,6 +42,28 @@ type Settings struct {
 
 			// TODO: dark/light theme switch
 		} `json:"customization"`
+
+		Downloads struct {
+			*handy.PreferencesGroup `json:"-"`
+
+			Directory string `json:"directory"`
+			AlwaysAsk bool   `json:"always_ask"`
+		} `json:"downloads"`
+
+		Uploads struct {
+			*handy.PreferencesGroup `json:"-"`
+
+			AutoCompress  bool `json:"auto_compress"`
+			StripLocation bool `json:"strip_location"`
+			Quality       int  `json:"quality"`
+			MaxDimension  int  `json:"max_dimension"`
+		} `json:"uploads"`
+
+		Developer struct {
+			*handy.PreferencesGroup `json:"-"`
+
+			Inspector bool `json:"inspector"`
+		} `json:"developer"`
 	} `json:"general"`
 
 	Integrations struct {
@@ -52,12 +75,20 @@ type Settings struct {
 			MPRIS bool `json:"mpris"`
 			// RichPresence *bool `json:"rich_presence"`
 		} `json:"rich_presence"`
-
-		// Ignore plugins in config
-		Plugins struct {
-			*handy.PreferencesGroup `json:"-"`
-		} `json:"-"`
 	} `json:"integrations"`
+
+	Plugins struct {
+		*handy.PreferencesPage `json:"-"`
+		List                   *handy.PreferencesGroup `json:"-"`
+
+		// Disabled contains the file names of plugins that aren't loaded.
+		Disabled []string `json:"disabled"`
+	} `json:"plugins"`
+
+	// pluginRows and pluginPages are the rows and the preferences pages of
+	// plugins by path.
+	pluginRows  map[string]*handy.ExpanderRow
+	pluginPages map[string]gtk.Widgetter
 }
 
 func (s *Settings) initWidgets(a *Application) {
@@ -169,8 +200,103 @@ func (s *Settings) initWidgets(a *Application) {
 			))
 		}
 
+		{
+			g := &p.Downloads
+
+			g.PreferencesGroup = handy.NewPreferencesGroup()
+			g.PreferencesGroup.SetTitle("Downloads")
+
+			dir := gtk.NewFileChooserButton("Select Folder", gtk.FileChooserActionSelectFolder)
+			preferences.BindFileChooser(dir, &g.Directory, func() {
+				downloads.Directory = g.Directory
+			})
+			g.Add(preferences.Row(
+				"Download folder",
+				"Where attachments are saved.",
+				dir,
+			))
+
+			ask := gtk.NewSwitch()
+			preferences.BindSwitch(ask, &g.AlwaysAsk, func() {
+				downloads.AlwaysAsk = g.AlwaysAsk
+			})
+			g.Add(preferences.Row(
+				"Always ask where to save",
+				"Choose the file name and folder of every download.",
+				ask,
+			))
+		}
+
+		{
+			g := &p.Uploads
+
+			g.PreferencesGroup = handy.NewPreferencesGroup()
+			g.PreferencesGroup.SetTitle("Uploads")
+
+			auto := gtk.NewSwitch()
+			preferences.BindSwitch(auto, &g.AutoCompress, func() {
+				extras.AutoCompress = g.AutoCompress
+			})
+			g.Add(preferences.Row(
+				"Compress large images",
+				"Compress images when the files are over the upload limit.",
+				auto,
+			))
+
+			location := gtk.NewSwitch()
+			preferences.BindSwitch(location, &g.StripLocation, func() {
+				extras.DefaultImageOptions.StripLocation = g.StripLocation
+			})
+			g.Add(preferences.Row(
+				"Remove location from photos",
+				"Remove where a photo was taken from its metadata before uploading it.",
+				location,
+			))
+
+			quality := gtk.NewEntry()
+			preferences.BindNumberEntry(quality, &g.Quality, func() {
+				extras.DefaultImageOptions.Quality = extras.ClampQuality(g.Quality)
+			})
+			g.Add(preferences.Row(
+				"Compression quality",
+				"The quality of compressed images, from 1 to 100.",
+				quality,
+			))
+
+			maxDim := gtk.NewEntry()
+			preferences.BindNumberEntry(maxDim, &g.MaxDimension, func() {
+				extras.DefaultImageOptions.MaxDimension = g.MaxDimension
+			})
+			g.Add(preferences.Row(
+				"Maximum image size",
+				"Compressed images are scaled down to fit this many pixels, or 0 to keep their size.",
+				maxDim,
+			))
+		}
+
+		{
+			g := &p.Developer
+
+			g.PreferencesGroup = handy.NewPreferencesGroup()
+			g.PreferencesGroup.SetTitle("Developer")
+
+			insp := gtk.NewSwitch()
+			preferences.BindSwitch(insp, &g.Inspector, func() {
+				a.setInspector(g.Inspector)
+			})
+
+			g.Add(preferences.Row(
+				"Inspector",
+				"Record gateway events and API requests. Open with Ctrl+Shift+I.",
+				insp,
+			))
+		}
+
 		p.Add(p.Behavior)
 		p.Add(p.Customization)
+		p.Add(p.Downloads)
+		p.Add(p.Uploads)
+		p.Add(p.Developer)
 	}
 
 	{
@@ -199,48 +325,19 @@ func (s *Settings) initWidgets(a *Application) {
 			))
 		}
 
-		{
-			g := &p.Plugins
-
-			g.PreferencesGroup = handy.NewPreferencesGroup()
-			g.PreferencesGroup.SetTitle("Plugins")
-			g.PreferencesGroup.SetDescription("Plugins are read from ~/.config/gtkcord/plugins/")
-
-			// Render all plugins:
-			for i := 0; i < len(a.Plugins); i++ {
-				plugin := a.Plugins[i]
-
-				var desc = plugin.Author
-				if plugin.Err != nil {
-					err := strings.Split(plugin.Err.Error(), ": ")
-					desc = `<span color="red">` + err[len(err)-1] + `</span>`
-				}
-
-				remove := gtk.NewButtonFromIconName("user-trash-symbolic", int(gtk.IconSizeMenu))
-
-				row := preferences.Row(plugin.Name, desc, remove)
-				row.SetTooltipText(plugin.Path)
-				g.PreferencesGroup.Add(row)
-
-				preferences.BindButton(remove, func() {
-					// If the plugin is removed, remove it from the list too.
-					if a.removePlugin(plugin.Path) {
-						row.Destroy()
-					}
-				})
-			}
-		}
-
 		p.Add(p.RichPresence)
-		p.Add(p.Plugins)
 	}
 
+	s.initPluginsPage(a)
+
 	s.Add(s.General)
 	s.Add(s.Integrations)
+	s.Add(s.Plugins)
 
 	// Just for sure:
 	s.General.ShowAll()
 	s.Integrations.ShowAll()
+	s.Plugins.ShowAll()
 }
 
 func (a *Application) makeSettings() *Settings {
@@ -248,12 +345,25 @@ func (a *Application) makeSettings() *Settings {
 	s.General.Behavior.OnTyping = true
 	s.General.Customization.MessageWidth = 750
 	s.General.Customization.HighlightStyle = "monokai"
+	s.General.Downloads.Directory = glib.GetUserSpecialDir(glib.UserDirectoryDownload)
+	s.General.Uploads.AutoCompress = extras.AutoCompress
+	s.General.Uploads.StripLocation = extras.DefaultImageOptions.StripLocation
+	s.General.Uploads.Quality = extras.DefaultImageOptions.Quality
+	s.General.Uploads.MaxDimension = extras.DefaultImageOptions.MaxDimension
 	s.Integrations.RichPresence.MPRIS = true
 
 	if err := config.UnmarshalFromFile(SettingsFile, s); err != nil {
 		log.Errorln("Failed to load settings, using default. Error:", err)
 	}
 
+	// Plugins are loaded here, since the settings decide which ones are
+	// disabled, and plugins contribute to the settings window.
+	plugins, err := loadPlugins(s.Plugins.Disabled)
+	if err != nil {
+		log.Fatalln("Failed to load plugins:", err)
+	}
+	a.Plugins = plugins
+
 	s.initWidgets(a)
 	return s
 }
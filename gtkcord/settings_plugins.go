@@ -0,0 +1,336 @@
+package gtkcord
+
+import (
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/diamondburned/gotk4-handy/pkg/handy"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/preferences"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/config"
+	"github.com/diamondburned/gtkcord3/internal/log"
+	"github.com/pkg/errors"
+)
+
+func (s *Settings) initPluginsPage(a *Application) {
+	p := &s.Plugins
+
+	p.PreferencesPage = handy.NewPreferencesPage()
+	p.PreferencesPage.SetIconName("application-x-addon-symbolic")
+	p.PreferencesPage.SetTitle("Plugins")
+
+	install := gtk.NewButtonWithLabel("Install…")
+	install.SetVAlign(gtk.AlignCenter)
+	preferences.BindButton(install, func() { s.installPlugin(a) })
+
+	installGroup := handy.NewPreferencesGroup()
+	installGroup.Add(preferences.Row(
+		"Install a plugin",
+		"Go plugins must end in <tt>.so</tt>. Any other file is run as a program.",
+		install,
+	))
+	p.Add(installGroup)
+
+	p.List = handy.NewPreferencesGroup()
+	p.List.SetTitle("Installed")
+	p.List.SetDescription(
+		"Plugins are read from " + filepath.Join(config.Path, "plugins") + ". " +
+			"Enabling or disabling a Go plugin takes effect after a restart.",
+	)
+	p.Add(p.List)
+
+	for _, plugin := range a.Plugins {
+		p.List.Add(s.newPluginRow(a, plugin))
+	}
+}
+
+func (s *Settings) newPluginRow(a *Application, plugin *Plugin) *handy.ExpanderRow {
+	row := handy.NewExpanderRow()
+	row.SetTitle(plugin.Name)
+
+	enable := gtk.NewSwitch()
+	enable.SetVAlign(gtk.AlignCenter)
+	enable.SetActive(!plugin.Disabled)
+	enable.SetTooltipText("Enabled")
+	row.AddAction(enable)
+
+	if plugin.Hooks.Preferences != nil {
+		settings := gtk.NewButtonFromIconName("emblem-system-symbolic", int(gtk.IconSizeButton))
+		settings.SetVAlign(gtk.AlignCenter)
+		settings.SetTooltipText("Settings")
+		preferences.BindButton(settings, func() { s.presentPluginPage(plugin) })
+		row.AddAction(settings)
+	}
+
+	remove := gtk.NewButtonFromIconName("user-trash-symbolic", int(gtk.IconSizeButton))
+	remove.SetVAlign(gtk.AlignCenter)
+	remove.SetTooltipText("Remove")
+	row.AddAction(remove)
+
+	kind := "Go plugin"
+	if plugin.External {
+		kind = "External program"
+	}
+
+	row.Add(preferences.Row("Path", html.EscapeString(plugin.Path), nil))
+	row.Add(preferences.Row("Type", kind, nil))
+
+	errRow := preferences.Row("Error", "", nil)
+	errRow.SetNoShowAll(true)
+	row.Add(errRow)
+
+	update := func() {
+		name, author, err := pluginStatus(plugin)
+
+		row.SetTitle(name)
+
+		switch {
+		case plugin.Disabled:
+			row.SetSubtitle("Disabled")
+		case err != nil:
+			row.SetSubtitle("Failed to load")
+		default:
+			row.SetSubtitle(author)
+		}
+
+		if err != nil {
+			errRow.SetSubtitle(`<span color="red">` + html.EscapeString(err.Error()) + `</span>`)
+			errRow.Show()
+		} else {
+			errRow.Hide()
+		}
+	}
+
+	update()
+	// External plugins report their name and errors asynchronously.
+	row.Connect("notify::expanded", update)
+
+	enable.Connect("state-set", func(_ *gtk.Switch, state bool) bool {
+		s.setPluginEnabled(a, plugin, state)
+		update()
+		return false
+	})
+
+	preferences.BindButton(remove, func() {
+		ok := window.Confirm(
+			&s.PreferencesWindow.Window.Window,
+			"Remove Plugin",
+			"Remove "+plugin.Name+"? This deletes "+plugin.Path+".",
+			"Remove",
+		)
+		if !ok {
+			return
+		}
+
+		s.setPluginDisabled(plugin, false)
+		if a.removePlugin(plugin.Path) {
+			s.removePluginRow(plugin.Path)
+		}
+	})
+
+	if s.pluginRows == nil {
+		s.pluginRows = map[string]*handy.ExpanderRow{}
+	}
+	s.pluginRows[plugin.Path] = row
+
+	row.ShowAll()
+	return row
+}
+
+// pluginStatus returns the current name, author and error of the plugin.
+// External plugins only know these once they're running.
+func pluginStatus(plugin *Plugin) (name, author string, err error) {
+	if plugin.Process == nil {
+		return plugin.Name, plugin.Author, plugin.Err
+	}
+
+	info := plugin.Process.Info()
+	return info.Name, info.Author, plugin.Process.Err()
+}
+
+// setPluginDisabled adds or removes the plugin from the disabled list.
+func (s *Settings) setPluginDisabled(plugin *Plugin, disabled bool) {
+	name := filepath.Base(plugin.Path)
+	list := s.Plugins.Disabled[:0]
+
+	for _, d := range s.Plugins.Disabled {
+		if d != name {
+			list = append(list, d)
+		}
+	}
+
+	if disabled {
+		list = append(list, name)
+	}
+
+	s.Plugins.Disabled = list
+}
+
+// setPluginEnabled persists whether the plugin is enabled. External plugins
+// are started or stopped immediately if the application is ready.
+func (s *Settings) setPluginEnabled(a *Application, plugin *Plugin, enabled bool) {
+	s.setPluginDisabled(plugin, !enabled)
+	plugin.Disabled = !enabled
+
+	if !plugin.External {
+		// Go plugins can't be unloaded, so their hooks are skipped instead.
+		// One that was disabled at startup is only loaded on the next start.
+		return
+	}
+
+	if a.State == nil {
+		return
+	}
+
+	if enabled {
+		a.startExternalPlugin(plugin)
+	} else {
+		a.stopExternalPlugin(plugin)
+	}
+}
+
+// presentPluginPage shows the preferences page contributed by the plugin as a
+// subpage with a back button.
+func (s *Settings) presentPluginPage(plugin *Plugin) {
+	if s.pluginPages == nil {
+		s.pluginPages = map[string]gtk.Widgetter{}
+	}
+
+	subpage, ok := s.pluginPages[plugin.Path]
+	if !ok {
+		page := plugin.Hooks.Preferences()
+		if page == nil {
+			return
+		}
+
+		back := gtk.NewButtonFromIconName("go-previous-symbolic", int(gtk.IconSizeButton))
+		back.SetRelief(gtk.ReliefNone)
+		preferences.BindButton(back, s.CloseSubpage)
+
+		title := gtk.NewLabel(plugin.Name)
+		title.StyleContext().AddClass("title")
+
+		header := gtk.NewBox(gtk.OrientationHorizontal, 5)
+		header.SetMarginStart(5)
+		header.SetMarginTop(5)
+		header.PackStart(back, false, false, 0)
+		header.PackStart(title, false, false, 0)
+
+		box := gtk.NewBox(gtk.OrientationVertical, 0)
+		box.PackStart(header, false, false, 0)
+		box.PackStart(page, true, true, 0)
+		box.ShowAll()
+
+		subpage = box
+		s.pluginPages[plugin.Path] = box
+	}
+
+	s.PresentSubpage(subpage)
+}
+
+// installPlugin asks for a file and copies it into the plugins directory.
+func (s *Settings) installPlugin(a *Application) {
+	d := gtk.NewFileChooserNative(
+		"Install Plugin", &s.PreferencesWindow.Window.Window, gtk.FileChooserActionOpen, "Install", "",
+	)
+
+	if resp := d.Run(); gtk.ResponseType(resp) != gtk.ResponseAccept {
+		return
+	}
+
+	src := d.Filename()
+	external := !strings.HasSuffix(src, ".so")
+	dst := filepath.Join(config.Path, "plugins", filepath.Base(src))
+
+	if _, err := os.Stat(dst); err == nil {
+		ok := window.Confirm(
+			&s.PreferencesWindow.Window.Window,
+			"Replace Plugin",
+			filepath.Base(dst)+" is already installed. Replace it?",
+			"Replace",
+		)
+		if !ok {
+			return
+		}
+
+		// Drop the old entry; Go plugins keep running until a restart.
+		for i, plugin := range a.Plugins {
+			if plugin.Path == dst {
+				a.Plugins = append(a.Plugins[:i], a.Plugins[i+1:]...)
+				a.stopExternalPlugin(plugin)
+				break
+			}
+		}
+		s.removePluginRow(dst)
+	}
+
+	if err := copyPlugin(src, dst); err != nil {
+		log.Errorln("Failed to install plugin:", err)
+		return
+	}
+
+	plugin := newPlugin(dst, external, s.Plugins.Disabled)
+	a.Plugins = append(a.Plugins, plugin)
+
+	// Start the plugin right away if we're ready.
+	if a.State != nil {
+		if plugin.Err == nil && plugin.Hooks.Ready != nil {
+			plugin.Hooks.Ready(a)
+		}
+		a.startExternalPlugin(plugin)
+	}
+
+	s.Plugins.List.Add(s.newPluginRow(a, plugin))
+}
+
+func (s *Settings) removePluginRow(path string) {
+	if row, ok := s.pluginRows[path]; ok {
+		s.Plugins.List.Remove(row)
+		delete(s.pluginRows, path)
+	}
+	delete(s.pluginPages, path)
+}
+
+func copyPlugin(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to make plugins directory")
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to open plugin")
+	}
+	defer in.Close()
+
+	// Write to a new file and rename it over the old one, since the old one
+	// might be running or mapped in if it's a Go plugin.
+	out, err := os.CreateTemp(filepath.Dir(dst), ".installing-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create plugin file")
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrap(err, "failed to copy plugin")
+	}
+
+	// Plugins that aren't Go plugins are run, so they must be executable.
+	if err := out.Chmod(0755); err != nil {
+		return errors.Wrap(err, "failed to make plugin executable")
+	}
+
+	if err := out.Close(); err != nil {
+		return errors.Wrap(err, "failed to write plugin")
+	}
+
+	if err := os.Rename(out.Name(), dst); err != nil {
+		return errors.Wrap(err, "failed to replace plugin")
+	}
+
+	return nil
+}
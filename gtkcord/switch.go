@@ -2,13 +2,16 @@ package gtkcord
 
 import (
 	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/channel"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/guild"
 	"github.com/diamondburned/gtkcord3/gtkcord/components/window"
+	"github.com/diamondburned/gtkcord3/gtkcord/threads"
 	"github.com/diamondburned/gtkcord3/internal/log"
 )
 
-// SwitchToID returns true if it can find the channel.
+// SwitchToID opens the channel. It returns true if it can find the channel
+// right away; otherwise, it's opened once its guild is loaded.
 func (a *Application) SwitchToID(chID discord.ChannelID, guildID discord.GuildID) bool {
 	guild, folder := a.Guilds.FindByID(guildID)
 
@@ -19,17 +22,22 @@ func (a *Application) SwitchToID(chID discord.ChannelID, guildID discord.GuildID
 	case guild != nil:
 		a.Guilds.Select(guild.ListBoxRow)
 
+		// Open the channel once the guild is loaded, if it isn't yet:
+		a.Channels.Remember(guildID, chID)
+
 		// Switch the channels view to the guild:
 		a.SwitchGuild(guild)
 
 		// Find the destination channel:
 		if channel := a.Channels.FindByID((chID)); channel != nil {
 			a.Channels.ChList.SelectRow(channel.Row)
+			channel.Row.Activate()
 			return true
 		}
 
 	default:
 		a.Guilds.Select(a.Guilds.DMButton.ListBoxRow)
+		a.Privates.Remember(chID)
 
 		// Switch the channels away to the private ones:
 		a.SwitchDM()
@@ -37,6 +45,7 @@ func (a *Application) SwitchToID(chID discord.ChannelID, guildID discord.GuildID
 		// Find the destination channel:
 		if channel := a.Privates.FindByID(chID); channel != nil {
 			a.Privates.List.SelectRow(channel.ListBoxRow)
+			channel.Activate()
 			return true
 		}
 	}
@@ -108,6 +117,9 @@ func (a *Application) prepChannelSwitch() {
 	for _, cleaner := range cleaners {
 		cleaner.Cleanup()
 	}
+
+	// The friends page might be shown instead of the messages.
+	a.Right.SetChild(a.Messages)
 }
 
 func (a *Application) SwitchGuild(g *guild.Guild) {
@@ -163,3 +175,38 @@ func (a *Application) SwitchChannel(ch ChannelContainer) {
 		a.Header.ChMenuBtn.SetRevealChild(true)
 	}
 }
+
+// OpenThread shows the thread in the message view. Threads that aren't listed
+// in the channels, such as archived ones, are fetched first.
+func (a *Application) OpenThread(threadID discord.ChannelID) {
+	if a.leftIsGuild() {
+		if ch := a.Channels.FindByID(threadID); ch != nil {
+			a.Channels.ChList.SelectRow(ch.Row)
+			ch.Row.Activate()
+			return
+		}
+	}
+
+	go func() {
+		t, err := threads.Fetch(a.State, threadID)
+		if err != nil {
+			log.Errorln("failed to get thread:", err)
+			return
+		}
+
+		glib.IdleAdd(func() {
+			a.SwitchChannel(threadContainer{t})
+			a.FocusMessages()
+		})
+	}()
+}
+
+// threadContainer is a ChannelContainer for threads that aren't listed.
+type threadContainer struct{ *threads.Thread }
+
+func (t threadContainer) GuildID() discord.GuildID     { return t.Thread.GuildID }
+func (t threadContainer) ChannelID() discord.ChannelID { return t.ID }
+
+func (t threadContainer) ChannelInfo() (name, topic string) {
+	return t.Name, t.Topic
+}
@@ -0,0 +1,57 @@
+package threads
+
+import (
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+)
+
+type (
+	ThreadCreateEvent Thread
+	ThreadUpdateEvent Thread
+
+	ThreadDeleteEvent struct {
+		ID       discord.ChannelID   `json:"id"`
+		GuildID  discord.GuildID     `json:"guild_id"`
+		ParentID discord.ChannelID   `json:"parent_id"`
+		Type     discord.ChannelType `json:"type"`
+	}
+
+	// ThreadListSyncEvent is sent when the current user gains access to a
+	// channel. ChannelIDs are the channels whose threads are synced, or every
+	// channel if it's empty.
+	ThreadListSyncEvent struct {
+		GuildID    discord.GuildID     `json:"guild_id"`
+		ChannelIDs []discord.ChannelID `json:"channel_ids,omitempty"`
+		Threads    []Thread            `json:"threads"`
+		Members    []Member            `json:"members"`
+	}
+
+	// ThreadMemberUpdateEvent is sent when the current user joins a thread.
+	ThreadMemberUpdateEvent struct {
+		Member
+		GuildID discord.GuildID `json:"guild_id"`
+	}
+
+	ThreadMembersUpdateEvent struct {
+		ID               discord.ChannelID `json:"id"`
+		GuildID          discord.GuildID   `json:"guild_id"`
+		MemberCount      int               `json:"member_count"`
+		AddedMembers     []Member          `json:"added_members,omitempty"`
+		RemovedMemberIDs []discord.UserID  `json:"removed_member_ids,omitempty"`
+	}
+)
+
+func init() {
+	events := map[string]func() gateway.Event{
+		"THREAD_CREATE":         func() gateway.Event { return new(ThreadCreateEvent) },
+		"THREAD_UPDATE":         func() gateway.Event { return new(ThreadUpdateEvent) },
+		"THREAD_DELETE":         func() gateway.Event { return new(ThreadDeleteEvent) },
+		"THREAD_LIST_SYNC":      func() gateway.Event { return new(ThreadListSyncEvent) },
+		"THREAD_MEMBER_UPDATE":  func() gateway.Event { return new(ThreadMemberUpdateEvent) },
+		"THREAD_MEMBERS_UPDATE": func() gateway.Event { return new(ThreadMembersUpdateEvent) },
+	}
+
+	for name, fn := range events {
+		gateway.EventCreator[name] = fn
+	}
+}
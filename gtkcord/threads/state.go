@@ -0,0 +1,294 @@
+package threads
+
+import (
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/diamondburned/ningen/v2"
+)
+
+// State keeps track of the active threads in each guild. Guilds are fetched
+// the first time their threads are asked for, then kept up to date with the
+// gateway events.
+type State struct {
+	state *ningen.State
+
+	mutex    sync.RWMutex
+	guilds   map[discord.GuildID]map[discord.ChannelID]*Thread
+	onUpdate []func(guildID discord.GuildID)
+}
+
+// NewState creates a new thread state and binds it to the gateway.
+func NewState(s *ningen.State) *State {
+	t := &State{
+		state:  s,
+		guilds: make(map[discord.GuildID]map[discord.ChannelID]*Thread),
+	}
+
+	s.AddHandler(func(ev *ThreadCreateEvent) { t.set((*Thread)(ev)) })
+	s.AddHandler(func(ev *ThreadUpdateEvent) { t.set((*Thread)(ev)) })
+	s.AddHandler(t.onDelete)
+	s.AddHandler(t.onListSync)
+	s.AddHandler(t.onMemberUpdate)
+	s.AddHandler(t.onMembersUpdate)
+	s.AddHandler(t.onMessageCreate)
+
+	return t
+}
+
+// OnUpdate adds a callback that's called when the threads of a guild change.
+// It's not called in the main thread.
+func (t *State) OnUpdate(fn func(guildID discord.GuildID)) {
+	t.mutex.Lock()
+	t.onUpdate = append(t.onUpdate, fn)
+	t.mutex.Unlock()
+}
+
+func (t *State) updated(guildID discord.GuildID) {
+	t.mutex.RLock()
+	callbacks := t.onUpdate
+	t.mutex.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(guildID)
+	}
+}
+
+// Active returns the active threads in the guild. They're fetched if this is
+// the first time. This method should not be called in the main thread.
+func (t *State) Active(guildID discord.GuildID) ([]Thread, error) {
+	if threads, ok := t.cached(guildID); ok {
+		return threads, nil
+	}
+
+	fetched, err := FetchActive(t.state, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make(map[discord.ChannelID]*Thread, len(fetched))
+	for i := range fetched {
+		threads[fetched[i].ID] = &fetched[i]
+	}
+
+	t.mutex.Lock()
+	t.guilds[guildID] = threads
+	t.mutex.Unlock()
+
+	return fetched, nil
+}
+
+func (t *State) cached(guildID discord.GuildID) ([]Thread, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	guild, ok := t.guilds[guildID]
+	if !ok {
+		return nil, false
+	}
+
+	threads := make([]Thread, 0, len(guild))
+	for _, thread := range guild {
+		threads = append(threads, *thread)
+	}
+
+	return threads, true
+}
+
+// Known returns the active threads of every guild that was fetched. Unlike
+// Active, it never fetches.
+func (t *State) Known() []Thread {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	var threads []Thread
+	for _, guild := range t.guilds {
+		for _, thread := range guild {
+			threads = append(threads, *thread)
+		}
+	}
+
+	return threads
+}
+
+// Thread returns the active thread with the given ID, or false if it's not
+// known. Threads started from a message have the message's ID.
+func (t *State) Thread(id discord.ChannelID) (Thread, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, guild := range t.guilds {
+		if thread, ok := guild[id]; ok {
+			return *thread, true
+		}
+	}
+
+	return Thread{}, false
+}
+
+// Join adds the current user to the thread.
+func (t *State) Join(thread Thread) error {
+	if err := Join(t.state, thread.ID); err != nil {
+		return err
+	}
+
+	me, _ := t.state.Me()
+
+	t.setMember(thread.GuildID, thread.ID, &Member{
+		ID:            thread.ID,
+		UserID:        me.ID,
+		JoinTimestamp: discord.NewTimestamp(time.Now()),
+	})
+	return nil
+}
+
+// Leave removes the current user from the thread.
+func (t *State) Leave(thread Thread) error {
+	if err := Leave(t.state, thread.ID); err != nil {
+		return err
+	}
+
+	t.setMember(thread.GuildID, thread.ID, nil)
+	return nil
+}
+
+// set adds or updates a thread. Archived threads are removed, since they're
+// not active anymore.
+func (t *State) set(thread *Thread) {
+	t.mutex.Lock()
+
+	guild, ok := t.guilds[thread.GuildID]
+	if !ok {
+		// The guild will be fetched when it's needed.
+		t.mutex.Unlock()
+		return
+	}
+
+	if thread.Metadata.Archived {
+		delete(guild, thread.ID)
+	} else {
+		// Updates don't have the member, so keep the old one.
+		if old, ok := guild[thread.ID]; ok && thread.Member == nil {
+			thread.Member = old.Member
+		}
+		guild[thread.ID] = thread
+	}
+
+	t.mutex.Unlock()
+	t.updated(thread.GuildID)
+}
+
+func (t *State) setMember(guildID discord.GuildID, id discord.ChannelID, m *Member) {
+	t.mutex.Lock()
+
+	thread, ok := t.guilds[guildID][id]
+	if ok {
+		thread.Member = m
+	}
+
+	t.mutex.Unlock()
+
+	if ok {
+		t.updated(guildID)
+	}
+}
+
+func (t *State) onDelete(ev *ThreadDeleteEvent) {
+	t.mutex.Lock()
+	delete(t.guilds[ev.GuildID], ev.ID)
+	t.mutex.Unlock()
+
+	t.updated(ev.GuildID)
+}
+
+func (t *State) onListSync(ev *ThreadListSyncEvent) {
+	t.mutex.Lock()
+
+	guild, ok := t.guilds[ev.GuildID]
+	if !ok {
+		t.mutex.Unlock()
+		return
+	}
+
+	synced := make(map[discord.ChannelID]bool, len(ev.ChannelIDs))
+	for _, id := range ev.ChannelIDs {
+		synced[id] = true
+	}
+
+	for id, thread := range guild {
+		if len(synced) == 0 || synced[thread.ParentID()] {
+			delete(guild, id)
+		}
+	}
+
+	for i := range ev.Threads {
+		guild[ev.Threads[i].ID] = &ev.Threads[i]
+	}
+
+	for i, member := range ev.Members {
+		if thread, ok := guild[member.ID]; ok {
+			thread.Member = &ev.Members[i]
+		}
+	}
+
+	t.mutex.Unlock()
+	t.updated(ev.GuildID)
+}
+
+func (t *State) onMemberUpdate(ev *ThreadMemberUpdateEvent) {
+	member := ev.Member
+	t.setMember(ev.GuildID, ev.ID, &member)
+}
+
+func (t *State) onMembersUpdate(ev *ThreadMembersUpdateEvent) {
+	me, _ := t.state.Me()
+
+	t.mutex.Lock()
+
+	thread, ok := t.guilds[ev.GuildID][ev.ID]
+	if !ok {
+		t.mutex.Unlock()
+		return
+	}
+
+	thread.MemberCount = ev.MemberCount
+
+	for i, member := range ev.AddedMembers {
+		if member.UserID == me.ID {
+			thread.Member = &ev.AddedMembers[i]
+		}
+	}
+
+	for _, id := range ev.RemovedMemberIDs {
+		if id == me.ID {
+			thread.Member = nil
+		}
+	}
+
+	t.mutex.Unlock()
+	t.updated(ev.GuildID)
+}
+
+// onMessageCreate counts the messages sent in threads, since Discord doesn't
+// send thread updates for them.
+func (t *State) onMessageCreate(ev *gateway.MessageCreateEvent) {
+	if !ev.GuildID.IsValid() {
+		return
+	}
+
+	t.mutex.Lock()
+
+	thread, ok := t.guilds[ev.GuildID][ev.ChannelID]
+	if ok {
+		thread.MessageCount++
+		thread.LastMessageID = ev.ID
+	}
+
+	t.mutex.Unlock()
+
+	if ok {
+		t.updated(ev.GuildID)
+	}
+}
@@ -0,0 +1,157 @@
+// Package threads adds the thread channels that arikawa doesn't know about yet.
+// It has the thread types, the REST endpoints and gateway events for them, and
+// a State that keeps track of the active threads of each guild.
+package threads
+
+import (
+	"github.com/diamondburned/arikawa/v2/api"
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/diamondburned/ningen/v2"
+)
+
+const (
+	GuildNewsThread    discord.ChannelType = 10
+	GuildPublicThread  discord.ChannelType = 11
+	GuildPrivateThread discord.ChannelType = 12
+)
+
+const (
+	// ThreadCreatedMessage is the system message sent in the parent channel
+	// when a thread is started. Its reference points to the thread.
+	ThreadCreatedMessage discord.MessageType = 18
+	// ThreadStarterMessage is the first message of a thread started from a
+	// message. Its reference points to that message.
+	ThreadStarterMessage discord.MessageType = 21
+)
+
+const (
+	PermissionCreatePublicThreads   discord.Permissions = 1 << 35
+	PermissionCreatePrivateThreads  discord.Permissions = 1 << 36
+	PermissionSendMessagesInThreads discord.Permissions = 1 << 38
+)
+
+// AutoArchiveDay is how long a new thread can go without messages, in minutes,
+// before it's archived.
+const AutoArchiveDay = 1440
+
+// IsThread returns true if the channel is a thread.
+func IsThread(t discord.ChannelType) bool {
+	return t == GuildNewsThread || t == GuildPublicThread || t == GuildPrivateThread
+}
+
+// Metadata is the state of a thread.
+type Metadata struct {
+	Archived            bool              `json:"archived"`
+	AutoArchiveDuration int               `json:"auto_archive_duration"`
+	ArchiveTimestamp    discord.Timestamp `json:"archive_timestamp"`
+	Locked              bool              `json:"locked,omitempty"`
+}
+
+// Member is a user that joined a thread.
+type Member struct {
+	// ID is the ID of the thread. It's omitted in threads.
+	ID            discord.ChannelID `json:"id,omitempty"`
+	UserID        discord.UserID    `json:"user_id,omitempty"`
+	JoinTimestamp discord.Timestamp `json:"join_timestamp"`
+}
+
+// Thread is a thread channel. Its CategoryID is the ID of the channel that the
+// thread is in, and its DMOwnerID is the user who started it.
+type Thread struct {
+	discord.Channel
+	Metadata Metadata `json:"thread_metadata"`
+
+	// MessageCount stops counting at 50.
+	MessageCount int `json:"message_count"`
+	MemberCount  int `json:"member_count"`
+
+	// Member is not nil if the current user joined the thread.
+	Member *Member `json:"member,omitempty"`
+}
+
+// ParentID returns the ID of the channel that the thread is in.
+func (t Thread) ParentID() discord.ChannelID {
+	return t.CategoryID
+}
+
+// Joined returns true if the current user joined the thread.
+func (t Thread) Joined() bool {
+	return t.Member != nil
+}
+
+// activeThreads is the response of the active threads endpoint.
+type activeThreads struct {
+	Threads []Thread `json:"threads"`
+	// Members are the thread members of the current user.
+	Members []Member `json:"members"`
+}
+
+// FetchActive fetches the active threads in the guild.
+func FetchActive(s *ningen.State, guildID discord.GuildID) ([]Thread, error) {
+	var active activeThreads
+
+	err := s.Client.Client.RequestJSON(
+		&active, "GET", api.EndpointGuilds+guildID.String()+"/threads/active",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	joined := make(map[discord.ChannelID]Member, len(active.Members))
+	for _, member := range active.Members {
+		joined[member.ID] = member
+	}
+
+	for i, thread := range active.Threads {
+		if member, ok := joined[thread.ID]; ok {
+			active.Threads[i].Member = &member
+		}
+	}
+
+	return active.Threads, nil
+}
+
+// Fetch fetches a thread, which may be archived.
+func Fetch(s *ningen.State, threadID discord.ChannelID) (*Thread, error) {
+	var thread *Thread
+	return thread, s.Client.Client.RequestJSON(
+		&thread, "GET", api.EndpointChannels+threadID.String(),
+	)
+}
+
+// StartFromMessage starts a public thread from the message. The thread has the
+// same ID as the message.
+func StartFromMessage(
+	s *ningen.State,
+	chID discord.ChannelID, msgID discord.MessageID, name string) (*Thread, error) {
+
+	var param struct {
+		Name                string `json:"name"`
+		AutoArchiveDuration int    `json:"auto_archive_duration"`
+	}
+
+	param.Name = name
+	param.AutoArchiveDuration = AutoArchiveDay
+
+	var thread *Thread
+	return thread, s.Client.Client.RequestJSON(
+		&thread, "POST",
+		api.EndpointChannels+chID.String()+"/messages/"+msgID.String()+"/threads",
+		httputil.WithJSONBody(param),
+	)
+}
+
+// Join adds the current user to the thread.
+func Join(s *ningen.State, threadID discord.ChannelID) error {
+	return s.Client.Client.FastRequest(
+		"PUT", api.EndpointChannels+threadID.String()+"/thread-members/@me",
+	)
+}
+
+// Leave removes the current user from the thread.
+func Leave(s *ningen.State, threadID discord.ChannelID) error {
+	return s.Client.Client.FastRequest(
+		"DELETE", api.EndpointChannels+threadID.String()+"/thread-members/@me",
+	)
+}
@@ -0,0 +1,28 @@
+// Package eventname maps gateway event types back to their Discord names.
+package eventname
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/gateway"
+)
+
+var (
+	namesOnce sync.Once
+	names     map[reflect.Type]string
+)
+
+// Of returns the Discord name of the event, such as MESSAGE_CREATE. False is
+// returned if the event isn't from Discord, such as ningen's own events.
+func Of(ev interface{}) (string, bool) {
+	namesOnce.Do(func() {
+		names = make(map[reflect.Type]string, len(gateway.EventCreator))
+		for name, fn := range gateway.EventCreator {
+			names[reflect.TypeOf(fn())] = name
+		}
+	})
+
+	name, ok := names[reflect.TypeOf(ev)]
+	return name, ok
+}
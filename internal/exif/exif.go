@@ -0,0 +1,149 @@
+// Package exif removes location metadata from JPEG files without re-encoding
+// them, so the rest of the metadata, like the orientation, is kept.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrNotJPEG   = errors.New("not a JPEG file")
+	ErrMalformed = errors.New("malformed EXIF data")
+)
+
+const gpsIFDTag = 0x8825
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// typeSizes maps TIFF field types to the size of one value.
+var typeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1,
+	7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// StripLocation clears the GPS fields of the JPEG's EXIF data in place. The
+// size of the file doesn't change. It returns true if there were GPS fields.
+func StripLocation(jpeg []byte) (bool, error) {
+	if len(jpeg) < 2 || jpeg[0] != 0xFF || jpeg[1] != 0xD8 {
+		return false, ErrNotJPEG
+	}
+
+	stripped := false
+
+	for i := 2; i+4 <= len(jpeg); {
+		if jpeg[i] != 0xFF {
+			return stripped, ErrNotJPEG
+		}
+
+		marker := jpeg[i+1]
+		// The image data starts at SOS, and metadata can't come after it.
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(jpeg[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(jpeg) {
+			return stripped, ErrNotJPEG
+		}
+
+		// APP1 holds EXIF.
+		if data := jpeg[i+4 : end]; marker == 0xE1 && bytes.HasPrefix(data, exifHeader) {
+			ok, err := stripTIFF(data[len(exifHeader):])
+			if err != nil {
+				return stripped, err
+			}
+			stripped = stripped || ok
+		}
+
+		i = end
+	}
+
+	return stripped, nil
+}
+
+func stripTIFF(tiff []byte) (bool, error) {
+	if len(tiff) < 8 {
+		return false, ErrMalformed
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return false, ErrMalformed
+	}
+
+	entries, err := ifdEntries(tiff, order, order.Uint32(tiff[4:]))
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if order.Uint16(entry) == gpsIFDTag {
+			return true, clearIFD(tiff, order, order.Uint32(entry[8:]))
+		}
+	}
+
+	return false, nil
+}
+
+// ifdEntries returns the 12-byte entries of the IFD at the offset.
+func ifdEntries(tiff []byte, order binary.ByteOrder, offset uint32) ([][]byte, error) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return nil, ErrMalformed
+	}
+
+	count := uint32(order.Uint16(tiff[offset:]))
+	start := offset + 2
+	if uint64(start)+uint64(count)*12 > uint64(len(tiff)) {
+		return nil, ErrMalformed
+	}
+
+	entries := make([][]byte, count)
+	for i := range entries {
+		pos := start + uint32(i)*12
+		entries[i] = tiff[pos : pos+12]
+	}
+
+	return entries, nil
+}
+
+// clearIFD zeroes the IFD's entries and the values they point to, then marks
+// the IFD as empty.
+func clearIFD(tiff []byte, order binary.ByteOrder, offset uint32) error {
+	entries, err := ifdEntries(tiff, order, offset)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		// The count can be anything, so this mustn't overflow.
+		size := uint64(typeSizes[order.Uint16(entry[2:])]) * uint64(order.Uint32(entry[4:]))
+
+		// Values that don't fit in the entry are stored elsewhere.
+		if size > 4 {
+			valueOffset := uint64(order.Uint32(entry[8:]))
+			if size > uint64(len(tiff)) || valueOffset+size > uint64(len(tiff)) {
+				return ErrMalformed
+			}
+			zero(tiff[valueOffset : valueOffset+size])
+		}
+
+		zero(entry)
+	}
+
+	order.PutUint16(tiff[offset:], 0)
+	return nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,119 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// makeJPEG returns a JPEG with an EXIF segment whose GPS IFD has a latitude
+// reference stored inline and a latitude stored after the IFD.
+func makeJPEG(order binary.ByteOrder) (jpeg []byte, latitude []byte) {
+	var tiff bytes.Buffer
+	w := func(v interface{}) { binary.Write(&tiff, order, v) }
+
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	w(uint16(42))
+	w(uint32(8)) // IFD0
+
+	// IFD0 with the GPS pointer and an orientation.
+	w(uint16(2))
+	w([]uint16{gpsIFDTag, 4})
+	w(uint32(1))
+	w(uint32(38)) // GPS IFD
+	w([]uint16{0x0112, 3})
+	w(uint32(1))
+	w([]uint16{6, 0})
+	w(uint32(0))
+
+	// GPS IFD at 38.
+	w(uint16(2))
+	w([]uint16{1, 2}) // GPSLatitudeRef
+	w(uint32(2))
+	tiff.WriteString("N\x00\x00\x00")
+	w([]uint16{2, 5}) // GPSLatitude
+	w(uint32(3))
+	w(uint32(68))
+	w(uint32(0))
+
+	// Latitude at 68.
+	latStart := tiff.Len()
+	w([]uint32{52, 1, 31, 1, 12, 1})
+	latitude = tiff.Bytes()[latStart:]
+
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&b, binary.BigEndian, uint16(2+len(exifHeader)+tiff.Len()))
+	b.Write(exifHeader)
+	b.Write(tiff.Bytes())
+	b.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0x12, 0x34, 0xFF, 0xD9})
+
+	jpeg = b.Bytes()
+	tiffStart := 4 + 2 + len(exifHeader)
+	return jpeg, jpeg[tiffStart+latStart : tiffStart+latStart+len(latitude)]
+}
+
+func TestStripLocation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		jpeg, latitude := makeJPEG(order)
+		size := len(jpeg)
+
+		stripped, err := StripLocation(jpeg)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", order, err)
+		}
+		if !stripped {
+			t.Fatalf("%v: GPS data wasn't found", order)
+		}
+		if len(jpeg) != size {
+			t.Fatalf("%v: size changed from %d to %d", order, size, len(jpeg))
+		}
+		if !bytes.Equal(latitude, make([]byte, len(latitude))) {
+			t.Errorf("%v: latitude wasn't cleared: %v", order, latitude)
+		}
+		if bytes.Contains(jpeg, []byte("N\x00\x00\x00")) {
+			t.Errorf("%v: latitude reference wasn't cleared", order)
+		}
+
+		// The orientation is kept.
+		tiff := jpeg[4+2+len(exifHeader):]
+		entries, err := ifdEntries(tiff, order, 8)
+		if err != nil || len(entries) != 2 || order.Uint16(entries[1][8:]) != 6 {
+			t.Errorf("%v: orientation was changed", order)
+		}
+
+		gps, err := ifdEntries(tiff, order, 38)
+		if err != nil || len(gps) != 0 {
+			t.Errorf("%v: GPS IFD wasn't emptied", order)
+		}
+	}
+}
+
+func TestStripLocationOverflow(t *testing.T) {
+	jpeg, _ := makeJPEG(binary.LittleEndian)
+
+	// The latitude's count times its size overflows 32 bits to 8.
+	tiff := jpeg[4+2+len(exifHeader):]
+	binary.LittleEndian.PutUint32(tiff[38+2+12+4:], 0x20000001)
+
+	if _, err := StripLocation(jpeg); err != ErrMalformed {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+}
+
+func TestStripLocationWithoutEXIF(t *testing.T) {
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9}
+
+	stripped, err := StripLocation(jpeg)
+	if err != nil || stripped {
+		t.Fatalf("expected nothing to strip, got %v, %v", stripped, err)
+	}
+
+	if _, err := StripLocation([]byte("\x89PNG")); err != ErrNotJPEG {
+		t.Fatalf("expected ErrNotJPEG, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package frecency ranks things by how frequently and how recently they were
+// used, so that something used a lot last month doesn't stay above what's
+// being used today forever.
+package frecency
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// MaxEntries is the maximum number of entries kept. The lowest ranked
+	// entries are forgotten first.
+	MaxEntries = 200
+	// maxUses is the number of recent uses that are kept for each entry.
+	maxUses = 10
+)
+
+// buckets weighs uses by their age.
+var buckets = []struct {
+	age    time.Duration
+	weight float64
+}{
+	{4 * time.Hour, 100},
+	{24 * time.Hour, 80},
+	{7 * 24 * time.Hour, 60},
+	{30 * 24 * time.Hour, 40},
+	{90 * 24 * time.Hour, 20},
+}
+
+const oldWeight = 10
+
+// Entry is how something was used.
+type Entry struct {
+	// Count is the number of times it was ever used.
+	Count int `json:"count"`
+	// Uses are the times of the most recent uses in Unix seconds, oldest
+	// first.
+	Uses []int64 `json:"uses"`
+}
+
+// Store keeps track of uses. The zero value is ready to use, and it can be
+// saved as JSON. It isn't thread-safe.
+type Store struct {
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Use records a use of the key.
+func (s *Store) Use(key string, now time.Time) {
+	if s.Entries == nil {
+		s.Entries = map[string]*Entry{}
+	}
+
+	e, ok := s.Entries[key]
+	if !ok {
+		e = &Entry{}
+		s.Entries[key] = e
+	}
+
+	e.Count++
+	e.Uses = append(e.Uses, now.Unix())
+	if len(e.Uses) > maxUses {
+		e.Uses = e.Uses[len(e.Uses)-maxUses:]
+	}
+
+	if len(s.Entries) > MaxEntries {
+		s.prune(now)
+	}
+}
+
+// Remove forgets the key.
+func (s *Store) Remove(key string) {
+	delete(s.Entries, key)
+}
+
+// Score returns the score of the key, which is 0 if it was never used.
+func (s *Store) Score(key string, now time.Time) float64 {
+	e, ok := s.Entries[key]
+	if !ok || len(e.Uses) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, use := range e.Uses {
+		total += weight(now.Sub(time.Unix(use, 0)))
+	}
+
+	// The recent uses are a sample of all uses.
+	return total * float64(e.Count) / float64(len(e.Uses))
+}
+
+func weight(age time.Duration) float64 {
+	for _, b := range buckets {
+		if age < b.age {
+			return b.weight
+		}
+	}
+	return oldWeight
+}
+
+// Top returns up to n keys with the highest scores, highest first. Keys with
+// the same score are sorted by name. A negative n returns all keys.
+func (s *Store) Top(n int, now time.Time) []string {
+	keys := make([]string, 0, len(s.Entries))
+	scores := make(map[string]float64, len(s.Entries))
+
+	for key := range s.Entries {
+		keys = append(keys, key)
+		scores[key] = s.Score(key, now)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if scores[keys[i]] != scores[keys[j]] {
+			return scores[keys[i]] > scores[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	if n >= 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+
+	return keys
+}
+
+func (s *Store) prune(now time.Time) {
+	keep := make(map[string]bool, MaxEntries)
+	for _, key := range s.Top(MaxEntries, now) {
+		keep[key] = true
+	}
+
+	for key := range s.Entries {
+		if !keep[key] {
+			delete(s.Entries, key)
+		}
+	}
+}
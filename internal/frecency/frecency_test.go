@@ -0,0 +1,84 @@
+package frecency
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestRecentBeatsOld(t *testing.T) {
+	var s Store
+
+	// Used a lot, but months ago.
+	for i := 0; i < 5; i++ {
+		s.Use("old", now.Add(-120*24*time.Hour))
+	}
+
+	// Used a few times today.
+	for i := 0; i < 3; i++ {
+		s.Use("new", now.Add(-time.Hour))
+	}
+
+	s.Use("once", now.Add(-2*24*time.Hour))
+
+	want := []string{"new", "once", "old"}
+	if got := s.Top(-1, now); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected order: got %v, want %v", got, want)
+	}
+
+	if got := s.Top(1, now); !reflect.DeepEqual(got, want[:1]) {
+		t.Fatalf("unexpected top 1: %v", got)
+	}
+}
+
+func TestFrequentBeatsRare(t *testing.T) {
+	var s Store
+
+	s.Use("rare", now)
+	for i := 0; i < 4; i++ {
+		s.Use("frequent", now.Add(-time.Minute))
+	}
+
+	if s.Score("frequent", now) <= s.Score("rare", now) {
+		t.Fatal("frequent isn't ranked above rare")
+	}
+
+	if s.Score("missing", now) != 0 {
+		t.Fatal("missing key has a score")
+	}
+}
+
+func TestUsesAreCapped(t *testing.T) {
+	var s Store
+
+	for i := 0; i < maxUses*3; i++ {
+		s.Use("key", now)
+	}
+
+	e := s.Entries["key"]
+	if e.Count != maxUses*3 || len(e.Uses) != maxUses {
+		t.Fatalf("unexpected entry: count %d, %d uses", e.Count, len(e.Uses))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	var s Store
+
+	s.Use("favorite", now)
+	s.Use("favorite", now)
+
+	for i := 0; i < MaxEntries; i++ {
+		s.Use(strconv.Itoa(i), now.Add(-365*24*time.Hour))
+	}
+
+	if len(s.Entries) != MaxEntries {
+		t.Fatalf("expected %d entries, got %d", MaxEntries, len(s.Entries))
+	}
+
+	if _, ok := s.Entries["favorite"]; !ok {
+		t.Fatal("the highest ranked entry was pruned")
+	}
+}
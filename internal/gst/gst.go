@@ -0,0 +1,252 @@
+//go:build gstreamer
+// +build gstreamer
+
+// Package gst is a small binding to GStreamer's playbin, with a gtksink for
+// video. It only does what inline attachment playback needs. GStreamer is only
+// built in with the gstreamer tag; otherwise, Available always returns false.
+package gst
+
+// #cgo pkg-config: gstreamer-1.0 gtk+-3.0
+// #include <stdlib.h>
+// #include <gst/gst.h>
+// #include <gtk/gtk.h>
+//
+// static gboolean has_element(const char *name) {
+// 	GstElementFactory *factory = gst_element_factory_find(name);
+// 	if (factory == NULL) {
+// 		return FALSE;
+// 	}
+// 	gst_object_unref(factory);
+// 	return TRUE;
+// }
+//
+// // player_new creates a playbin for the URI. The returned widget is owned by
+// // the caller.
+// static GstElement *player_new(const char *uri, GtkWidget **widget) {
+// 	GstElement *playbin = gst_element_factory_make("playbin", NULL);
+// 	GstElement *sink = gst_element_factory_make("gtksink", NULL);
+// 	if (playbin == NULL || sink == NULL) {
+// 		if (playbin) gst_object_unref(playbin);
+// 		if (sink) gst_object_unref(sink);
+// 		return NULL;
+// 	}
+//
+// 	g_object_get(sink, "widget", widget, NULL);
+// 	g_object_set(playbin, "uri", uri, "video-sink", sink, NULL);
+// 	return playbin;
+// }
+//
+// static void player_set_volume(GstElement *p, double volume) {
+// 	g_object_set(p, "volume", volume, NULL);
+// }
+//
+// static gboolean player_query(GstElement *p, gboolean duration, gint64 *ns) {
+// 	if (duration) {
+// 		return gst_element_query_duration(p, GST_FORMAT_TIME, ns);
+// 	}
+// 	return gst_element_query_position(p, GST_FORMAT_TIME, ns);
+// }
+//
+// static gboolean player_seek(GstElement *p, gint64 ns) {
+// 	return gst_element_seek_simple(
+// 		p, GST_FORMAT_TIME, GST_SEEK_FLAG_FLUSH | GST_SEEK_FLAG_KEY_UNIT, ns);
+// }
+//
+// // player_poll drains the bus. It returns 1 on end of stream and 2 on error,
+// // in which case text is set and must be freed.
+// static int player_poll(GstElement *p, gboolean *missing, char **text) {
+// 	GstBus *bus = gst_element_get_bus(p);
+// 	GstMessage *msg;
+// 	int result = 0;
+//
+// 	while ((msg = gst_bus_pop_filtered(bus, GST_MESSAGE_EOS | GST_MESSAGE_ERROR))) {
+// 		if (GST_MESSAGE_TYPE(msg) == GST_MESSAGE_EOS) {
+// 			if (result == 0) result = 1;
+// 		} else if (result != 2) {
+// 			GError *err = NULL;
+// 			gst_message_parse_error(msg, &err, NULL);
+//
+// 			*missing =
+// 				(err->domain == GST_CORE_ERROR && err->code == GST_CORE_ERROR_MISSING_PLUGIN) ||
+// 				(err->domain == GST_STREAM_ERROR && err->code == GST_STREAM_ERROR_CODEC_NOT_FOUND) ||
+// 				(err->domain == GST_STREAM_ERROR && err->code == GST_STREAM_ERROR_TYPE_NOT_FOUND);
+// 			*text = g_strdup(err->message);
+//
+// 			g_error_free(err);
+// 			result = 2;
+// 		}
+// 		gst_message_unref(msg);
+// 	}
+//
+// 	gst_object_unref(bus);
+// 	return result;
+// }
+import "C"
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	externglib "github.com/diamondburned/gotk4/pkg/core/glib"
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnavailable is returned if GStreamer or the needed elements aren't
+	// installed.
+	ErrUnavailable = errors.New("GStreamer with playbin and gtksink is not available")
+	// ErrNoDecoder is the cause of errors from Poll when the media can't be
+	// decoded with the installed plugins.
+	ErrNoDecoder = errors.New("no decoder available")
+)
+
+var (
+	initOnce  sync.Once
+	available bool
+)
+
+// Available initializes GStreamer and returns true if it can play media.
+func Available() bool {
+	initOnce.Do(func() {
+		if C.gst_init_check(nil, nil, nil) == 0 {
+			return
+		}
+		available = hasElement("playbin") && hasElement("gtksink")
+	})
+
+	return available
+}
+
+func hasElement(name string) bool {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	return C.has_element(cname) != 0
+}
+
+// Player plays a single URI. Its methods must be called in the main thread.
+type Player struct {
+	pipeline *C.GstElement
+	widget   gtk.Widgetter
+	playing  bool
+}
+
+// NewPlayer creates a paused player for the URI.
+func NewPlayer(uri string) (*Player, error) {
+	if !Available() {
+		return nil, ErrUnavailable
+	}
+
+	curi := C.CString(uri)
+	defer C.free(unsafe.Pointer(curi))
+
+	var widget *C.GtkWidget
+
+	pipeline := C.player_new(curi, &widget)
+	if pipeline == nil {
+		return nil, ErrUnavailable
+	}
+
+	p := &Player{pipeline: pipeline}
+	if widget != nil {
+		obj := externglib.AssumeOwnership(unsafe.Pointer(widget))
+		p.widget = obj.Cast().(gtk.Widgetter)
+	}
+
+	p.setState(C.GST_STATE_PAUSED)
+	return p, nil
+}
+
+// Widget returns the widget that shows the video.
+func (p *Player) Widget() gtk.Widgetter {
+	return p.widget
+}
+
+func (p *Player) setState(state C.GstState) {
+	C.gst_element_set_state(p.pipeline, state)
+}
+
+// Playing returns true if the player is playing.
+func (p *Player) Playing() bool {
+	return p.playing
+}
+
+func (p *Player) Play() {
+	p.playing = true
+	p.setState(C.GST_STATE_PLAYING)
+}
+
+func (p *Player) Pause() {
+	p.playing = false
+	p.setState(C.GST_STATE_PAUSED)
+}
+
+// Seek seeks to the given position from the start.
+func (p *Player) Seek(pos time.Duration) {
+	C.player_seek(p.pipeline, C.gint64(pos.Nanoseconds()))
+}
+
+// Position returns the current position, or false if it's not known yet.
+func (p *Player) Position() (time.Duration, bool) {
+	return p.query(false)
+}
+
+// Duration returns the length of the media, or false if it's not known yet.
+func (p *Player) Duration() (time.Duration, bool) {
+	return p.query(true)
+}
+
+func (p *Player) query(duration bool) (time.Duration, bool) {
+	var ns C.gint64
+	var dur C.gboolean
+	if duration {
+		dur = 1
+	}
+
+	if C.player_query(p.pipeline, dur, &ns) == 0 {
+		return 0, false
+	}
+	return time.Duration(ns), true
+}
+
+// SetVolume sets the volume, where 1 is 100%.
+func (p *Player) SetVolume(volume float64) {
+	C.player_set_volume(p.pipeline, C.double(volume))
+}
+
+// Poll handles pending messages from the pipeline. It returns true once the
+// end is reached, or an error if playback failed. The error's cause is
+// ErrNoDecoder if a plugin is missing.
+func (p *Player) Poll() (bool, error) {
+	var missing C.gboolean
+	var text *C.char
+
+	switch C.player_poll(p.pipeline, &missing, &text) {
+	case 1:
+		p.playing = false
+		return true, nil
+	case 2:
+		defer C.g_free(C.gpointer(text))
+		p.playing = false
+
+		if missing != 0 {
+			return false, errors.Wrap(ErrNoDecoder, C.GoString(text))
+		}
+		return false, errors.New(C.GoString(text))
+	}
+
+	return false, nil
+}
+
+// Close stops playback and frees the pipeline.
+func (p *Player) Close() {
+	if p.pipeline == nil {
+		return
+	}
+
+	p.setState(C.GST_STATE_NULL)
+	C.gst_object_unref(C.gpointer(p.pipeline))
+	p.pipeline = nil
+}
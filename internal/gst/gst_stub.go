@@ -0,0 +1,35 @@
+//go:build !gstreamer
+// +build !gstreamer
+
+package gst
+
+import (
+	"time"
+
+	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnavailable = errors.New("built without GStreamer")
+	ErrNoDecoder   = errors.New("no decoder available")
+)
+
+// Available always returns false, since GStreamer isn't built in.
+func Available() bool { return false }
+
+// Player is never created without GStreamer.
+type Player struct{}
+
+func NewPlayer(uri string) (*Player, error) { return nil, ErrUnavailable }
+
+func (p *Player) Widget() gtk.Widgetter           { return nil }
+func (p *Player) Playing() bool                   { return false }
+func (p *Player) Play()                           {}
+func (p *Player) Pause()                          {}
+func (p *Player) Seek(time.Duration)              {}
+func (p *Player) Position() (time.Duration, bool) { return 0, false }
+func (p *Player) Duration() (time.Duration, bool) { return 0, false }
+func (p *Player) SetVolume(float64)               {}
+func (p *Player) Poll() (bool, error)             { return false, nil }
+func (p *Player) Close()                          {}
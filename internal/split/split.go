@@ -0,0 +1,254 @@
+// Package split splits long messages into ones that fit in Discord's length
+// limit. Code blocks are kept whole when possible, and blocks that are too
+// long are closed and reopened with the same language at every split.
+package split
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Limit is the maximum number of characters in a message.
+const Limit = 2000
+
+const fence = "```"
+
+// Length returns the number of characters in s as Discord counts them.
+func Length(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// Message splits content into messages that are at most limit characters long
+// once transform is applied to them. Messages are split between lines if
+// possible, then between words. transform may be nil.
+func Message(content string, limit int, transform func(string) string) []string {
+	s := splitter{limit: limit, transform: transform}
+
+	for _, b := range blocks(content) {
+		s.add(b)
+	}
+
+	s.flush()
+	return s.out
+}
+
+// block is a line, or a fenced code block with its fences.
+type block struct {
+	lines  []string
+	fenced bool
+	closed bool
+}
+
+func (b block) String() string {
+	return strings.Join(b.lines, "\n")
+}
+
+func blocks(content string) []block {
+	var (
+		blocks []block
+		code   *block
+	)
+
+	for _, line := range strings.Split(content, "\n") {
+		// A line with an odd number of fences opens or closes a block.
+		toggles := strings.Count(line, fence)%2 == 1
+
+		switch {
+		case code != nil:
+			code.lines = append(code.lines, line)
+			if toggles {
+				code.closed = true
+				blocks = append(blocks, *code)
+				code = nil
+			}
+		case toggles && strings.HasPrefix(strings.TrimSpace(line), fence):
+			code = &block{lines: []string{line}, fenced: true}
+		default:
+			blocks = append(blocks, block{lines: []string{line}})
+		}
+	}
+
+	if code != nil {
+		blocks = append(blocks, *code)
+	}
+
+	return blocks
+}
+
+type splitter struct {
+	limit     int
+	transform func(string) string
+
+	out []string
+	cur string
+	// hasCur is false if nothing has been added to cur yet, which isn't the
+	// same as cur being empty, since blank lines are kept.
+	hasCur bool
+}
+
+func (s *splitter) fits(str string) bool {
+	if s.transform != nil {
+		str = s.transform(str)
+	}
+	return Length(str) <= s.limit
+}
+
+func (s *splitter) add(b block) {
+	text := b.String()
+
+	if s.hasCur && s.fits(s.cur+"\n"+text) {
+		s.cur += "\n" + text
+		return
+	}
+
+	s.flush()
+	s.hasCur = true
+
+	if s.fits(text) {
+		s.cur = text
+		return
+	}
+
+	if b.fenced {
+		s.addFenced(b)
+		return
+	}
+
+	pieces := s.splitLong(text, nil)
+	if len(pieces) == 0 {
+		return
+	}
+
+	for _, piece := range pieces[:len(pieces)-1] {
+		s.emit(piece)
+	}
+	s.cur = pieces[len(pieces)-1]
+}
+
+// addFenced adds a code block that doesn't fit in one message. Every message
+// but the last gets a closing fence, and every one but the first gets the
+// opening fence again. If the fences leave too little room, such as when the
+// opening line is too long, the block is split like text instead.
+func (s *splitter) addFenced(b block) {
+	opener := b.lines[0]
+	inner := b.lines[1:]
+
+	if !s.fits(opener + "\n" + strings.Repeat("x", s.limit/4) + "\n" + fence) {
+		s.hasCur = false
+		for _, line := range b.lines {
+			s.add(block{lines: []string{line}})
+		}
+		return
+	}
+
+	closer := ""
+	if b.closed {
+		closer = inner[len(inner)-1]
+		inner = inner[:len(inner)-1]
+	}
+
+	wrap := func(body string) string {
+		return opener + "\n" + body + "\n" + fence
+	}
+
+	var body string
+	var hasBody bool
+
+	for _, line := range inner {
+		if hasBody && s.fits(wrap(body+"\n"+line)) {
+			body += "\n" + line
+			continue
+		}
+
+		if hasBody {
+			s.emit(wrap(body))
+		}
+
+		hasBody = true
+
+		if s.fits(wrap(line)) {
+			body = line
+			continue
+		}
+
+		pieces := s.splitLong(line, wrap)
+		if len(pieces) == 0 {
+			body = ""
+			continue
+		}
+
+		for _, piece := range pieces[:len(pieces)-1] {
+			s.emit(wrap(piece))
+		}
+		body = pieces[len(pieces)-1]
+	}
+
+	s.cur = opener
+	if hasBody {
+		s.cur += "\n" + body
+	}
+	if b.closed {
+		s.cur += "\n" + closer
+	}
+
+	// The closing fence may not fit anymore.
+	if !s.fits(s.cur) && hasBody {
+		s.emit(wrap(body))
+		s.cur = opener + "\n" + closer
+	}
+}
+
+// splitLong splits a line into pieces that fit once wrapped, preferring to
+// split after spaces. wrap may be nil.
+func (s *splitter) splitLong(line string, wrap func(string) string) []string {
+	fits := func(runes []rune) bool {
+		str := string(runes)
+		if wrap != nil {
+			str = wrap(str)
+		}
+		return s.fits(str)
+	}
+
+	var pieces []string
+
+	for runes := []rune(line); len(runes) > 0; {
+		// Find the longest prefix that fits, but take at least one rune so
+		// that this always ends.
+		lo, hi := 1, len(runes)
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if fits(runes[:mid]) {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+
+		n := lo
+		if n < len(runes) {
+			for i := n - 1; i > 0; i-- {
+				if runes[i] == ' ' {
+					n = i + 1
+					break
+				}
+			}
+		}
+
+		pieces = append(pieces, strings.TrimRight(string(runes[:n]), " "))
+		runes = runes[n:]
+	}
+
+	return pieces
+}
+
+func (s *splitter) emit(msg string) {
+	if strings.TrimSpace(msg) != "" {
+		s.out = append(s.out, strings.Trim(msg, "\n"))
+	}
+}
+
+func (s *splitter) flush() {
+	s.emit(s.cur)
+	s.cur = ""
+	s.hasCur = false
+}
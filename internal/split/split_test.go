@@ -0,0 +1,212 @@
+package split
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/gtkcord3/internal/zwsp"
+)
+
+func checkLengths(t *testing.T, msgs []string, limit int, transform func(string) string) {
+	t.Helper()
+
+	for i, msg := range msgs {
+		if transform != nil {
+			msg = transform(msg)
+		}
+		if n := Length(msg); n > limit {
+			t.Errorf("message %d is %d characters long, over %d:\n%s", i, n, limit, msg)
+		}
+	}
+}
+
+// checkFences checks that every message has balanced fences.
+func checkFences(t *testing.T, msgs []string) {
+	t.Helper()
+
+	for i, msg := range msgs {
+		if strings.Count(msg, fence)%2 != 0 {
+			t.Errorf("message %d has unbalanced fences:\n%s", i, msg)
+		}
+	}
+}
+
+func TestMessageShort(t *testing.T) {
+	msgs := Message("hello\nworld", Limit, nil)
+	if len(msgs) != 1 || msgs[0] != "hello\nworld" {
+		t.Fatalf("unexpected split: %q", msgs)
+	}
+}
+
+func TestMessageLines(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = strings.Repeat("a", 49)
+	}
+	content := strings.Join(lines, "\n")
+
+	msgs := Message(content, 500, nil)
+	checkLengths(t, msgs, 500, nil)
+
+	// 10 lines of 49 characters and 9 newlines fit in each message.
+	if len(msgs) != 10 {
+		t.Fatalf("expected 10 messages, got %d", len(msgs))
+	}
+
+	if joined := strings.Join(msgs, "\n"); joined != content {
+		t.Fatal("content changed after splitting")
+	}
+}
+
+func TestMessageWords(t *testing.T) {
+	content := strings.Repeat("word ", 100)
+
+	msgs := Message(content, 42, nil)
+	checkLengths(t, msgs, 42, nil)
+
+	for i, msg := range msgs {
+		if strings.HasSuffix(msg, " ") || strings.HasPrefix(msg, " ") {
+			t.Errorf("message %d has surrounding spaces: %q", i, msg)
+		}
+		for _, word := range strings.Fields(msg) {
+			if word != "word" {
+				t.Errorf("message %d has a split word: %q", i, msg)
+			}
+		}
+	}
+}
+
+func TestMessageRunes(t *testing.T) {
+	// Each rune is 3 bytes, but counts as one character.
+	content := strings.Repeat("日", 25)
+
+	msgs := Message(content, 10, nil)
+	checkLengths(t, msgs, 10, nil)
+
+	if len(msgs) != 3 || strings.Join(msgs, "") != content {
+		t.Fatalf("unexpected split: %q", msgs)
+	}
+}
+
+func TestMessageKeepsFences(t *testing.T) {
+	code := "```go\nfunc main() {\n\tprintln(1)\n}\n```"
+	content := strings.Repeat("b", 30) + "\n" + code + "\nafter"
+
+	msgs := Message(content, 50, nil)
+	checkLengths(t, msgs, 50, nil)
+	checkFences(t, msgs)
+
+	found := false
+	for _, msg := range msgs {
+		if strings.Contains(msg, code) {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("code block was split even though it fits: %q", msgs)
+	}
+}
+
+func TestMessageSplitsLongFences(t *testing.T) {
+	lines := []string{"```go"}
+	for i := 0; i < 50; i++ {
+		lines = append(lines, "fmt.Println(\"line\")")
+	}
+	lines = append(lines, "```", "done")
+
+	msgs := Message(strings.Join(lines, "\n"), 200, nil)
+	checkLengths(t, msgs, 200, nil)
+	checkFences(t, msgs)
+
+	if len(msgs) < 2 {
+		t.Fatalf("expected several messages, got %d", len(msgs))
+	}
+
+	for i, msg := range msgs[:len(msgs)-1] {
+		if !strings.HasPrefix(msg, "```go\n") {
+			t.Errorf("message %d doesn't reopen the block: %q", i, msg)
+		}
+		if !strings.HasSuffix(msg, "\n```") {
+			t.Errorf("message %d doesn't close the block: %q", i, msg)
+		}
+	}
+
+	if last := msgs[len(msgs)-1]; !strings.HasSuffix(last, "```\ndone") {
+		t.Errorf("text after the block is misplaced: %q", last)
+	}
+
+	var count int
+	for _, msg := range msgs {
+		count += strings.Count(msg, "fmt.Println")
+	}
+	if count != 50 {
+		t.Errorf("expected 50 lines of code, got %d", count)
+	}
+}
+
+func TestMessageLongFencedLine(t *testing.T) {
+	content := "```\n" + strings.Repeat("x", 100) + "\n```"
+
+	msgs := Message(content, 40, nil)
+	checkLengths(t, msgs, 40, nil)
+	checkFences(t, msgs)
+
+	var xs int
+	for _, msg := range msgs {
+		xs += strings.Count(msg, "x")
+	}
+	if xs != 100 {
+		t.Errorf("expected 100 characters of code, got %d", xs)
+	}
+}
+
+func TestMessageLongOpener(t *testing.T) {
+	long := strings.Repeat("x", 2500)
+
+	tests := []string{
+		"```" + long + "\n\nfoo\n```",
+		"```" + long + "\nfoo\n```",
+		"```json {\"a\":\"" + long + "\"}\n```",
+	}
+
+	for _, content := range tests {
+		msgs := Message(content, Limit, nil)
+		checkLengths(t, msgs, Limit, nil)
+
+		var xs int
+		for _, msg := range msgs {
+			xs += strings.Count(msg, "x")
+		}
+		if xs != 2500 {
+			t.Errorf("expected 2500 characters of the opener, got %d", xs)
+		}
+	}
+}
+
+func TestMessageZeroWidth(t *testing.T) {
+	content := strings.Repeat("abcdefgh ", 500)
+
+	// The content fits without zero-width spaces.
+	if plain := Message(content, 5000, nil); len(plain) != 1 {
+		t.Fatalf("expected 1 message without zwsp, got %d", len(plain))
+	}
+
+	msgs := Message(content, 5000, zwsp.Insert)
+	if len(msgs) < 2 {
+		t.Fatalf("expected zwsp to make the content too long, got %d message", len(msgs))
+	}
+	checkLengths(t, msgs, 5000, zwsp.Insert)
+}
+
+func TestMessageZeroWidthFences(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, "hello there, general kenobi")
+	}
+	lines = append(lines, "```", "code stays plain", "```")
+
+	msgs := Message(strings.Join(lines, "\n"), 300, zwsp.Insert)
+	checkLengths(t, msgs, 300, zwsp.Insert)
+	checkFences(t, msgs)
+}
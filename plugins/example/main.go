@@ -2,34 +2,108 @@ package main
 
 import (
 	"log"
+	"strings"
 
+	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/diamondburned/arikawa/v2/gateway"
-	"github.com/diamondburned/gtkcord3/gtkcord"
-	
+	"github.com/diamondburned/gotk4-handy/pkg/handy"
 	"github.com/diamondburned/gotk4/pkg/gtk/v3"
+	"github.com/diamondburned/gtkcord3/gtkcord"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/message"
+	"github.com/diamondburned/gtkcord3/gtkcord/components/preferences"
 )
 
 var (
-	Name   = "Example plugin"
-	Author = "Bluskript"
+	APIVersion = gtkcord.PluginAPIVersion
+	Name       = "Example plugin"
+	Author     = "Bluskript"
 )
 
-func Ready(a *gtkcord.Application) {
-	a.State.AddHandler(onTypingStart)
-	BAD_SEMAPHORE_CALL(func() {
-		a.Channels.Main.Add(pluginButton())
+// shout is toggled from the preferences page.
+var shout bool
+
+func Hooks() gtkcord.PluginHooks {
+	return gtkcord.PluginHooks{
+		Ready:       ready,
+		PreSend:     preSend,
+		Decorate:    decorate,
+		MessageMenu: messageMenu,
+		Commands: []gtkcord.PluginCommand{{
+			Name:        "shrug",
+			Description: "Appends ¯\\_(ツ)_/¯ to your message.",
+			Run: func(chID discord.ChannelID, args string) (string, error) {
+				return strings.TrimSpace(args + ` ¯\_(ツ)_/¯`), nil
+			},
+		}},
+		Preferences: preferencesPage,
+		Shutdown: func() {
+			log.Println("Example plugin shutting down")
+		},
+	}
+}
+
+func ready(a *gtkcord.Application) {
+	a.State.AddHandler(func(t *gateway.TypingStartEvent) {
+		// Handlers are called in another goroutine, so widgets must be
+		// touched in the main thread.
+		gtkcord.MainThread(func() {
+			log.Println("Typing start from plugin in", a.Messages.ChannelID())
+		})
+	})
+
+	a.Channels.Main.Add(pluginButton())
+}
+
+func preSend(chID discord.ChannelID, content string) string {
+	if shout {
+		return strings.ToUpper(content)
+	}
+	return content
+}
+
+func decorate(msg *message.Message) {
+	if !strings.Contains(strings.ToLower(msg.Author), "bot") {
+		return
+	}
+
+	l := gtk.NewLabel("Possibly a bot")
+	l.SetXAlign(0)
+	l.SetOpacity(0.5)
+	l.Show()
+	msg.AddDecoration(l)
+}
+
+func messageMenu(msg *message.Message, menu *gtk.Menu) {
+	item := gtk.NewMenuItemWithLabel("Log Message ID")
+	item.Connect("activate", func() {
+		log.Println("Message ID:", msg.ID)
 	})
+	item.Show()
+	menu.Add(item)
+}
+
+func preferencesPage() *handy.PreferencesPage {
+	sw := gtk.NewSwitch()
+	preferences.BindSwitch(sw, &shout)
+
+	row := preferences.Row("Shout", "Send every message in uppercase.", sw)
+
+	group := handy.NewPreferencesGroup()
+	group.SetTitle("Example plugin")
+	group.Add(row)
+
+	page := handy.NewPreferencesPage()
+	page.SetTitle("Example")
+	page.SetIconName("application-x-addon-symbolic")
+	page.Add(group)
+
+	return page
 }
 
 func pluginButton() *gtk.Button {
-	// Gtk errors can be ignored, things will panic on their own anyway.
 	pb := gtk.NewButton()
 	pb.SetLabel("BOTTOM TEXT")
 	pb.SetSizeRequest(128, 128)
 	pb.Show()
 	return pb
 }
-
-func onTypingStart(t *gateway.TypingStartEvent) {
-	log.Println("Typing start from plugin")
-}